@@ -0,0 +1,100 @@
+package bucketing
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var ErrInvalidBuckets = errors.New("hashutils: number of buckets must be positive")
+
+var ErrInvalidWeights = errors.New("hashutils: weights must be finite, not negative and not all zero")
+
+var ErrInvalidRange = errors.New("hashutils: range bounds must be between 0 and 1, the lower first")
+
+// Fraction returns the position of the key for the salt, uniform in
+// [0, 1) over the keys. A different salt, say one per experiment, gives
+// independent positions to the same keys. The construction is fixed, so
+// that any release or other language places a key at the same position:
+//
+//	message  = uint32 big endian byte length of salt || salt || key
+//	digest   = SHA-256(message)
+//	u        = uint64 big endian of the first 8 bytes of digest
+//	fraction = (u >> 11) / 2^53
+//
+// with the salt and the key taken as their UTF-8 bytes. The fraction is
+// exactly representable as an IEEE 754 double.
+func Fraction(salt string, key string) float64 {
+	message := make([]byte, 4, 4+len(salt)+len(key))
+	binary.BigEndian.PutUint32(message, uint32(len(salt)))
+	message = append(message, salt...)
+	message = append(message, key...)
+	digest := sha256.Sum256(message)
+	return float64(binary.BigEndian.Uint64(digest[:8])>>11) / (1 << 53)
+}
+
+// Bucket returns the bucket of the key for the salt among buckets of the
+// same size, floor(Fraction(salt, key) * buckets) computed in IEEE 754
+// doubles. The buckets split [0, 1) in order, so that the keys of the
+// first 10 of 100 buckets are those of a fraction below 0.1.
+func Bucket(salt string, key string, buckets int) (int, error) {
+	if buckets <= 0 {
+		return 0, ErrInvalidBuckets
+	}
+	b := int(math.Floor(Fraction(salt, key) * float64(buckets)))
+	if b >= buckets {
+		b = buckets - 1
+	}
+	return b, nil
+}
+
+// WeightedBucket returns the bucket of the key for the salt among
+// buckets of the relative sizes of the weights: the first index i whose
+// cumulative weight, w[0] + ... + w[i] summed in order in IEEE 754
+// doubles, exceeds Fraction(salt, key) times the sum of the weights.
+// Buckets of weight 0 get no keys.
+func WeightedBucket(salt string, key string, weights []float64) (int, error) {
+	total := 0.0
+	for _, weight := range weights {
+		if weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return 0, ErrInvalidWeights
+		}
+		total += weight
+	}
+	if total == 0 || math.IsInf(total, 0) {
+		return 0, ErrInvalidWeights
+	}
+	target := Fraction(salt, key) * total
+	cumulative, last := 0.0, 0
+	for i, weight := range weights {
+		if weight == 0 {
+			continue
+		}
+		cumulative += weight
+		if target < cumulative {
+			return i, nil
+		}
+		last = i
+	}
+	// Rounding left the target at the total.
+	return last, nil
+}
+
+// InRange tells whether the fraction of the key for the salt is in
+// [from, to). Ranges of one salt that don't overlap select disjoint
+// keys, and widening a range only adds keys to it, as a rollout from 5%
+// to 20% with the range [0, 0.05) and then [0, 0.2).
+func InRange(salt string, key string, from float64, to float64) (bool, error) {
+	if !(from >= 0 && from <= to && to <= 1) {
+		return false, ErrInvalidRange
+	}
+	fraction := Fraction(salt, key)
+	return fraction >= from && fraction < to, nil
+}
+
+// Sample tells whether the key is among the share rate, from 0 to 1, of
+// the keys sampled for the salt, those of a fraction below rate.
+func Sample(salt string, key string, rate float64) bool {
+	return Fraction(salt, key) < rate
+}
@@ -0,0 +1,99 @@
+package bucketing
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The expected fractions were computed independently from the documented
+// construction.
+func TestFraction(t *testing.T) {
+	tests := []struct {
+		salt     string
+		key      string
+		fraction float64
+	}{
+		{"", "", 0.8720608707559775},
+		{"checkout-redesign", "user-42", 0.18807286923242938},
+		{"checkout-redesign", "user-43", 0.7825606530874695},
+		{"log-sampling", "request-7f3a", 0.804352964080019},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.fraction, Fraction(test.salt, test.key), "%q %q", test.salt, test.key)
+	}
+	// The length of the salt separates it from the key.
+	assert.NotEqual(t, Fraction("ab", "c"), Fraction("a", "bc"))
+}
+
+func TestBucket(t *testing.T) {
+	counts := make([]int, 10)
+	for i := 0; i < 100000; i++ {
+		b, err := Bucket("experiment", fmt.Sprintf("user-%d", i), len(counts))
+		require.NoError(t, err, "Error bucketing key")
+		counts[b]++
+	}
+	for _, count := range counts {
+		assert.InDelta(t, 10000, count, 500)
+	}
+
+	b, err := Bucket("checkout-redesign", "user-42", 100)
+	require.NoError(t, err, "Error bucketing key")
+	assert.Equal(t, 18, b)
+	_, err = Bucket("experiment", "user-1", 0)
+	assert.Equal(t, ErrInvalidBuckets, err)
+}
+
+func TestWeightedBucket(t *testing.T) {
+	weights := []float64{5, 0, 3, 2}
+	counts := make([]int, len(weights))
+	for i := 0; i < 100000; i++ {
+		b, err := WeightedBucket("experiment", fmt.Sprintf("user-%d", i), weights)
+		require.NoError(t, err, "Error bucketing key")
+		counts[b]++
+		// Weighted buckets split [0, 1) like equal ones.
+		fraction := Fraction("experiment", fmt.Sprintf("user-%d", i))
+		assert.Equal(t, fraction < 0.5, b == 0)
+	}
+	assert.InDelta(t, 50000, counts[0], 1000)
+	assert.Equal(t, 0, counts[1])
+	assert.InDelta(t, 30000, counts[2], 1000)
+	assert.InDelta(t, 20000, counts[3], 1000)
+
+	for _, weights := range [][]float64{nil, {0, 0}, {1, -1}, {1, math.NaN()}, {math.Inf(1)}} {
+		_, err := WeightedBucket("experiment", "user-1", weights)
+		assert.Equal(t, ErrInvalidWeights, err, "%v", weights)
+	}
+}
+
+func TestInRange(t *testing.T) {
+	small, large := 0, 0
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		inSmall, err := InRange("rollout", key, 0, 0.05)
+		require.NoError(t, err, "Error checking range")
+		inLarge, err := InRange("rollout", key, 0, 0.2)
+		require.NoError(t, err, "Error checking range")
+		inNext, err := InRange("rollout", key, 0.2, 1)
+		require.NoError(t, err, "Error checking range")
+		if inSmall {
+			small++
+			assert.True(t, inLarge, "Widening the range keeps %s", key)
+		}
+		if inLarge {
+			large++
+		}
+		assert.NotEqual(t, inLarge, inNext)
+		assert.Equal(t, inLarge, Sample("rollout", key, 0.2))
+	}
+	assert.InDelta(t, 500, small, 100)
+	assert.InDelta(t, 2000, large, 200)
+
+	for _, bounds := range [][2]float64{{-0.1, 0.5}, {0.5, 0.4}, {0, 1.5}, {math.NaN(), 1}} {
+		_, err := InRange("rollout", "user-1", bounds[0], bounds[1])
+		assert.Equal(t, ErrInvalidRange, err, "%v", bounds)
+	}
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sarathkumarsivan/hashutils/bucketing"
+)
+
+const (
+	FlagDescSalt    = "Salt the keys are bucketed with, one per experiment or sampling rule."
+	FlagDescBuckets = "Number of buckets of the same size."
+	FlagDescWeights = "Comma separated relative sizes of the buckets, instead of buckets of the same size."
+)
+
+const (
+	ErrMsgNoKeys         = "hashutils: no keys to bucket"
+	ErrMsgInvalidBuckets = "hashutils: number of buckets must be positive"
+	ErrMsgInvalidWeights = "hashutils: weights must be comma separated numbers, not negative and not all zero"
+)
+
+type BucketOptions struct {
+	salt    string
+	buckets int
+	weights []float64
+	output  string
+	pretty  bool
+	keys    []string
+}
+
+// bucketReport is the JSON form of the bucket of a key.
+type bucketReport struct {
+	Key      string  `json:"key"`
+	Bucket   int     `json:"bucket"`
+	Fraction float64 `json:"fraction"`
+}
+
+// ParseBucketCommandLine parses the arguments of the bucket subcommand,
+// which prints the bucket of the keys given as positional arguments.
+func ParseBucketCommandLine(args []string, errorHandling flag.ErrorHandling) (options BucketOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	salt := flags.String("s", "", FlagDescSalt)
+	buckets := flags.Int("n", 100, FlagDescBuckets)
+	weights := flags.String("w", "", FlagDescWeights)
+	output := flags.String("o", "text", FlagDescOutput)
+	pretty := flags.Bool("p", false, FlagDescPretty)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.salt = *salt
+	options.buckets = *buckets
+	options.output = *output
+	options.pretty = *pretty
+	options.keys = flags.Args()
+	if len(options.keys) == 0 {
+		Exit(ErrMsgNoKeys, flags)
+	}
+	if options.buckets <= 0 {
+		Exit(ErrMsgInvalidBuckets, flags)
+	}
+	if *weights != "" {
+		for _, field := range strings.Split(*weights, ",") {
+			weight, parseErr := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if parseErr != nil {
+				Exit(ErrMsgInvalidWeights, flags)
+			}
+			options.weights = append(options.weights, weight)
+		}
+		if _, weightsErr := bucketing.WeightedBucket("", "", options.weights); weightsErr != nil {
+			Exit(ErrMsgInvalidWeights, flags)
+		}
+	}
+	if options.output != "text" && options.output != "json" {
+		Exit(ErrMsgUnsupportedOutput, flags)
+	}
+	return
+}
+
+// bucketKeys prints the bucket of every key, as "bucket  key" lines or
+// as a JSON array also holding the fraction of the keys.
+func bucketKeys(options BucketOptions, stdout io.Writer, stderr io.Writer) int {
+	reports := make([]bucketReport, 0, len(options.keys))
+	for _, key := range options.keys {
+		var b int
+		var err error
+		if options.weights != nil {
+			b, err = bucketing.WeightedBucket(options.salt, key, options.weights)
+		} else {
+			b, err = bucketing.Bucket(options.salt, key, options.buckets)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s\n", err)
+			return 1
+		}
+		reports = append(reports, bucketReport{Key: key, Bucket: b, Fraction: bucketing.Fraction(options.salt, key)})
+	}
+	if options.output == "json" {
+		if options.pretty {
+			printAsPrettyJSON(stdout, reports)
+		} else {
+			printAsJSON(stdout, reports)
+		}
+		return 0
+	}
+	for _, report := range reports {
+		fmt.Fprintf(stdout, "%d  %s\n", report.Bucket, report.Key)
+	}
+	return 0
+}
+
+func executeBucket(args []string) int {
+	options, err := ParseBucketCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return bucketKeys(options, os.Stdout, os.Stderr)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBucketCommandLine(t *testing.T) {
+	args := []string{"bucket", "user-42"}
+	options, err := ParseBucketCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Empty(t, options.salt)
+	assert.Equal(t, 100, options.buckets)
+	assert.Nil(t, options.weights)
+	assert.Equal(t, "text", options.output)
+	assert.Equal(t, []string{"user-42"}, options.keys)
+
+	args = []string{"bucket", "-s", "checkout-redesign", "-w", "50, 30,20", "-o", "json", "-p", "user-42", "user-43"}
+	options, err = ParseBucketCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, "checkout-redesign", options.salt)
+	assert.Equal(t, []float64{50, 30, 20}, options.weights)
+	assert.Equal(t, "json", options.output)
+	assert.True(t, options.pretty)
+	assert.Equal(t, []string{"user-42", "user-43"}, options.keys)
+}
+
+func TestBucketKeys(t *testing.T) {
+	options := BucketOptions{salt: "checkout-redesign", buckets: 100, output: "text", keys: []string{"user-42", "user-43"}}
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, bucketKeys(options, &stdout, &stderr))
+	assert.Equal(t, "18  user-42\n78  user-43\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	options.weights = []float64{50, 30, 20}
+	options.output = "json"
+	stdout.Reset()
+	assert.Equal(t, 0, bucketKeys(options, &stdout, &stderr))
+	assert.Equal(t, `[{"key":"user-42","bucket":0,"fraction":0.18807286923242938},`+
+		`{"key":"user-43","bucket":1,"fraction":0.7825606530874695}]`+"\n", stdout.String())
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+const (
+	FlagDescCheckAlgorithm = "Algorithm of the checksums; guessed from each line if not specified."
+	FlagDescZero           = "Specify zero flag if lines are NUL terminated instead of newline terminated."
+	FlagDescQuiet          = "Don't print OK for each successfully verified file."
+	FlagDescStatus         = "Don't output anything, the exit status shows success."
+	FlagDescStrict         = "Exit non-zero for improperly formatted checksum lines."
+	FlagDescIgnoreMissing  = "Don't fail or report status for missing files."
+)
+
+type CheckOptions struct {
+	algorithm     hash.Algorithm
+	files         []string
+	zero          bool
+	quiet         bool
+	status        bool
+	strict        bool
+	ignoreMissing bool
+}
+
+// ParseCheckCommandLine parses the arguments of the check subcommand,
+// which verifies the checksum files given as positional arguments, or
+// the standard input if there are none, like sha256sum -c does.
+func ParseCheckCommandLine(args []string, errorHandling flag.ErrorHandling) (options CheckOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	algorithm := flags.String("a", "", FlagDescCheckAlgorithm)
+	zero := flags.Bool("z", false, FlagDescZero)
+	quiet := flags.Bool("quiet", false, FlagDescQuiet)
+	status := flags.Bool("status", false, FlagDescStatus)
+	strict := flags.Bool("strict", false, FlagDescStrict)
+	ignoreMissing := flags.Bool("ignore-missing", false, FlagDescIgnoreMissing)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.algorithm = hash.Algorithm(*algorithm)
+	options.files = flags.Args()
+	if len(options.files) == 0 {
+		options.files = []string{"-"}
+	}
+	options.zero = *zero
+	options.quiet = *quiet
+	options.status = *status
+	options.strict = *strict
+	options.ignoreMissing = *ignoreMissing
+	return
+}
+
+// check verifies the checksum files and returns the exit status: 0 if
+// every listed file was verified successfully, 1 otherwise.
+func check(options CheckOptions, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if options.status {
+		stdout, stderr = ioutil.Discard, ioutil.Discard
+	}
+	exit := 0
+	for _, name := range options.files {
+		if !checkFile(options, name, stdin, stdout, stderr) {
+			exit = 1
+		}
+	}
+	return exit
+}
+
+func checkFile(options CheckOptions, name string, stdin io.Reader, stdout io.Writer, stderr io.Writer) bool {
+	reader := stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s\n", err)
+			return false
+		}
+		defer file.Close()
+		reader = file
+	}
+	checksums, err := hash.ParseChecksums(reader, options.zero)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s: %s\n", name, err)
+		return false
+	}
+
+	malformed := len(checksums.Malformed)
+	var entries []hash.ChecksumEntry
+	// The lengths of the checksums whose algorithm can't be guessed.
+	var ambiguous []int
+	for _, entry := range checksums.Entries {
+		algorithm := options.algorithm
+		if algorithm == "" {
+			algorithm = entry.Algorithm
+		}
+		if algorithm == "" && len(hash.CandidateAlgorithms(entry)) > 0 {
+			// VerifyChecksums resolves the algorithm of ambiguous lines.
+			entries = append(entries, entry)
+			continue
+		}
+		if algorithm == "" {
+			if !containsInt(ambiguous, len(entry.Checksum)) {
+				ambiguous = append(ambiguous, len(entry.Checksum))
+			}
+			continue
+		}
+		if size, err := hash.Size(algorithm); err != nil || len(entry.Checksum) != 2*size {
+			malformed++
+			continue
+		}
+		entry.Algorithm = algorithm
+		entries = append(entries, entry)
+	}
+	for _, length := range ambiguous {
+		fmt.Fprintf(stderr, "hashutils: %s: algorithm of %d-digit checksums is ambiguous, use -a\n", name, length)
+	}
+	if len(entries) == 0 {
+		if len(ambiguous) == 0 {
+			fmt.Fprintf(stderr, "hashutils: %s: no properly formatted checksum lines found\n", name)
+		}
+		return false
+	}
+
+	results, err := hash.VerifyChecksums("", entries...)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s: %s\n", name, err)
+		return false
+	}
+	failed, unreadable, verified := 0, 0, 0
+	for _, result := range results {
+		path := displayPath(result.Entry.Path, options.zero)
+		switch result.Status {
+		case hash.CheckOK:
+			verified++
+			if !options.quiet {
+				fmt.Fprintf(stdout, "%s: OK\n", path)
+			}
+		case hash.CheckFailed:
+			verified++
+			failed++
+			fmt.Fprintf(stdout, "%s: FAILED\n", path)
+		case hash.CheckMissing, hash.CheckUnreadable:
+			if result.Status == hash.CheckMissing && options.ignoreMissing {
+				continue
+			}
+			unreadable++
+			fmt.Fprintf(stderr, "hashutils: %s\n", result.Err)
+			fmt.Fprintf(stdout, "%s: FAILED open or read\n", path)
+		}
+	}
+
+	if malformed > 0 {
+		fmt.Fprintf(stderr, "hashutils: WARNING: %s\n", plural(malformed, "line is", "lines are")+" improperly formatted")
+	}
+	if unreadable > 0 {
+		fmt.Fprintf(stderr, "hashutils: WARNING: %s\n", plural(unreadable, "listed file", "listed files")+" could not be read")
+	}
+	if failed > 0 {
+		fmt.Fprintf(stderr, "hashutils: WARNING: %s\n", plural(failed, "computed checksum", "computed checksums")+" did NOT match")
+	}
+	if options.ignoreMissing && verified == 0 {
+		fmt.Fprintf(stderr, "hashutils: %s: no file was verified\n", name)
+		return false
+	}
+	return failed == 0 && unreadable == 0 && len(ambiguous) == 0 && !(options.strict && malformed > 0)
+}
+
+// displayPath escapes the path the same way it is escaped in checksum
+// files, so every result takes exactly one line.
+func displayPath(path string, zero bool) string {
+	if zero || !strings.ContainsAny(path, "\\\r\n") {
+		return path
+	}
+	return "\\" + strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(path)
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func plural(count int, singular string, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
+
+func executeCheck(args []string) int {
+	options, err := ParseCheckCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return check(options, os.Stdin, os.Stdout, os.Stderr)
+}
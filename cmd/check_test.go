@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sha256Foo = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	sha256Bar = "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+)
+
+func TestParseCheckCommandLine(t *testing.T) {
+	args := []string{"check"}
+	options, err := ParseCheckCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm(""), options.algorithm)
+	assert.Equal(t, []string{"-"}, options.files)
+
+	args = []string{"check", "-a", "sha256", "--quiet", "--strict", "--ignore-missing", "-z", "SHA256SUMS"}
+	options, err = ParseCheckCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("sha256"), options.algorithm)
+	assert.Equal(t, []string{"SHA256SUMS"}, options.files)
+	assert.True(t, options.quiet)
+	assert.True(t, options.strict)
+	assert.True(t, options.ignoreMissing)
+	assert.True(t, options.zero)
+	assert.False(t, options.status)
+}
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "check")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	foo := filepath.Join(dir, "foo")
+	bar := filepath.Join(dir, "bar")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(bar, []byte("bar"), 0644))
+
+	run := func(sums string, options CheckOptions) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		options.files = []string{"-"}
+		exit := check(options, strings.NewReader(sums), &stdout, &stderr)
+		return exit, stdout.String(), stderr.String()
+	}
+
+	exit, stdout, stderr := run(sha256Foo+"  "+foo+"\nSHA256 ("+bar+") = "+sha256Bar+"\n", CheckOptions{})
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, foo+": OK\n"+bar+": OK\n", stdout)
+	assert.Empty(t, stderr)
+
+	exit, stdout, _ = run(sha256Foo+"  "+foo+"\n", CheckOptions{quiet: true})
+	assert.Equal(t, 0, exit)
+	assert.Empty(t, stdout)
+
+	exit, stdout, stderr = run(sha256Foo+"  "+foo+"\n"+sha256Foo+"  "+bar+"\n", CheckOptions{quiet: true})
+	assert.Equal(t, 1, exit)
+	assert.Equal(t, bar+": FAILED\n", stdout)
+	assert.Equal(t, "hashutils: WARNING: 1 computed checksum did NOT match\n", stderr)
+
+	exit, stdout, stderr = run(sha256Foo+"  "+foo+"\n"+sha256Foo+"  "+bar+"\n", CheckOptions{status: true})
+	assert.Equal(t, 1, exit)
+	assert.Empty(t, stdout)
+	assert.Empty(t, stderr)
+
+	missing := filepath.Join(dir, "baz")
+	exit, stdout, stderr = run(sha256Foo+"  "+foo+"\n"+sha256Foo+"  "+missing+"\n", CheckOptions{})
+	assert.Equal(t, 1, exit)
+	assert.Equal(t, foo+": OK\n"+missing+": FAILED open or read\n", stdout)
+	assert.Contains(t, stderr, "hashutils: WARNING: 1 listed file could not be read\n")
+
+	exit, stdout, stderr = run(sha256Foo+"  "+foo+"\n"+sha256Foo+"  "+missing+"\n", CheckOptions{ignoreMissing: true})
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, foo+": OK\n", stdout)
+	assert.Empty(t, stderr)
+
+	exit, _, stderr = run(sha256Foo+"  "+missing+"\n", CheckOptions{ignoreMissing: true})
+	assert.Equal(t, 1, exit)
+	assert.Equal(t, "hashutils: -: no file was verified\n", stderr)
+
+	b2sumFoo := "ca002330e69d3e6b84a46a56a6533fd79d51d97a3bb7cad6c2ff43b354185d6dc1e723fb3db4ae0737e120378424c714bb982d9dc5bbd7a0ab318240ddd18f8d"
+	exit, stdout, _ = run(b2sumFoo+"  "+foo+"\n", CheckOptions{algorithm: hash.Blake2bHash})
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, foo+": OK\n", stdout)
+	exit, stdout, _ = run(b2sumFoo+"  "+foo+"\n", CheckOptions{})
+	assert.Equal(t, 0, exit, "The algorithm of 128 digits is resolved")
+	assert.Equal(t, foo+": OK\n", stdout)
+
+	exit, _, stderr = run(sha256Foo+"  "+foo+"\nfoo bar\n", CheckOptions{})
+	assert.Equal(t, 0, exit)
+	assert.Equal(t, "hashutils: WARNING: 1 line is improperly formatted\n", stderr)
+
+	exit, _, _ = run(sha256Foo+"  "+foo+"\nfoo bar\n", CheckOptions{strict: true})
+	assert.Equal(t, 1, exit)
+
+	exit, _, stderr = run("foo bar\n", CheckOptions{})
+	assert.Equal(t, 1, exit)
+	assert.Equal(t, "hashutils: -: no properly formatted checksum lines found\n", stderr)
+
+	exit, stdout, _ = run(sha256Foo+"  "+foo+"\n", CheckOptions{algorithm: hash.Md5Hash})
+	assert.Equal(t, 1, exit)
+	assert.Empty(t, stdout)
+}
+
+func TestCheckCoreutilsOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "check")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	foo := filepath.Join(dir, "foo")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+
+	// The 128 digits of both tools are verified without -a.
+	for _, tool := range []string{"sha512sum", "b2sum"} {
+		sums, err := exec.Command(tool, foo).Output()
+		if err != nil {
+			t.Logf("Skipping %s output: %s", tool, err)
+			continue
+		}
+		var stdout, stderr bytes.Buffer
+		options := CheckOptions{files: []string{"-"}}
+		assert.Equal(t, 0, check(options, bytes.NewReader(sums), &stdout, &stderr), tool)
+		assert.Equal(t, foo+": OK\n", stdout.String(), tool)
+		assert.Empty(t, stderr.String(), tool)
+	}
+
+	var stdout, stderr bytes.Buffer
+	options := CheckOptions{files: []string{"-"}}
+	assert.Equal(t, 1, check(options, strings.NewReader("0123456789abcdef  "+foo+"\n"), &stdout, &stderr))
+	assert.Equal(t, "hashutils: -: algorithm of 16-digit checksums is ambiguous, use -a\n", stderr.String())
+}
@@ -2,20 +2,81 @@ package cmd
 
 import (
 	"flag"
+	"strings"
+
 	"github.com/sarathkumarsivan/hashutils/hash"
 	"github.com/sarathkumarsivan/hashutils/util"
 )
 
 const (
 	FlagDescAlgorithm = "Algorithm to be used to hash your text/file/directory."
-	FlagDescEncoding  = "Encoding to be used to encode the checksum."
+	FlagDescEncoding  = "Encoding to be used to encode the checksum (hex, hexupper, hexcolon, base64, base64url, base64raw, base64rawurl, base32, base32crockford, base58, base62, ascii85, z85, decimal)."
 	FlagDescText      = "Text to be hashed with the specified algorithm and encoding."
-	FlagDescFile      = "File to be hashed with the specified algorithm and encoding."
+	FlagDescFile      = "File or directory to be hashed with the specified algorithm and encoding."
 	FlagDescPretty    = "Specify pretty flag if you want formatted JSON."
+	FlagDescMultihash = "Specify multihash flag if you want a multibase-prefixed multihash."
+)
+
+const (
+	FlagDescInclude    = "Pattern of the files to hash in directories, .gitignore syntax with ** support; can be repeated."
+	FlagDescExclude    = "Pattern of the files and directories to skip in directories; can be repeated."
+	FlagDescIgnoreFile = "Name of ignore files, like .gitignore, whose patterns are skipped in directories; can be repeated."
+	FlagDescMaxDepth   = "Number of levels below directories to hash, 0 for all of them."
+	FlagDescLinks      = "Policy for symbolic links in directories: skip, target to hash the path they point to, or follow."
+	FlagDescStreams    = "Hash named pipes and character devices by reading them instead of skipping them."
+	FlagDescOneFS      = "Don't enter directories on other file systems."
 )
 
 const ErrMsgNotEnoughOptions = "hashutils: not enough options to perform hashing"
 
+// stringList is a flag.Value collecting the values of a flag that can
+// be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// walkFlags holds the flags selecting the files hashed in directories.
+type walkFlags struct {
+	include     stringList
+	exclude     stringList
+	ignoreFiles stringList
+	maxDepth    *int
+	links       *string
+	streams     *bool
+	oneFS       *bool
+}
+
+func addWalkFlags(flags *flag.FlagSet) *walkFlags {
+	w := &walkFlags{}
+	flags.Var(&w.include, "include", FlagDescInclude)
+	flags.Var(&w.exclude, "exclude", FlagDescExclude)
+	flags.Var(&w.ignoreFiles, "ignore-file", FlagDescIgnoreFile)
+	w.maxDepth = flags.Int("max-depth", 0, FlagDescMaxDepth)
+	w.links = flags.String("links", "", FlagDescLinks)
+	w.streams = flags.Bool("streams", false, FlagDescStreams)
+	w.oneFS = flags.Bool("one-file-system", false, FlagDescOneFS)
+	return w
+}
+
+func (w *walkFlags) options() hash.WalkOptions {
+	return hash.WalkOptions{
+		Include:       w.include,
+		Exclude:       w.exclude,
+		IgnoreFiles:   w.ignoreFiles,
+		MaxDepth:      *w.maxDepth,
+		Links:         hash.LinkPolicy(*w.links),
+		Streams:       *w.streams,
+		OneFileSystem: *w.oneFS,
+	}
+}
+
 func ParseCommandLine(args []string, errorHandling flag.ErrorHandling) (options Options, err error) {
 	flags := flag.NewFlagSet(args[0], errorHandling)
 	algorithm := flags.String("a", "sha1", FlagDescAlgorithm)
@@ -23,6 +84,8 @@ func ParseCommandLine(args []string, errorHandling flag.ErrorHandling) (options
 	text := flags.String("t", "", FlagDescText)
 	file := flags.String("f", "", FlagDescFile)
 	pretty := flags.Bool("p", false, FlagDescPretty)
+	multihash := flags.Bool("m", false, FlagDescMultihash)
+	walk := addWalkFlags(flags)
 
 	if err = flags.Parse(args[1:]); err != nil {
 		return
@@ -40,6 +103,8 @@ func ParseCommandLine(args []string, errorHandling flag.ErrorHandling) (options
 			options.valid = true
 		}
 		options.pretty = *pretty
+		options.multihash = *multihash
+		options.walk = walk.options()
 	}
 	if !options.valid {
 		Exit(ErrMsgNotEnoughOptions, flags)
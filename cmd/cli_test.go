@@ -233,4 +233,25 @@ func TestParseCommandLine(t *testing.T) {
 	assert.Equal(t, hash.Algorithm("sha512"), options.algorithm)
 	assert.Equal(t, hash.Encoding("base64"), options.encoding)
 	assert.Equal(t, "foo.txt", options.file)
+
+	args = []string{"hash", "-a", "sha256", "-t", "foo", "-e", "base58", "-m"}
+	options, err = ParseCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("sha256"), options.algorithm)
+	assert.Equal(t, hash.Encoding("base58"), options.encoding)
+	assert.Equal(t, "foo", options.text)
+	assert.True(t, options.multihash)
+
+	args = []string{"hash", "-f", "src", "-include", "*.go", "-exclude", "vendor", "-exclude", "**/testdata", "-ignore-file", ".gitignore", "-max-depth", "3", "-links", "follow", "-streams", "-one-file-system"}
+	options, err = ParseCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.WalkOptions{
+		Include:       []string{"*.go"},
+		Exclude:       []string{"vendor", "**/testdata"},
+		IgnoreFiles:   []string{".gitignore"},
+		MaxDepth:      3,
+		Links:         hash.LinkFollow,
+		Streams:       true,
+		OneFileSystem: true,
+	}, options.walk)
 }
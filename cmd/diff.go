@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+const FlagDescDiffAlgorithm = "Algorithm to be used to compare the content of the files."
+
+const ErrMsgTwoDirs = "hashutils: two directories to compare are required"
+
+type DiffOptions struct {
+	algorithm hash.Algorithm
+	output    string
+	pretty    bool
+	walk      hash.WalkOptions
+	oldDir    string
+	newDir    string
+}
+
+// ParseDiffCommandLine parses the arguments of the diff subcommand,
+// which compares the old and the new directory given as positional
+// arguments.
+func ParseDiffCommandLine(args []string, errorHandling flag.ErrorHandling) (options DiffOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	algorithm := flags.String("a", "sha256", FlagDescDiffAlgorithm)
+	output := flags.String("o", "text", FlagDescOutput)
+	pretty := flags.Bool("p", false, FlagDescPretty)
+	walk := addWalkFlags(flags)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.algorithm = hash.Algorithm(*algorithm)
+	options.output = *output
+	options.pretty = *pretty
+	options.walk = walk.options()
+	if flags.NArg() != 2 {
+		Exit(ErrMsgTwoDirs, flags)
+	}
+	options.oldDir = flags.Arg(0)
+	options.newDir = flags.Arg(1)
+	if options.output != "text" && options.output != "json" {
+		Exit(ErrMsgUnsupportedOutput, flags)
+	}
+	return
+}
+
+// printChanges prints the changes as a JSON array or as a line per
+// change, with renames written as "old -> new".
+func printChanges(options DiffOptions, changes []hash.TreeChange, stdout io.Writer) {
+	if options.output == "json" {
+		if changes == nil {
+			changes = []hash.TreeChange{}
+		}
+		if options.pretty {
+			printAsPrettyJSON(stdout, changes)
+		} else {
+			printAsJSON(stdout, changes)
+		}
+		return
+	}
+	for _, change := range changes {
+		if change.Status == hash.ChangeRenamed {
+			fmt.Fprintf(stdout, "%-8s %s -> %s\n", change.Status, change.OldPath, change.Path)
+			continue
+		}
+		fmt.Fprintf(stdout, "%-8s %s\n", change.Status, change.Path)
+	}
+}
+
+// diff compares the directories and prints the changes. Like diff(1),
+// the exit status is 0 if the trees are identical, 1 if they differ and
+// 2 if they couldn't be compared.
+func diff(options DiffOptions, stdout io.Writer, stderr io.Writer) int {
+	changes, err := hash.CompareTrees(options.algorithm, options.walk, options.oldDir, options.newDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s\n", err)
+		return 2
+	}
+	printChanges(options, changes, stdout)
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func executeDiff(args []string) int {
+	options, err := ParseDiffCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 2
+	}
+	return diff(options, os.Stdout, os.Stderr)
+}
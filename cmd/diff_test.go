@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiffCommandLine(t *testing.T) {
+	args := []string{"diff", "release", "deploy"}
+	options, err := ParseDiffCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("sha256"), options.algorithm)
+	assert.Equal(t, "text", options.output)
+	assert.Equal(t, "release", options.oldDir)
+	assert.Equal(t, "deploy", options.newDir)
+
+	args = []string{"diff", "-a", "md5", "-o", "json", "-p", "release", "deploy"}
+	options, err = ParseDiffCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("md5"), options.algorithm)
+	assert.Equal(t, "json", options.output)
+	assert.True(t, options.pretty)
+}
+
+func TestDiff(t *testing.T) {
+	oldDir, err := ioutil.TempDir("", "release")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(oldDir)
+	newDir, err := ioutil.TempDir("", "deploy")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(newDir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(oldDir, "foo"), []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(oldDir, "bar"), []byte("bar"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(newDir, "foo"), []byte("bar"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(newDir, "baz"), []byte("bar"), 0644))
+
+	options := DiffOptions{algorithm: "sha256", output: "text", oldDir: oldDir, newDir: newDir}
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, diff(options, &stdout, &stderr))
+	assert.Equal(t, "renamed  bar -> baz\nmodified foo\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	stdout.Reset()
+	options.newDir = oldDir
+	assert.Equal(t, 0, diff(options, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+
+	options.output = "json"
+	assert.Equal(t, 0, diff(options, &stdout, &stderr))
+	assert.Equal(t, "[]\n", stdout.String())
+
+	options.newDir = filepath.Join(oldDir, "missing")
+	assert.Equal(t, 2, diff(options, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "hashutils: ")
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+const (
+	FlagDescDupesAlgorithm = "Algorithm to be used to compare the content of the files."
+	FlagDescMinSize        = "Size in bytes below which files are ignored."
+	FlagDescOutput         = "Output format, text or json."
+	FlagDescLink           = "Replace duplicates with links to the first file of their set, hard or reflink."
+	FlagDescDryRun         = "Only report the duplicates that would be replaced with links."
+)
+
+const (
+	ErrMsgUnsupportedOutput   = "hashutils: unsupported output format"
+	ErrMsgUnsupportedLinkMode = "hashutils: unsupported link mode"
+)
+
+type DupesOptions struct {
+	algorithm hash.Algorithm
+	minSize   int64
+	walk      hash.WalkOptions
+	output    string
+	pretty    bool
+	link      hash.LinkMode
+	dryRun    bool
+	paths     []string
+}
+
+// ParseDupesCommandLine parses the arguments of the dupes subcommand,
+// which reports the duplicate files under the directories given as
+// positional arguments.
+func ParseDupesCommandLine(args []string, errorHandling flag.ErrorHandling) (options DupesOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	algorithm := flags.String("a", "sha256", FlagDescDupesAlgorithm)
+	minSize := flags.Int64("min-size", 1, FlagDescMinSize)
+	output := flags.String("o", "text", FlagDescOutput)
+	pretty := flags.Bool("p", false, FlagDescPretty)
+	link := flags.String("link", "", FlagDescLink)
+	dryRun := flags.Bool("n", false, FlagDescDryRun)
+	walk := addWalkFlags(flags)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.algorithm = hash.Algorithm(*algorithm)
+	options.minSize = *minSize
+	options.walk = walk.options()
+	options.output = *output
+	options.pretty = *pretty
+	options.link = hash.LinkMode(*link)
+	options.dryRun = *dryRun
+	options.paths = flags.Args()
+	if len(options.paths) == 0 {
+		Exit(ErrMsgNoPaths, flags)
+	}
+	if options.output != "text" && options.output != "json" {
+		Exit(ErrMsgUnsupportedOutput, flags)
+	}
+	if options.link != "" && options.link != hash.HardLink && options.link != hash.Reflink {
+		Exit(ErrMsgUnsupportedLinkMode, flags)
+	}
+	return
+}
+
+// printDuplicates prints the duplicate sets, as a JSON array or as text
+// where every path takes a line and sets are separated by an empty
+// line, like fdupes does.
+func printDuplicates(options DupesOptions, sets []hash.DuplicateSet, stdout io.Writer) {
+	if options.output == "json" {
+		if sets == nil {
+			sets = []hash.DuplicateSet{}
+		}
+		if options.pretty {
+			printAsPrettyJSON(stdout, sets)
+		} else {
+			printAsJSON(stdout, sets)
+		}
+		return
+	}
+	for i, set := range sets {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		for _, path := range set.Paths {
+			fmt.Fprintln(stdout, path)
+		}
+	}
+}
+
+// dedupeReport is the JSON form of a hash.DedupeResult.
+type dedupeReport struct {
+	Status    hash.DedupeStatus `json:"status"`
+	Original  string            `json:"original"`
+	Duplicate string            `json:"duplicate"`
+	Size      int64             `json:"size"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// printDedupeResults prints the results of replacing duplicates with
+// links, as a JSON array or as a "<status> duplicate -> original" line
+// per file, with the errors of failed files going to stderr.
+func printDedupeResults(options DupesOptions, results []hash.DedupeResult, stdout io.Writer, stderr io.Writer) {
+	reports := make([]dedupeReport, 0, len(results))
+	for _, result := range results {
+		report := dedupeReport{Status: result.Status, Original: result.Original, Duplicate: result.Duplicate, Size: result.Size}
+		if result.Err != nil {
+			report.Error = result.Err.Error()
+			fmt.Fprintf(stderr, "hashutils: %s: %s\n", result.Duplicate, result.Err)
+		}
+		reports = append(reports, report)
+		if options.output == "text" {
+			fmt.Fprintf(stdout, "<%s> %s -> %s\n", result.Status, result.Duplicate, result.Original)
+		}
+	}
+	if options.output == "json" {
+		if options.pretty {
+			printAsPrettyJSON(stdout, reports)
+		} else {
+			printAsJSON(stdout, reports)
+		}
+	}
+}
+
+// dupes finds the duplicate files and either prints them or, with a
+// link mode, replaces them with links. Files that can't be read are
+// reported and left out of the search. The exit status is 1 if the
+// search failed, a file couldn't be read or a duplicate couldn't be
+// replaced.
+func dupes(options DupesOptions, stdout io.Writer, stderr io.Writer) int {
+	exit := 0
+	duplicateOptions := hash.DuplicateOptions{
+		Algorithm: options.algorithm,
+		MinSize:   options.minSize,
+		Walk:      options.walk,
+		OnError: func(path string, err error) {
+			fmt.Fprintf(stderr, "hashutils: %s\n", err)
+			exit = 1
+		},
+	}
+	sets, err := hash.FindDuplicates(duplicateOptions, options.paths...)
+	if err != nil {
+		fmt.Fprintf(stderr, "error finding duplicates using algorithm %s, error: %s\n", options.algorithm, err)
+		return 1
+	}
+	if options.link == "" {
+		printDuplicates(options, sets, stdout)
+		return exit
+	}
+	results, err := hash.Deduplicate(sets, options.link, options.dryRun)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s\n", err)
+		return 1
+	}
+	printDedupeResults(options, results, stdout, stderr)
+	for _, result := range results {
+		if result.Status == hash.DedupeFailed {
+			return 1
+		}
+	}
+	return exit
+}
+
+func executeDupes(args []string) int {
+	options, err := ParseDupesCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return dupes(options, os.Stdout, os.Stderr)
+}
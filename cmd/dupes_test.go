@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDupesCommandLine(t *testing.T) {
+	args := []string{"dupes", "build"}
+	options, err := ParseDupesCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("sha256"), options.algorithm)
+	assert.Equal(t, int64(1), options.minSize)
+	assert.Equal(t, "text", options.output)
+	assert.Equal(t, []string{"build"}, options.paths)
+
+	args = []string{"dupes", "-a", "md5", "-min-size", "1024", "-exclude", "*.tmp", "-exclude", ".git/", "-o", "json", "build", "cache"}
+	options, err = ParseDupesCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("md5"), options.algorithm)
+	assert.Equal(t, int64(1024), options.minSize)
+	assert.Equal(t, []string{"*.tmp", ".git/"}, options.walk.Exclude)
+	assert.Equal(t, "json", options.output)
+	assert.Equal(t, []string{"build", "cache"}, options.paths)
+
+	args = []string{"dupes", "-link", "reflink", "-n", "build"}
+	options, err = ParseDupesCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.LinkMode("reflink"), options.link)
+	assert.True(t, options.dryRun)
+}
+
+func TestPrintDuplicates(t *testing.T) {
+	sets := []hash.DuplicateSet{
+		{Size: 3, Checksum: sha256Foo, Paths: []string{"a/foo", "b/foo"}},
+		{Size: 3, Checksum: sha256Bar, Paths: []string{"a/bar", "b/bar", "c/bar"}},
+	}
+	var stdout bytes.Buffer
+	printDuplicates(DupesOptions{output: "text"}, sets, &stdout)
+	assert.Equal(t, "a/foo\nb/foo\n\na/bar\nb/bar\nc/bar\n", stdout.String())
+}
+
+func TestPrintDuplicatesAsJSON(t *testing.T) {
+	var stdout bytes.Buffer
+	printDuplicates(DupesOptions{output: "json"}, nil, &stdout)
+	assert.Equal(t, "[]\n", stdout.String())
+
+	sets := []hash.DuplicateSet{{Size: 3, Checksum: sha256Foo, Paths: []string{"a/foo", "b/foo"}}}
+	stdout.Reset()
+	printDuplicates(DupesOptions{output: "json"}, sets, &stdout)
+	assert.Equal(t, `[{"size":3,"checksum":"`+sha256Foo+`","paths":["a/foo","b/foo"]}]`+"\n", stdout.String())
+}
+
+func TestDupesLink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dupes")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, ioutil.WriteFile(a, []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(b, []byte("foo"), 0644))
+
+	options := DupesOptions{algorithm: "sha256", minSize: 1, output: "text", link: hash.HardLink, dryRun: true, paths: []string{dir}}
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, dupes(options, &stdout, &stderr))
+	assert.Equal(t, "<would link> "+b+" -> "+a+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	options.dryRun = false
+	stdout.Reset()
+	assert.Equal(t, 0, dupes(options, &stdout, &stderr))
+	assert.Equal(t, "<linked> "+b+" -> "+a+"\n", stdout.String())
+	infoA, err := os.Stat(a)
+	require.NoError(t, err)
+	infoB, err := os.Stat(b)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(infoA, infoB))
+
+	stdout.Reset()
+	assert.Equal(t, 0, dupes(options, &stdout, &stderr))
+	assert.Empty(t, stdout.String(), "Hard links are not reported as duplicates")
+}
+
+func TestDupesUnreadable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dupes")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, ioutil.WriteFile(a, []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(b, []byte("foo"), 0644))
+
+	// The duplicates are still printed when a path can't be read.
+	options := DupesOptions{algorithm: "sha256", minSize: 1, output: "text", paths: []string{filepath.Join(dir, "missing"), dir}}
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 1, dupes(options, &stdout, &stderr))
+	assert.Equal(t, a+"\n"+b+"\n", stdout.String())
+	assert.Contains(t, stderr.String(), "hashutils: ")
+	assert.Contains(t, stderr.String(), "missing")
+}
+
+func TestPrintDedupeResultsAsJSON(t *testing.T) {
+	results := []hash.DedupeResult{
+		{Original: "a", Duplicate: "b", Size: 3, Status: hash.DedupeLinked},
+		{Original: "a", Duplicate: "c", Size: 3, Status: hash.DedupeFailed, Err: os.ErrPermission},
+	}
+	var stdout, stderr bytes.Buffer
+	printDedupeResults(DupesOptions{output: "json"}, results, &stdout, &stderr)
+	assert.Equal(t, `[{"status":"linked","original":"a","duplicate":"b","size":3},`+
+		`{"status":"failed","original":"a","duplicate":"c","size":3,"error":"permission denied"}]`+"\n", stdout.String())
+	assert.Equal(t, "hashutils: c: permission denied\n", stderr.String())
+}
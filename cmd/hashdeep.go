@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+const (
+	FlagDescHashDeepAlgorithms = "Comma separated algorithms to be used to hash every file."
+	FlagDescKnown              = "Known hashdeep file to audit the files against."
+)
+
+const ErrMsgNoPaths = "hashutils: no files or directories to hash"
+
+type HashDeepOptions struct {
+	algorithms []hash.Algorithm
+	known      string
+	walk       hash.WalkOptions
+	paths      []string
+}
+
+// ParseHashDeepCommandLine parses the arguments of the hashdeep
+// subcommand, which prints a hashdeep file of the files and directories
+// given as positional arguments, or audits them against a known one.
+func ParseHashDeepCommandLine(args []string, errorHandling flag.ErrorHandling) (options HashDeepOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	algorithms := flags.String("c", "md5,sha256", FlagDescHashDeepAlgorithms)
+	known := flags.String("k", "", FlagDescKnown)
+	walk := addWalkFlags(flags)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	for _, algorithm := range strings.Split(*algorithms, ",") {
+		options.algorithms = append(options.algorithms, hash.Algorithm(strings.TrimSpace(algorithm)))
+	}
+	options.known = *known
+	options.walk = walk.options()
+	options.paths = flags.Args()
+	if len(options.paths) == 0 {
+		Exit(ErrMsgNoPaths, flags)
+	}
+	return
+}
+
+// hashDeep writes the hashdeep file of the paths, or the audit report
+// if a known file is given, and returns the exit status. An audit fails
+// unless every file matched.
+func hashDeep(options HashDeepOptions, stdout io.Writer, stderr io.Writer) int {
+	var known []hash.HashDeepEntry
+	algorithms := options.algorithms
+	if options.known != "" {
+		file, err := os.Open(options.known)
+		if err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s\n", err)
+			return 1
+		}
+		defer file.Close()
+		algorithms, known, err = hash.ReadHashDeep(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s: %s\n", options.known, err)
+			return 1
+		}
+	}
+
+	entries, err := hash.HashDeep(algorithms, options.walk, options.paths...)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s\n", err)
+		return 1
+	}
+	if options.known == "" {
+		if err := hash.WriteHashDeep(stdout, algorithms, entries...); err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	counts := make(map[hash.AuditStatus]int)
+	for _, result := range hash.AuditHashDeep(known, entries) {
+		counts[result.Status]++
+		switch result.Status {
+		case hash.AuditMatched:
+		case hash.AuditMoved:
+			fmt.Fprintf(stdout, "%s: %s (known as %s)\n", result.Path, result.Status, result.KnownPath)
+		default:
+			fmt.Fprintf(stdout, "%s: %s\n", result.Path, result.Status)
+		}
+	}
+	fmt.Fprintf(stdout, "Files matched: %d\n", counts[hash.AuditMatched])
+	fmt.Fprintf(stdout, "Files moved: %d\n", counts[hash.AuditMoved])
+	fmt.Fprintf(stdout, "Files changed: %d\n", counts[hash.AuditChanged])
+	fmt.Fprintf(stdout, "New files found: %d\n", counts[hash.AuditNew])
+	fmt.Fprintf(stdout, "Known files not found: %d\n", counts[hash.AuditMissing])
+	if counts[hash.AuditMatched] != len(entries) || counts[hash.AuditMissing] > 0 {
+		fmt.Fprintln(stdout, "Audit failed")
+		return 1
+	}
+	fmt.Fprintln(stdout, "Audit passed")
+	return 0
+}
+
+func executeHashDeep(args []string) int {
+	options, err := ParseHashDeepCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return hashDeep(options, os.Stdout, os.Stderr)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHashDeepCommandLine(t *testing.T) {
+	args := []string{"hashdeep", "evidence"}
+	options, err := ParseHashDeepCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, []hash.Algorithm{hash.Md5Hash, hash.Sha256Hash}, options.algorithms)
+	assert.Equal(t, []string{"evidence"}, options.paths)
+
+	args = []string{"hashdeep", "-c", "sha1", "-k", "known.txt", "-exclude", "*.tmp", "evidence"}
+	options, err = ParseHashDeepCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, []hash.Algorithm{hash.Sha1Hash}, options.algorithms)
+	assert.Equal(t, "known.txt", options.known)
+	assert.Equal(t, []string{"*.tmp"}, options.walk.Exclude)
+}
+
+func TestHashDeepAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evidence")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	foo := filepath.Join(dir, "foo")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+
+	var manifest, stderr bytes.Buffer
+	options := HashDeepOptions{algorithms: []hash.Algorithm{hash.Md5Hash, hash.Sha256Hash}, paths: []string{dir}}
+	require.Equal(t, 0, hashDeep(options, &manifest, &stderr))
+	known := filepath.Join(dir, "..", filepath.Base(dir)+".hashdeep")
+	require.NoError(t, ioutil.WriteFile(known, manifest.Bytes(), 0644))
+	defer os.Remove(known)
+
+	var stdout bytes.Buffer
+	options.known = known
+	assert.Equal(t, 0, hashDeep(options, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "Audit passed")
+
+	require.NoError(t, os.Rename(foo, filepath.Join(dir, "bar")))
+	stdout.Reset()
+	assert.Equal(t, 1, hashDeep(options, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), filepath.Join(dir, "bar")+": moved (known as "+foo+")\n")
+	assert.Contains(t, stdout.String(), "Audit failed")
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/sarathkumarsivan/hashutils/imagehash"
+)
+
+const (
+	FlagDescImageMethod   = "Perceptual hashing method, ahash, dhash or phash."
+	FlagDescImageDistance = "Print the Hamming distance between the hashes of the two images instead of the hashes."
+)
+
+const (
+	ErrMsgUnsupportedImageMethod = "hashutils: unsupported perceptual hashing method"
+	ErrMsgTwoImages              = "hashutils: two images to compare are required"
+)
+
+type ImageOptions struct {
+	method   imagehash.Method
+	encoding hash.Encoding
+	output   string
+	pretty   bool
+	distance bool
+	paths    []string
+}
+
+// imageReport is the JSON form of the hash of an image.
+type imageReport struct {
+	Path   string           `json:"path"`
+	Method imagehash.Method `json:"method"`
+	Hash   string           `json:"hash"`
+}
+
+// ParseImageCommandLine parses the arguments of the image subcommand,
+// which computes the perceptual hashes of the images given as
+// positional arguments.
+func ParseImageCommandLine(args []string, errorHandling flag.ErrorHandling) (options ImageOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	method := flags.String("m", "phash", FlagDescImageMethod)
+	encoding := flags.String("e", "hex", FlagDescEncoding)
+	output := flags.String("o", "text", FlagDescOutput)
+	pretty := flags.Bool("p", false, FlagDescPretty)
+	distance := flags.Bool("d", false, FlagDescImageDistance)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.method = imagehash.Method(*method)
+	options.encoding = hash.Encoding(*encoding)
+	options.output = *output
+	options.pretty = *pretty
+	options.distance = *distance
+	options.paths = flags.Args()
+	if len(options.paths) == 0 {
+		Exit(ErrMsgNoPaths, flags)
+	}
+	if options.distance && len(options.paths) != 2 {
+		Exit(ErrMsgTwoImages, flags)
+	}
+	switch options.method {
+	case imagehash.AverageHash, imagehash.DifferenceHash, imagehash.PerceptualHash:
+	default:
+		Exit(ErrMsgUnsupportedImageMethod, flags)
+	}
+	if options.output != "text" && options.output != "json" {
+		Exit(ErrMsgUnsupportedOutput, flags)
+	}
+	return
+}
+
+// hashImage returns the perceptual hash of the image and its encoding.
+func hashImage(options ImageOptions, path string) (uint64, string, error) {
+	sum, err := imagehash.HashFile(path, options.method)
+	if err != nil {
+		return 0, "", err
+	}
+	encoded, err := hash.Encode(options.encoding, imagehash.Bytes(sum))
+	return sum, encoded, err
+}
+
+// hashImages prints the perceptual hash of every image, encoded like
+// the checksums of the other subcommands, as "hash  path" lines or as a
+// JSON array, or the distance between the hashes of two images. The
+// exit status is 1 if an image couldn't be hashed.
+func hashImages(options ImageOptions, stdout io.Writer, stderr io.Writer) int {
+	hashes := make([]uint64, 0, len(options.paths))
+	reports := make([]imageReport, 0, len(options.paths))
+	exit := 0
+	for _, path := range options.paths {
+		sum, encoded, err := hashImage(options, path)
+		if err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s: %s\n", path, err)
+			exit = 1
+			continue
+		}
+		hashes = append(hashes, sum)
+		reports = append(reports, imageReport{Path: path, Method: options.method, Hash: encoded})
+	}
+	if options.distance {
+		if exit == 0 {
+			fmt.Fprintln(stdout, imagehash.Distance(hashes[0], hashes[1]))
+		}
+		return exit
+	}
+	if options.output == "json" {
+		if options.pretty {
+			printAsPrettyJSON(stdout, reports)
+		} else {
+			printAsJSON(stdout, reports)
+		}
+		return exit
+	}
+	for _, report := range reports {
+		fmt.Fprintf(stdout, "%s  %s\n", report.Hash, report.Path)
+	}
+	return exit
+}
+
+func executeImage(args []string) int {
+	options, err := ParseImageCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return hashImages(options, os.Stdout, os.Stderr)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/sarathkumarsivan/hashutils/imagehash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImageCommandLine(t *testing.T) {
+	args := []string{"image", "a.png"}
+	options, err := ParseImageCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, imagehash.Method("phash"), options.method)
+	assert.Equal(t, hash.Encoding("hex"), options.encoding)
+	assert.Equal(t, "text", options.output)
+	assert.False(t, options.distance)
+	assert.Equal(t, []string{"a.png"}, options.paths)
+
+	args = []string{"image", "-m", "dhash", "-e", "base64", "-d", "a.png", "b.jpg"}
+	options, err = ParseImageCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, imagehash.Method("dhash"), options.method)
+	assert.Equal(t, hash.Encoding("base64"), options.encoding)
+	assert.True(t, options.distance)
+	assert.Equal(t, []string{"a.png", "b.jpg"}, options.paths)
+}
+
+// writePNG writes a horizontal gradient, inverted if asked to.
+func writePNG(t *testing.T, path string, inverted bool) {
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			v := uint8(x * 4)
+			if inverted {
+				v = 255 - v
+			}
+			img.SetGray(x, y, color.Gray{v})
+		}
+	}
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+	require.NoError(t, png.Encode(file, img))
+}
+
+func TestHashImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a.png"), filepath.Join(dir, "b.png")
+	writePNG(t, a, false)
+	writePNG(t, b, true)
+
+	options := ImageOptions{method: imagehash.DifferenceHash, encoding: hash.Hex, output: "text", paths: []string{a, b}}
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, hashImages(options, &stdout, &stderr))
+	assert.Equal(t, "ffffffffffffffff  "+a+"\n0000000000000000  "+b+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	options.distance = true
+	stdout.Reset()
+	assert.Equal(t, 0, hashImages(options, &stdout, &stderr))
+	assert.Equal(t, "64\n", stdout.String())
+
+	options = ImageOptions{method: imagehash.AverageHash, encoding: hash.Hex, output: "json", paths: []string{a, filepath.Join(dir, "missing.png")}}
+	stdout.Reset()
+	assert.Equal(t, 1, hashImages(options, &stdout, &stderr))
+	assert.Equal(t, `[{"path":"`+a+`","method":"ahash","hash":"0f0f0f0f0f0f0f0f"}]`+"\n", stdout.String())
+	assert.Contains(t, stderr.String(), "missing.png")
+}
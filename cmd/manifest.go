@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+const (
+	FlagDescManifestAlgorithms = "Comma separated algorithms to be used to hash every file."
+	FlagDescManifestBinary     = "Write the manifest in the compact binary format instead of JSON."
+	FlagDescManifestDiff       = "Compare the two manifests given as arguments instead of creating one."
+)
+
+const (
+	ErrMsgNoDir        = "hashutils: a directory to snapshot is required"
+	ErrMsgTwoManifests = "hashutils: two manifests to compare are required"
+)
+
+type ManifestOptions struct {
+	algorithms []hash.Algorithm
+	binary     bool
+	diff       bool
+	output     string
+	pretty     bool
+	walk       hash.WalkOptions
+	paths      []string
+}
+
+// ParseManifestCommandLine parses the arguments of the manifest
+// subcommand, which writes the manifest of the directory given as
+// positional argument, or compares the two manifests given as positional
+// arguments.
+func ParseManifestCommandLine(args []string, errorHandling flag.ErrorHandling) (options ManifestOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	algorithms := flags.String("c", "sha256", FlagDescManifestAlgorithms)
+	binary := flags.Bool("b", false, FlagDescManifestBinary)
+	diff := flags.Bool("diff", false, FlagDescManifestDiff)
+	output := flags.String("o", "text", FlagDescOutput)
+	pretty := flags.Bool("p", false, FlagDescPretty)
+	walk := addWalkFlags(flags)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	for _, algorithm := range strings.Split(*algorithms, ",") {
+		options.algorithms = append(options.algorithms, hash.Algorithm(strings.TrimSpace(algorithm)))
+	}
+	options.binary = *binary
+	options.diff = *diff
+	options.output = *output
+	options.pretty = *pretty
+	options.walk = walk.options()
+	options.paths = flags.Args()
+	switch {
+	case options.diff && len(options.paths) != 2:
+		Exit(ErrMsgTwoManifests, flags)
+	case !options.diff && len(options.paths) != 1:
+		Exit(ErrMsgNoDir, flags)
+	case options.output != "text" && options.output != "json":
+		Exit(ErrMsgUnsupportedOutput, flags)
+	}
+	return
+}
+
+// manifest writes the manifest of the directory, or compares the two
+// manifests with the exit status of the diff subcommand.
+func manifest(options ManifestOptions, stdout io.Writer, stderr io.Writer) int {
+	if options.diff {
+		return diffManifests(options, stdout, stderr)
+	}
+	manifest, err := hash.CreateManifest(options.paths[0], options.walk, options.algorithms...)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s\n", err)
+		return 1
+	}
+	format := hash.ManifestFormat(hash.ManifestJSON)
+	if options.binary {
+		format = hash.ManifestBinary
+	}
+	if err := hash.WriteManifest(stdout, manifest, format); err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func diffManifests(options ManifestOptions, stdout io.Writer, stderr io.Writer) int {
+	var manifests [2]*hash.Manifest
+	for i, path := range options.paths {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s\n", err)
+			return 2
+		}
+		manifests[i], err = hash.LoadManifest(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s: %s\n", path, err)
+			return 2
+		}
+	}
+	changes, err := hash.DiffManifests(manifests[0], manifests[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s\n", err)
+		return 2
+	}
+	printChanges(DiffOptions{output: options.output, pretty: options.pretty}, changes, stdout)
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func executeManifest(args []string) int {
+	options, err := ParseManifestCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return manifest(options, os.Stdout, os.Stderr)
+}
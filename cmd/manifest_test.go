@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifestCommandLine(t *testing.T) {
+	args := []string{"manifest", "release"}
+	options, err := ParseManifestCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, []hash.Algorithm{hash.Sha256Hash}, options.algorithms)
+	assert.False(t, options.binary)
+	assert.False(t, options.diff)
+	assert.Equal(t, []string{"release"}, options.paths)
+
+	args = []string{"manifest", "-c", "sha256,md5", "-b", "release"}
+	options, err = ParseManifestCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, []hash.Algorithm{hash.Sha256Hash, hash.Md5Hash}, options.algorithms)
+	assert.True(t, options.binary)
+
+	args = []string{"manifest", "-diff", "-o", "json", "v1.json", "v2.json"}
+	options, err = ParseManifestCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.True(t, options.diff)
+	assert.Equal(t, "json", options.output)
+	assert.Equal(t, []string{"v1.json", "v2.json"}, options.paths)
+}
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	tree := filepath.Join(dir, "tree")
+	require.NoError(t, os.Mkdir(tree, 0755))
+	foo := filepath.Join(tree, "foo")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+
+	var stdout, stderr bytes.Buffer
+	options := ManifestOptions{algorithms: []hash.Algorithm{hash.Sha256Hash}, paths: []string{tree}}
+	require.Equal(t, 0, manifest(options, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), sha256Foo)
+	v1 := filepath.Join(dir, "v1.json")
+	require.NoError(t, ioutil.WriteFile(v1, stdout.Bytes(), 0644))
+
+	require.NoError(t, ioutil.WriteFile(foo, []byte("bar"), 0644))
+	stdout.Reset()
+	options.binary = true
+	require.Equal(t, 0, manifest(options, &stdout, &stderr))
+	v2 := filepath.Join(dir, "v2.bin")
+	require.NoError(t, ioutil.WriteFile(v2, stdout.Bytes(), 0644))
+
+	stdout.Reset()
+	options = ManifestOptions{diff: true, output: "text", paths: []string{v1, v2}}
+	assert.Equal(t, 1, manifest(options, &stdout, &stderr))
+	assert.Equal(t, "modified foo\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	stdout.Reset()
+	options.paths = []string{v1, v1}
+	assert.Equal(t, 0, manifest(options, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+
+	options.paths = []string{v1, foo}
+	assert.Equal(t, 2, manifest(options, &stdout, &stderr))
+	assert.Equal(t, "hashutils: "+foo+": hashutils: invalid manifest\n", stderr.String())
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/sarathkumarsivan/hashutils/mph"
+)
+
+const (
+	FlagDescMPHPackage    = "Package of the generated Go source."
+	FlagDescMPHName       = "Name of the generated Go function."
+	FlagDescMPHFormat     = "Format of the perfect hash function, go for Go source or binary for its serialization."
+	FlagDescMPHOut        = "File to write the perfect hash function to instead of the standard output."
+	FlagDescMPHBucketSize = "Average number of keys per bucket, from 1 to 6, 0 for the default of 4."
+)
+
+const (
+	ErrMsgOneKeyFile         = "hashutils: one file of keys is required"
+	ErrMsgUnsupportedFormat  = "hashutils: unsupported perfect hash function format"
+	ErrMsgMissingPackageName = "hashutils: package and function names are required for Go source"
+)
+
+type MPHOptions struct {
+	pkg        string
+	name       string
+	format     string
+	out        string
+	bucketSize int
+	path       string
+}
+
+// ParseMPHCommandLine parses the arguments of the mph subcommand, which
+// builds the minimal perfect hash function of the keys of the file given
+// as positional argument, one per line, for instance from go generate:
+//
+//	//go:generate go run github.com/sarathkumarsivan/hashutils mph -package countries -name Country -out country.go codes.txt
+func ParseMPHCommandLine(args []string, errorHandling flag.ErrorHandling) (options MPHOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	pkg := flags.String("package", "", FlagDescMPHPackage)
+	name := flags.String("name", "", FlagDescMPHName)
+	format := flags.String("format", "go", FlagDescMPHFormat)
+	out := flags.String("out", "", FlagDescMPHOut)
+	bucketSize := flags.Int("bucket-size", 0, FlagDescMPHBucketSize)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.pkg = *pkg
+	options.name = *name
+	options.format = *format
+	options.out = *out
+	options.bucketSize = *bucketSize
+	if flags.NArg() != 1 {
+		Exit(ErrMsgOneKeyFile, flags)
+	}
+	options.path = flags.Arg(0)
+	switch options.format {
+	case "go":
+		if options.pkg == "" || options.name == "" {
+			Exit(ErrMsgMissingPackageName, flags)
+		}
+	case "binary":
+	default:
+		Exit(ErrMsgUnsupportedFormat, flags)
+	}
+	return
+}
+
+// readKeys returns the lines of the file, without their line endings,
+// skipping the empty ones.
+func readKeys(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var keys []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if key := strings.TrimSuffix(scanner.Text(), "\r"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, scanner.Err()
+}
+
+// generateMPH writes the minimal perfect hash function of the keys as Go
+// source or serialized, to the output file or to stdout. The exit status
+// is 1 if the keys couldn't be read or the function built.
+func generateMPH(options MPHOptions, stdout io.Writer, stderr io.Writer) int {
+	keys, err := readKeys(options.path)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s: %s\n", options.path, err)
+		return 1
+	}
+	table, err := mph.BuildStrings(keys, mph.Options{BucketSize: options.bucketSize})
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s: %s\n", options.path, err)
+		return 1
+	}
+	var output bytes.Buffer
+	if options.format == "go" {
+		err = table.WriteGo(&output, keys, mph.GoOptions{Package: options.pkg, Name: options.name})
+	} else {
+		var data []byte
+		data, err = table.MarshalBinary()
+		output.Write(data)
+	}
+	if err == nil && options.out != "" {
+		err = ioutil.WriteFile(options.out, output.Bytes(), 0644)
+	} else if err == nil {
+		_, err = stdout.Write(output.Bytes())
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func executeMPH(args []string) int {
+	options, err := ParseMPHCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return generateMPH(options, os.Stdout, os.Stderr)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/mph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMPHCommandLine(t *testing.T) {
+	args := []string{"mph", "-package", "countries", "-name", "Country", "codes.txt"}
+	options, err := ParseMPHCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, "countries", options.pkg)
+	assert.Equal(t, "Country", options.name)
+	assert.Equal(t, "go", options.format)
+	assert.Empty(t, options.out)
+	assert.Equal(t, 0, options.bucketSize)
+	assert.Equal(t, "codes.txt", options.path)
+
+	args = []string{"mph", "-format", "binary", "-out", "codes.mph", "-bucket-size", "6", "codes.txt"}
+	options, err = ParseMPHCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, "binary", options.format)
+	assert.Equal(t, "codes.mph", options.out)
+	assert.Equal(t, 6, options.bucketSize)
+}
+
+func TestGenerateMPH(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mph")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "codes.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("fr\r\nde\n\nus\ngb\n"), 0644))
+
+	options := MPHOptions{pkg: "countries", name: "Country", format: "go", path: path}
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, generateMPH(options, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "package countries\n")
+	assert.Contains(t, stdout.String(), "func Country(key string) int {\n")
+	assert.Empty(t, stderr.String())
+
+	options = MPHOptions{format: "binary", out: filepath.Join(dir, "codes.mph"), path: path}
+	stdout.Reset()
+	assert.Equal(t, 0, generateMPH(options, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+	data, err := ioutil.ReadFile(options.out)
+	require.NoError(t, err, "Error reading table")
+	var table mph.Table
+	require.NoError(t, table.UnmarshalBinary(data), "Error decoding table")
+	assert.Equal(t, 4, table.Len())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("fr\nde\nfr\n"), 0644))
+	assert.Equal(t, 1, generateMPH(options, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), mph.ErrDuplicateKey.Error())
+
+	options.path = filepath.Join(dir, "missing.txt")
+	assert.Equal(t, 1, generateMPH(options, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "missing.txt")
+}
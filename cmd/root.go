@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/sarathkumarsivan/hashutils/hash"
@@ -16,6 +17,8 @@ type Options struct {
 	file      string
 	valid     bool
 	pretty    bool
+	multihash bool
+	walk      hash.WalkOptions
 }
 
 type response struct {
@@ -24,24 +27,42 @@ type response struct {
 	Algorithm string `json:"algorithm,omitempty"`
 	Encoding  string `json:"encoding,omitempty"`
 	Hash      string `json:"hash,omitempty"`
+	// Walk records the options a directory was hashed with.
+	Walk *hash.WalkOptions `json:"walk,omitempty"`
 }
 
-func printAsJSON(response response) {
-	bytes, err := json.Marshal(response)
+func printAsJSON(w io.Writer, v interface{}) {
+	bytes, err := json.Marshal(v)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(w, err)
 		return
 	}
-	fmt.Println(string(bytes))
+	fmt.Fprintln(w, string(bytes))
 }
 
-func printAsPrettyJSON(response response) {
-	bytes, err := json.MarshalIndent(response, "", "  ")
+func printAsPrettyJSON(w io.Writer, v interface{}) {
+	bytes, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(w, err)
 		return
 	}
-	fmt.Println(string(bytes))
+	fmt.Fprintln(w, string(bytes))
+}
+
+// commands maps the subcommands to their entry points, which receive
+// the arguments following the program name and return the exit status.
+var commands = map[string]func(args []string) int{
+	"bucket":   executeBucket,
+	"check":    executeCheck,
+	"diff":     executeDiff,
+	"dupes":    executeDupes,
+	"hashdeep": executeHashDeep,
+	"image":    executeImage,
+	"manifest": executeManifest,
+	"mph":      executeMPH,
+	"similar":  executeSimilar,
+	"sri":      executeSRI,
+	"tag":      executeTag,
 }
 
 func Exit(message string, flags *flag.FlagSet) {
@@ -51,6 +72,11 @@ func Exit(message string, flags *flag.FlagSet) {
 }
 
 func Execute() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[1:]))
+		}
+	}
 	options, err := ParseCommandLine(os.Args, flag.ExitOnError)
 	if err != nil {
 		os.Exit(1)
@@ -63,27 +89,30 @@ func Execute() {
 	}
 
 	if options.text != "" {
-		maker := hash.New().Algorithm(options.algorithm).Encoding(options.encoding).Build()
+		maker := hash.New().Algorithm(options.algorithm).Encoding(options.encoding).Multihash(options.multihash).Build()
 		hash, err := maker.HashText(options.text)
 		if err != nil {
-			fmt.Errorf("error hasing text: %s using algorithm %s, error: %s", options.text, options.algorithm, err)
+			fmt.Fprintf(os.Stderr, "error hashing text: %s using algorithm %s, error: %s\n", options.text, options.algorithm, err)
 			os.Exit(1)
 		}
 		response.Hash = hash
 	}
 	if options.file != "" {
-		maker := hash.New().Algorithm(options.algorithm).Encoding(options.encoding).Build()
-		hash, err := maker.HashFile(options.file)
+		maker := hash.New().Algorithm(options.algorithm).Encoding(options.encoding).Multihash(options.multihash).Walk(options.walk).Build()
+		hash, err := maker.HashPath(options.file)
 		if err != nil {
-			fmt.Errorf("error hasing file: %s using algorithm %s, error: %s", options.text, options.algorithm, err)
+			fmt.Fprintf(os.Stderr, "error hashing file: %s using algorithm %s, error: %s\n", options.file, options.algorithm, err)
 			os.Exit(1)
 		}
 		response.Hash = hash
+		if info, err := os.Stat(options.file); err == nil && info.IsDir() {
+			response.Walk = &options.walk
+		}
 	}
 
 	if options.pretty {
-		printAsPrettyJSON(response)
+		printAsPrettyJSON(os.Stdout, response)
 	} else {
-		printAsJSON(response)
+		printAsJSON(os.Stdout, response)
 	}
 }
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarathkumarsivan/hashutils/fuzzy"
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+const (
+	FlagDescSimilarMethod = "Fuzzy hashing method, ssdeep or tlsh."
+	FlagDescThreshold     = "Lowest ssdeep score or highest TLSH distance of similar files, 0 for the default of the method (50 and 100)."
+)
+
+const ErrMsgUnsupportedMethod = "hashutils: unsupported fuzzy hashing method"
+
+type SimilarOptions struct {
+	method    fuzzy.Method
+	threshold int
+	minSize   int64
+	walk      hash.WalkOptions
+	output    string
+	pretty    bool
+	paths     []string
+}
+
+// ParseSimilarCommandLine parses the arguments of the similar
+// subcommand, which clusters the similar files under the directories
+// given as positional arguments.
+func ParseSimilarCommandLine(args []string, errorHandling flag.ErrorHandling) (options SimilarOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	method := flags.String("m", "ssdeep", FlagDescSimilarMethod)
+	threshold := flags.Int("t", 0, FlagDescThreshold)
+	minSize := flags.Int64("min-size", 1, FlagDescMinSize)
+	output := flags.String("o", "text", FlagDescOutput)
+	pretty := flags.Bool("p", false, FlagDescPretty)
+	walk := addWalkFlags(flags)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.method = fuzzy.Method(*method)
+	options.threshold = *threshold
+	options.minSize = *minSize
+	options.walk = walk.options()
+	options.output = *output
+	options.pretty = *pretty
+	options.paths = flags.Args()
+	if len(options.paths) == 0 {
+		Exit(ErrMsgNoPaths, flags)
+	}
+	if options.method != fuzzy.MethodSSDeep && options.method != fuzzy.MethodTLSH {
+		Exit(ErrMsgUnsupportedMethod, flags)
+	}
+	if options.output != "text" && options.output != "json" {
+		Exit(ErrMsgUnsupportedOutput, flags)
+	}
+	return
+}
+
+// printClusters prints the clusters as a JSON array or as text where
+// every file takes a "digest path" line and clusters are separated by
+// an empty line.
+func printClusters(options SimilarOptions, clusters [][]fuzzy.FileDigest, stdout io.Writer) {
+	if options.output == "json" {
+		if clusters == nil {
+			clusters = [][]fuzzy.FileDigest{}
+		}
+		if options.pretty {
+			printAsPrettyJSON(stdout, clusters)
+		} else {
+			printAsJSON(stdout, clusters)
+		}
+		return
+	}
+	for i, cluster := range clusters {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		for _, file := range cluster {
+			fmt.Fprintf(stdout, "%s %s\n", file.Digest, file.Path)
+		}
+	}
+}
+
+// similar clusters the similar files and prints the clusters. The exit
+// status is 1 if the files couldn't be hashed.
+func similar(options SimilarOptions, stdout io.Writer, stderr io.Writer) int {
+	clusterOptions := fuzzy.ClusterOptions{
+		Method:    options.method,
+		Threshold: options.threshold,
+		MinSize:   options.minSize,
+		Walk:      options.walk,
+	}
+	clusters, err := fuzzy.ClusterFiles(clusterOptions, options.paths...)
+	if err != nil {
+		fmt.Fprintf(stderr, "hashutils: %s\n", err)
+		return 1
+	}
+	printClusters(options, clusters, stdout)
+	return 0
+}
+
+func executeSimilar(args []string) int {
+	options, err := ParseSimilarCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return similar(options, os.Stdout, os.Stderr)
+}
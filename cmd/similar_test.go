@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/fuzzy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSimilarCommandLine(t *testing.T) {
+	args := []string{"similar", "samples"}
+	options, err := ParseSimilarCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, fuzzy.MethodSSDeep, options.method)
+	assert.Equal(t, 0, options.threshold)
+	assert.Equal(t, int64(1), options.minSize)
+	assert.Equal(t, "text", options.output)
+	assert.Equal(t, []string{"samples"}, options.paths)
+
+	args = []string{"similar", "-m", "tlsh", "-t", "70", "-min-size", "512", "-o", "json", "-p", "-exclude", "*.zip", "samples", "quarantine"}
+	options, err = ParseSimilarCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, fuzzy.MethodTLSH, options.method)
+	assert.Equal(t, 70, options.threshold)
+	assert.Equal(t, int64(512), options.minSize)
+	assert.Equal(t, "json", options.output)
+	assert.True(t, options.pretty)
+	assert.Equal(t, []string{"*.zip"}, options.walk.Exclude)
+	assert.Equal(t, []string{"samples", "quarantine"}, options.paths)
+}
+
+func TestSimilar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "similar")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	data := make([]byte, 8192)
+	rand.New(rand.NewSource(1)).Read(data)
+	a, b := filepath.Join(dir, "a.bin"), filepath.Join(dir, "b.bin")
+	require.NoError(t, ioutil.WriteFile(a, data, 0644))
+	data[4096] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(b, data, 0644))
+	digestA, err := fuzzy.SSDeepFile(a)
+	require.NoError(t, err, "Error hashing file")
+	digestB, err := fuzzy.SSDeepFile(b)
+	require.NoError(t, err, "Error hashing file")
+
+	options := SimilarOptions{method: fuzzy.MethodSSDeep, minSize: 1, output: "text", paths: []string{dir}}
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, similar(options, &stdout, &stderr))
+	assert.Equal(t, digestA+" "+a+"\n"+digestB+" "+b+"\n", stdout.String())
+	assert.Empty(t, stderr.String())
+
+	options.output = "json"
+	options.threshold = 101
+	stdout.Reset()
+	assert.Equal(t, 0, similar(options, &stdout, &stderr))
+	assert.Equal(t, "[]\n", stdout.String())
+
+	options.paths = []string{filepath.Join(dir, "missing")}
+	assert.Equal(t, 1, similar(options, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "hashutils: ")
+}
+
+func TestPrintClustersAsJSON(t *testing.T) {
+	clusters := [][]fuzzy.FileDigest{{{Path: "a", Digest: "3:abc:ab"}, {Path: "b", Digest: "3:abd:ab"}}}
+	var stdout bytes.Buffer
+	printClusters(SimilarOptions{output: "json"}, clusters, &stdout)
+	assert.Equal(t, `[[{"path":"a","digest":"3:abc:ab"},{"path":"b","digest":"3:abd:ab"}]]`+"\n", stdout.String())
+
+	stdout.Reset()
+	printClusters(SimilarOptions{output: "json", pretty: true}, clusters, &stdout)
+	assert.Contains(t, stdout.String(), "[\n  [\n    {\n      \"path\": \"a\",\n")
+}
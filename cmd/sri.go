@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+const FlagDescSRIAlgorithm = "Algorithm to be used for the integrity metadata (sha256, sha384 or sha512)."
+
+const ErrMsgNoAssets = "hashutils: no asset files or directories to compute integrity for"
+
+type SRIOptions struct {
+	algorithm hash.Algorithm
+	walk      hash.WalkOptions
+	paths     []string
+	pretty    bool
+}
+
+// ParseSRICommandLine parses the arguments of the sri subcommand, which
+// prints an integrity manifest of the files and directories given as
+// positional arguments.
+func ParseSRICommandLine(args []string, errorHandling flag.ErrorHandling) (options SRIOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	algorithm := flags.String("a", "sha384", FlagDescSRIAlgorithm)
+	pretty := flags.Bool("p", false, FlagDescPretty)
+	walk := addWalkFlags(flags)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.algorithm = hash.Algorithm(*algorithm)
+	options.paths = flags.Args()
+	options.pretty = *pretty
+	options.walk = walk.options()
+	if len(options.paths) == 0 {
+		Exit(ErrMsgNoAssets, flags)
+	}
+	return
+}
+
+// sriManifest maps the regular files selected by the walk options to
+// their integrity metadata. Files found by walking the only directory
+// given are keyed by their slash-separated path relative to it; when
+// several paths are given, files are keyed by their slash-separated
+// path, the root included, so that the files of different roots don't
+// share keys. A file listed twice is an error.
+func sriManifest(algorithm hash.Algorithm, walk hash.WalkOptions, paths []string) (map[string]string, error) {
+	manifest := make(map[string]string)
+	for _, root := range paths {
+		err := hash.WalkTree(root, walk, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			integrity, err := hash.SRIFile(algorithm, path)
+			if err != nil {
+				return err
+			}
+			key := path
+			if path != root && len(paths) == 1 {
+				key, err = filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+			}
+			key = filepath.ToSlash(key)
+			if _, ok := manifest[key]; ok {
+				return fmt.Errorf("hashutils: %s is listed twice", key)
+			}
+			manifest[key] = integrity
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+func executeSRI(args []string) int {
+	options, err := ParseSRICommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	manifest, err := sriManifest(options.algorithm, options.walk, options.paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error computing integrity using algorithm %s, error: %s\n", options.algorithm, err)
+		return 1
+	}
+	if options.pretty {
+		printAsPrettyJSON(os.Stdout, manifest)
+	} else {
+		printAsJSON(os.Stdout, manifest)
+	}
+	return 0
+}
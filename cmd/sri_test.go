@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSRICommandLine(t *testing.T) {
+	args := []string{"sri", "dist"}
+	options, err := ParseSRICommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("sha384"), options.algorithm)
+	assert.Equal(t, []string{"dist"}, options.paths)
+
+	args = []string{"sri", "-a", "sha512", "-p", "-include", "*.js", "dist", "app.js"}
+	options, err = ParseSRICommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("sha512"), options.algorithm)
+	assert.Equal(t, []string{"dist", "app.js"}, options.paths)
+	assert.True(t, options.pretty)
+	assert.Equal(t, []string{"*.js"}, options.walk.Include)
+}
+
+func TestSRIManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dist")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "js"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "js", "app.js"), []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "js", "app.js.map"), []byte("bar"), 0644))
+
+	manifest, err := sriManifest(hash.Sha256Hash, hash.WalkOptions{Exclude: []string{"*.map"}}, []string{dir})
+	require.NoError(t, err, "Error computing integrity manifest")
+	assert.Equal(t, map[string]string{"js/app.js": "sha256-LCa0a2j/xo/5m0U8HTBBNBNCLXBkg7+g+YpeiGJm564="}, manifest)
+
+	_, err = sriManifest(hash.Md5Hash, hash.WalkOptions{}, []string{dir})
+	assert.Equal(t, hash.ErrUnsupportedAlgorithm, err)
+}
+
+func TestSRIManifestRoots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dist")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	dist1, dist2 := filepath.Join(dir, "dist1"), filepath.Join(dir, "dist2")
+	for _, root := range []string{dist1, dist2} {
+		require.NoError(t, os.Mkdir(root, 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dist1, "a.js"), []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dist2, "a.js"), []byte("bar"), 0644))
+
+	// The files of several roots are keyed by their path, root included.
+	manifest, err := sriManifest(hash.Sha256Hash, hash.WalkOptions{}, []string{dist1, dist2})
+	require.NoError(t, err, "Error computing integrity manifest")
+	assert.Equal(t, map[string]string{
+		filepath.ToSlash(filepath.Join(dist1, "a.js")): "sha256-LCa0a2j/xo/5m0U8HTBBNBNCLXBkg7+g+YpeiGJm564=",
+		filepath.ToSlash(filepath.Join(dist2, "a.js")): "sha256-/N4rLtula/QIYB+3If6bXDONEO5CnqBPrlURto+/j7k=",
+	}, manifest)
+
+	_, err = sriManifest(hash.Sha256Hash, hash.WalkOptions{}, []string{dist1, filepath.Join(dist1, "a.js")})
+	assert.EqualError(t, err, "hashutils: "+filepath.ToSlash(filepath.Join(dist1, "a.js"))+" is listed twice")
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+const (
+	FlagDescTagAlgorithm = "Algorithm to be used to hash the files."
+	FlagDescTagCheck     = "Only verify the stored checksums, without updating them."
+	FlagDescTagRemove    = "Remove the stored checksums instead of verifying them."
+	FlagDescTagQuiet     = "Only print files that are new, outdated or corrupt."
+)
+
+type TagOptions struct {
+	algorithm hash.Algorithm
+	check     bool
+	remove    bool
+	quiet     bool
+	walk      hash.WalkOptions
+	paths     []string
+}
+
+// ParseTagCommandLine parses the arguments of the tag subcommand, which
+// stores the checksums of the files under the paths given as positional
+// arguments in their extended attributes, shatag style.
+func ParseTagCommandLine(args []string, errorHandling flag.ErrorHandling) (options TagOptions, err error) {
+	flags := flag.NewFlagSet(args[0], errorHandling)
+	algorithm := flags.String("a", "sha256", FlagDescTagAlgorithm)
+	check := flags.Bool("check", false, FlagDescTagCheck)
+	remove := flags.Bool("remove", false, FlagDescTagRemove)
+	quiet := flags.Bool("q", false, FlagDescTagQuiet)
+	walk := addWalkFlags(flags)
+
+	if err = flags.Parse(args[1:]); err != nil {
+		return
+	}
+
+	options.algorithm = hash.Algorithm(*algorithm)
+	options.check = *check
+	options.remove = *remove
+	options.quiet = *quiet
+	options.walk = walk.options()
+	options.paths = flags.Args()
+	if len(options.paths) == 0 {
+		Exit(ErrMsgNoPaths, flags)
+	}
+	return
+}
+
+// tag verifies and updates, only verifies or removes the checksums
+// stored in the extended attributes of every regular file under the
+// paths selected by the walk options, printing a line per file the way
+// cshatag does. Corrupt files are never updated. The exit status is 1
+// if a file is corrupt or couldn't be processed.
+func tag(options TagOptions, stdout io.Writer, stderr io.Writer) int {
+	exit := 0
+	for _, root := range options.paths {
+		err := hash.WalkTree(root, options.walk, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				fmt.Fprintf(stderr, "hashutils: %s\n", err)
+				exit = 1
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			if err := tagFile(options, path, stdout); err != nil {
+				fmt.Fprintf(stderr, "hashutils: %s: %s\n", path, err)
+				exit = 1
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "hashutils: %s\n", err)
+			exit = 1
+		}
+	}
+	return exit
+}
+
+func tagFile(options TagOptions, path string, stdout io.Writer) error {
+	if options.remove {
+		return hash.RemoveXattrTag(path, options.algorithm)
+	}
+	status, err := hash.VerifyXattrTag(path, options.algorithm)
+	if err != nil {
+		return err
+	}
+	if status != hash.XattrOK || !options.quiet {
+		fmt.Fprintf(stdout, "<%s> %s\n", status, path)
+	}
+	switch {
+	case status == hash.XattrCorrupt:
+		return fmt.Errorf("checksum doesn't match although the modification time does")
+	case status != hash.XattrOK && !options.check:
+		_, err = hash.WriteXattrTag(path, options.algorithm)
+	}
+	return err
+}
+
+func executeTag(args []string) int {
+	options, err := ParseTagCommandLine(args, flag.ExitOnError)
+	if err != nil {
+		return 1
+	}
+	return tag(options, os.Stdout, os.Stderr)
+}
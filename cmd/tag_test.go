@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTagCommandLine(t *testing.T) {
+	args := []string{"tag", "/mnt/nas"}
+	options, err := ParseTagCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("sha256"), options.algorithm)
+	assert.Equal(t, []string{"/mnt/nas"}, options.paths)
+
+	args = []string{"tag", "-a", "sha512", "-check", "-q", "-one-file-system", "/mnt/nas"}
+	options, err = ParseTagCommandLine(args, flag.ContinueOnError)
+	require.NoError(t, err, "Error parsing commandline options")
+	assert.Equal(t, hash.Algorithm("sha512"), options.algorithm)
+	assert.True(t, options.walk.OneFileSystem)
+	assert.True(t, options.check)
+	assert.True(t, options.quiet)
+	assert.False(t, options.remove)
+}
+
+func TestTag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tag")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	foo := filepath.Join(dir, "foo")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+	hour := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(foo, hour, hour))
+	if _, err := hash.WriteXattrTag(foo, hash.Sha256Hash); err == hash.ErrXattrUnsupported {
+		t.Skip("Extended attributes are not supported by the temporary directory")
+	}
+	require.NoError(t, hash.RemoveXattrTag(foo, hash.Sha256Hash))
+
+	var stdout, stderr bytes.Buffer
+	options := TagOptions{algorithm: hash.Sha256Hash, paths: []string{dir}}
+	assert.Equal(t, 0, tag(options, &stdout, &stderr))
+	assert.Equal(t, "<new> "+foo+"\n", stdout.String())
+
+	stdout.Reset()
+	assert.Equal(t, 0, tag(options, &stdout, &stderr))
+	assert.Equal(t, "<ok> "+foo+"\n", stdout.String())
+
+	stdout.Reset()
+	options.remove = true
+	assert.Equal(t, 0, tag(options, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+
+	stdout.Reset()
+	options.remove, options.check = false, true
+	assert.Equal(t, 0, tag(options, &stdout, &stderr))
+	assert.Equal(t, "<new> "+foo+"\n", stdout.String())
+	_, err = hash.ReadXattrTag(foo, hash.Sha256Hash)
+	assert.Equal(t, hash.ErrNoXattrTag, err)
+}
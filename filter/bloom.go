@@ -0,0 +1,206 @@
+package filter
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/sarathkumarsivan/hashutils/internal/probabilistic"
+)
+
+var ErrInvalidCapacity = errors.New("hashutils: filter capacity must be positive")
+
+var ErrInvalidRate = errors.New("hashutils: false positive rate must be between 0 and 1")
+
+var ErrIncompatibleFilters = errors.New("hashutils: filters have different sizes or hash counts")
+
+var ErrInvalidFilter = errors.New("hashutils: invalid serialized filter")
+
+const (
+	bloomMagic         = "HUBF"
+	countingBloomMagic = "HUCB"
+	filterVersion      = 1
+)
+
+// bloomParameters returns the number of bits and of hash functions of a
+// filter holding n items with a false positive rate of p.
+func bloomParameters(n uint64, p float64) (uint64, uint32, error) {
+	if n == 0 {
+		return 0, 0, ErrInvalidCapacity
+	}
+	if p <= 0 || p >= 1 {
+		return 0, 0, ErrInvalidRate
+	}
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return uint64(m), uint32(k), nil
+}
+
+// Bloom is a Bloom filter: a set answering whether it may contain an
+// item, with false positives but no false negatives. The k positions of
+// an item among the m bits are h1 + i*h2 mod m, the double hashing of
+// Kirsch and Mitzenmacher over the 64-bit xxHash.
+type Bloom struct {
+	m    uint64
+	k    uint32
+	bits []uint64
+}
+
+// NewBloom returns a Bloom filter sized to hold n items with a false
+// positive rate of p.
+func NewBloom(n uint64, p float64) (*Bloom, error) {
+	m, k, err := bloomParameters(n, p)
+	if err != nil {
+		return nil, err
+	}
+	return NewBloomSize(m, k), nil
+}
+
+// NewBloomSize returns a Bloom filter of m bits using k hash functions.
+func NewBloomSize(m uint64, k uint32) *Bloom {
+	if m == 0 {
+		m = 1
+	}
+	if k == 0 {
+		k = 1
+	}
+	return &Bloom{m: m, k: k, bits: make([]uint64, (m+63)/64)}
+}
+
+// Bits returns the number of bits of the filter.
+func (b *Bloom) Bits() uint64 {
+	return b.m
+}
+
+// Hashes returns the number of hash functions of the filter.
+func (b *Bloom) Hashes() uint32 {
+	return b.k
+}
+
+// Add adds the item to the filter.
+func (b *Bloom) Add(data []byte) {
+	h1, h2 := probabilistic.IndexHashes(data)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		position := (h1 + i*h2) % b.m
+		b.bits[position/64] |= 1 << (position % 64)
+	}
+}
+
+// AddString adds the string to the filter.
+func (b *Bloom) AddString(s string) {
+	b.Add([]byte(s))
+}
+
+// Test tells whether the filter may contain the item; false means it
+// certainly doesn't.
+func (b *Bloom) Test(data []byte) bool {
+	h1, h2 := probabilistic.IndexHashes(data)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		position := (h1 + i*h2) % b.m
+		if b.bits[position/64]&(1<<(position%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TestString tells whether the filter may contain the string.
+func (b *Bloom) TestString(s string) bool {
+	return b.Test([]byte(s))
+}
+
+func (b *Bloom) compatible(other *Bloom) error {
+	if b.m != other.m || b.k != other.k {
+		return ErrIncompatibleFilters
+	}
+	return nil
+}
+
+// Union adds the items of the other filter, of the same size, to the
+// filter.
+func (b *Bloom) Union(other *Bloom) error {
+	if err := b.compatible(other); err != nil {
+		return err
+	}
+	for i := range b.bits {
+		b.bits[i] |= other.bits[i]
+	}
+	return nil
+}
+
+// Intersect keeps in the filter the items of the other filter, of the
+// same size. The false positive rate of the result is at most the one of
+// the filters.
+func (b *Bloom) Intersect(other *Bloom) error {
+	if err := b.compatible(other); err != nil {
+		return err
+	}
+	for i := range b.bits {
+		b.bits[i] &= other.bits[i]
+	}
+	return nil
+}
+
+// EstimateCount estimates the number of distinct items added to the
+// filter from the number of bits set, as -m/k ln(1 - set/m).
+func (b *Bloom) EstimateCount() float64 {
+	set := 0
+	for _, word := range b.bits {
+		set += bits.OnesCount64(word)
+	}
+	if uint64(set) == b.m {
+		return math.Inf(1)
+	}
+	return -float64(b.m) / float64(b.k) * math.Log(1-float64(set)/float64(b.m))
+}
+
+// MarshalBinary encodes the filter as the "HUBF" magic, a version byte,
+// the unsigned varints of the number of bits and of hash functions and
+// the bits as little endian 64-bit words.
+func (b *Bloom) MarshalBinary() ([]byte, error) {
+	data := appendHeader(nil, bloomMagic, b.m, uint64(b.k))
+	for _, word := range b.bits {
+		data = probabilistic.AppendUint64(data, word)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (b *Bloom) UnmarshalBinary(data []byte) error {
+	parameters, data, err := readHeader(data, bloomMagic, 2)
+	if err != nil {
+		return err
+	}
+	m, k := parameters[0], parameters[1]
+	// Bounding m by the data first keeps the word count from overflowing.
+	if m == 0 || m > uint64(len(data))*8 || k == 0 || k > math.MaxUint32 || uint64(len(data)) != (m+63)/64*8 {
+		return ErrInvalidFilter
+	}
+	filter := NewBloomSize(m, uint32(k))
+	for i := range filter.bits {
+		filter.bits[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	*b = *filter
+	return nil
+}
+
+// appendHeader appends the magic, the filter version and the
+// parameters as unsigned varints.
+func appendHeader(data []byte, magic string, parameters ...uint64) []byte {
+	return probabilistic.AppendHeader(data, magic, filterVersion, parameters...)
+}
+
+// readHeader reads the magic, the version and n parameters, and returns
+// them with the remaining data.
+func readHeader(data []byte, magic string, n int) ([]uint64, []byte, error) {
+	d := probabilistic.NewDecoder(data, magic, filterVersion, ErrInvalidFilter)
+	parameters := make([]uint64, n)
+	for i := range parameters {
+		parameters[i] = d.Uvarint()
+	}
+	return parameters, d.Remaining(), d.Err()
+}
@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomParameters(t *testing.T) {
+	m, k, err := bloomParameters(1000, 0.01)
+	require.NoError(t, err, "Error sizing filter")
+	assert.Equal(t, uint64(9586), m)
+	assert.Equal(t, uint32(7), k)
+
+	_, _, err = bloomParameters(0, 0.01)
+	assert.Equal(t, ErrInvalidCapacity, err)
+	_, _, err = bloomParameters(1000, 1)
+	assert.Equal(t, ErrInvalidRate, err)
+}
+
+func TestBloom(t *testing.T) {
+	b, err := NewBloom(10000, 0.01)
+	require.NoError(t, err, "Error creating filter")
+	for i := 0; i < 10000; i++ {
+		b.AddString(fmt.Sprintf("item-%d", i))
+	}
+	for i := 0; i < 10000; i++ {
+		assert.True(t, b.TestString(fmt.Sprintf("item-%d", i)), "No false negatives")
+	}
+	falsePositives := 0
+	for i := 0; i < 100000; i++ {
+		if b.TestString(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.InDelta(t, 0.01, float64(falsePositives)/100000, 0.005, "%d false positives", falsePositives)
+	assert.InDelta(t, 10000, b.EstimateCount(), 300)
+}
+
+func TestBloomUnionIntersect(t *testing.T) {
+	a, b := NewBloomSize(4096, 5), NewBloomSize(4096, 5)
+	a.AddString("both")
+	a.AddString("a")
+	b.AddString("both")
+	b.AddString("b")
+
+	union := NewBloomSize(4096, 5)
+	require.NoError(t, union.Union(a))
+	require.NoError(t, union.Union(b))
+	assert.True(t, union.TestString("a"))
+	assert.True(t, union.TestString("b"))
+	assert.InDelta(t, 3, union.EstimateCount(), 0.1)
+
+	require.NoError(t, a.Intersect(b))
+	assert.True(t, a.TestString("both"))
+	assert.False(t, a.TestString("a"))
+	assert.False(t, a.TestString("b"))
+
+	assert.Equal(t, ErrIncompatibleFilters, a.Union(NewBloomSize(4096, 4)))
+	assert.Equal(t, ErrIncompatibleFilters, a.Intersect(NewBloomSize(2048, 5)))
+}
+
+func TestBloomMarshalBinary(t *testing.T) {
+	b := NewBloomSize(100, 3)
+	b.AddString("foo")
+	data, err := b.MarshalBinary()
+	require.NoError(t, err, "Error encoding filter")
+	assert.Equal(t, []byte("HUBF\x01\x64\x03"), data[:7])
+	assert.Len(t, data, 7+2*8)
+
+	var decoded Bloom
+	require.NoError(t, decoded.UnmarshalBinary(data), "Error decoding filter")
+	assert.Equal(t, b, &decoded)
+	assert.True(t, decoded.TestString("foo"))
+
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary(data[:len(data)-1]))
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary([]byte("HUCB\x01\x64\x03")))
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary(nil))
+	// A number of bits whose word count overflows to 0.
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary([]byte("HUBF\x01\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01\x03")))
+}
@@ -0,0 +1,156 @@
+package filter
+
+import (
+	"errors"
+	"math"
+
+	"github.com/sarathkumarsivan/hashutils/internal/probabilistic"
+)
+
+var ErrNotInFilter = errors.New("hashutils: item is not in the filter")
+
+// maxCounter is the value at which the 4-bit counters saturate.
+const maxCounter = 15
+
+// CountingBloom is a Bloom filter whose positions are 4-bit counters
+// instead of bits, so that items can be removed. A counter reaching 15
+// saturates and is never decremented again, trading a little accuracy
+// for never forgetting items still in the filter.
+type CountingBloom struct {
+	m        uint64
+	k        uint32
+	counters []byte
+}
+
+// NewCountingBloom returns a counting Bloom filter sized to hold n items
+// with a false positive rate of p. It takes four times the memory of a
+// Bloom filter of the same rate.
+func NewCountingBloom(n uint64, p float64) (*CountingBloom, error) {
+	m, k, err := bloomParameters(n, p)
+	if err != nil {
+		return nil, err
+	}
+	return NewCountingBloomSize(m, k), nil
+}
+
+// NewCountingBloomSize returns a counting Bloom filter of m counters
+// using k hash functions.
+func NewCountingBloomSize(m uint64, k uint32) *CountingBloom {
+	if m == 0 {
+		m = 1
+	}
+	if k == 0 {
+		k = 1
+	}
+	return &CountingBloom{m: m, k: k, counters: make([]byte, (m+1)/2)}
+}
+
+func (c *CountingBloom) counter(position uint64) byte {
+	return c.counters[position/2] >> (4 * (position % 2)) & 0x0f
+}
+
+func (c *CountingBloom) setCounter(position uint64, value byte) {
+	shift := 4 * (position % 2)
+	c.counters[position/2] = c.counters[position/2]&^(0x0f<<shift) | value<<shift
+}
+
+// positions returns the counters of the item.
+func (c *CountingBloom) positions(data []byte) []uint64 {
+	h1, h2 := probabilistic.IndexHashes(data)
+	positions := make([]uint64, c.k)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % c.m
+	}
+	return positions
+}
+
+// Add adds the item to the filter.
+func (c *CountingBloom) Add(data []byte) {
+	for _, position := range c.positions(data) {
+		if value := c.counter(position); value < maxCounter {
+			c.setCounter(position, value+1)
+		}
+	}
+}
+
+// Remove removes an item added to the filter. Removing an item that was
+// never added may remove others, so items the filter certainly doesn't
+// contain are refused with ErrNotInFilter.
+func (c *CountingBloom) Remove(data []byte) error {
+	positions := c.positions(data)
+	for _, position := range positions {
+		if c.counter(position) == 0 {
+			return ErrNotInFilter
+		}
+	}
+	for _, position := range positions {
+		if value := c.counter(position); value < maxCounter {
+			c.setCounter(position, value-1)
+		}
+	}
+	return nil
+}
+
+// Test tells whether the filter may contain the item; false means it
+// certainly doesn't.
+func (c *CountingBloom) Test(data []byte) bool {
+	for _, position := range c.positions(data) {
+		if c.counter(position) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count estimates how many times the item was added, as the smallest of
+// its counters, 15 meaning 15 or more.
+func (c *CountingBloom) Count(data []byte) int {
+	count := maxCounter
+	for _, position := range c.positions(data) {
+		if value := int(c.counter(position)); value < count {
+			count = value
+		}
+	}
+	return count
+}
+
+// Bloom returns the Bloom filter of the items of the filter, whose bits
+// are set where the counters are not zero.
+func (c *CountingBloom) Bloom() *Bloom {
+	b := NewBloomSize(c.m, c.k)
+	for position := uint64(0); position < c.m; position++ {
+		if c.counter(position) != 0 {
+			b.bits[position/64] |= 1 << (position % 64)
+		}
+	}
+	return b
+}
+
+// EstimateCount estimates the number of distinct items in the filter.
+func (c *CountingBloom) EstimateCount() float64 {
+	return c.Bloom().EstimateCount()
+}
+
+// MarshalBinary encodes the filter as the "HUCB" magic, a version byte,
+// the unsigned varints of the number of counters and of hash functions
+// and the counters, two per byte with the first in the low nibble.
+func (c *CountingBloom) MarshalBinary() ([]byte, error) {
+	data := appendHeader(nil, countingBloomMagic, c.m, uint64(c.k))
+	return append(data, c.counters...), nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary.
+func (c *CountingBloom) UnmarshalBinary(data []byte) error {
+	parameters, data, err := readHeader(data, countingBloomMagic, 2)
+	if err != nil {
+		return err
+	}
+	m, k := parameters[0], parameters[1]
+	if m == 0 || m > uint64(len(data))*2 || k == 0 || k > math.MaxUint32 || uint64(len(data)) != (m+1)/2 {
+		return ErrInvalidFilter
+	}
+	filter := NewCountingBloomSize(m, uint32(k))
+	copy(filter.counters, data)
+	*c = *filter
+	return nil
+}
@@ -0,0 +1,68 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountingBloom(t *testing.T) {
+	c, err := NewCountingBloom(1000, 0.01)
+	require.NoError(t, err, "Error creating filter")
+	for i := 0; i < 1000; i++ {
+		c.Add([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	for i := 0; i < 500; i++ {
+		require.NoError(t, c.Remove([]byte(fmt.Sprintf("item-%d", i))), "Error removing item-%d", i)
+	}
+	for i := 500; i < 1000; i++ {
+		assert.True(t, c.Test([]byte(fmt.Sprintf("item-%d", i))), "No false negatives after removals")
+	}
+	present := 0
+	for i := 0; i < 500; i++ {
+		if c.Test([]byte(fmt.Sprintf("item-%d", i))) {
+			present++
+		}
+	}
+	assert.True(t, present < 20, "%d removed items still present", present)
+	assert.InDelta(t, 500, c.EstimateCount(), 30)
+
+	assert.Equal(t, ErrNotInFilter, c.Remove([]byte("never added")))
+}
+
+func TestCountingBloomCount(t *testing.T) {
+	c := NewCountingBloomSize(1024, 4)
+	for i := 0; i < 3; i++ {
+		c.Add([]byte("foo"))
+	}
+	assert.Equal(t, 3, c.Count([]byte("foo")))
+	assert.Equal(t, 0, c.Count([]byte("bar")))
+
+	for i := 0; i < 20; i++ {
+		c.Add([]byte("foo"))
+	}
+	assert.Equal(t, 15, c.Count([]byte("foo")))
+	require.NoError(t, c.Remove([]byte("foo")))
+	assert.Equal(t, 15, c.Count([]byte("foo")), "Saturated counters are kept")
+	assert.True(t, c.Bloom().Test([]byte("foo")))
+}
+
+func TestCountingBloomMarshalBinary(t *testing.T) {
+	c := NewCountingBloomSize(101, 3)
+	c.Add([]byte("foo"))
+	c.Add([]byte("foo"))
+	data, err := c.MarshalBinary()
+	require.NoError(t, err, "Error encoding filter")
+	assert.Equal(t, []byte("HUCB\x01\x65\x03"), data[:7])
+	assert.Len(t, data, 7+51)
+
+	var decoded CountingBloom
+	require.NoError(t, decoded.UnmarshalBinary(data), "Error decoding filter")
+	assert.Equal(t, c, &decoded)
+	assert.Equal(t, 2, decoded.Count([]byte("foo")))
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary(data[:20]))
+	// A number of counters whose byte count overflows to 0.
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary([]byte("HUCB\x01\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01\x03")))
+}
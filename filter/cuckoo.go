@@ -0,0 +1,303 @@
+package filter
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+var ErrFilterFull = errors.New("hashutils: cuckoo filter is full")
+
+var ErrInvalidFingerprint = errors.New("hashutils: fingerprint size must be between 1 and 32 bits")
+
+var ErrInvalidBucketSize = errors.New("hashutils: bucket size must be between 1 and 64")
+
+const cuckooMagic = "HUCF"
+
+// CuckooOptions configures a Cuckoo filter.
+type CuckooOptions struct {
+	// FingerprintBits is the size of the fingerprints stored for every
+	// item, from 1 to 32 bits; 0 selects 16. The false positive rate is
+	// about 2*BucketSize/2^FingerprintBits.
+	FingerprintBits uint
+	// BucketSize is the number of fingerprints of a bucket; 0 selects 4.
+	BucketSize int
+	// MaxKicks is the number of fingerprints relocated before an insert
+	// fails; 0 selects 500.
+	MaxKicks int
+}
+
+// Cuckoo is a cuckoo filter: a set of fingerprints answering whether it
+// may contain an item, which unlike a Bloom filter supports deletion.
+// The fingerprint of an item is stored in one of two buckets, i1 and
+// i2 = i1 ^ hash(fingerprint), so that either can be computed from the
+// other and fingerprints can be moved between them to make room.
+type Cuckoo struct {
+	fingerprintBits uint
+	bucketSize      int
+	maxKicks        int
+	buckets         uint64
+	count           uint64
+	// slots holds bucketSize fingerprints per bucket, 0 being empty.
+	slots []uint32
+	// victim is the fingerprint left without a bucket by a failed
+	// insert, kept so that the filter has no false negatives.
+	victim      uint32
+	victimIndex uint64
+	// kick is the state of the xorshift generator choosing the
+	// fingerprints to relocate.
+	kick uint64
+}
+
+// NewCuckoo returns a cuckoo filter with room for at least capacity
+// items. The number of buckets is rounded up to a power of two, at 95%
+// occupancy for the capacity.
+func NewCuckoo(capacity uint64, options CuckooOptions) (*Cuckoo, error) {
+	if capacity == 0 {
+		return nil, ErrInvalidCapacity
+	}
+	c, err := newCuckoo(options)
+	if err != nil {
+		return nil, err
+	}
+	needed := (capacity*100/95 + uint64(c.bucketSize) - 1) / uint64(c.bucketSize)
+	c.buckets = 1
+	for c.buckets < needed {
+		c.buckets <<= 1
+	}
+	c.slots = make([]uint32, c.buckets*uint64(c.bucketSize))
+	return c, nil
+}
+
+func newCuckoo(options CuckooOptions) (*Cuckoo, error) {
+	c := &Cuckoo{
+		fingerprintBits: options.FingerprintBits,
+		bucketSize:      options.BucketSize,
+		maxKicks:        options.MaxKicks,
+		kick:            0x2545f4914f6cdd1d,
+	}
+	if c.fingerprintBits == 0 {
+		c.fingerprintBits = 16
+	}
+	if c.bucketSize == 0 {
+		c.bucketSize = 4
+	}
+	if c.maxKicks <= 0 {
+		c.maxKicks = 500
+	}
+	if c.fingerprintBits > 32 {
+		return nil, ErrInvalidFingerprint
+	}
+	if c.bucketSize < 0 || c.bucketSize > 64 {
+		return nil, ErrInvalidBucketSize
+	}
+	return c, nil
+}
+
+// locate returns the fingerprint and the first bucket of the item, from
+// the 64-bit xxHash of the data: the bucket from its low bits and the
+// fingerprint from its high bits, 0 being replaced by 1.
+func (c *Cuckoo) locate(data []byte) (uint32, uint64) {
+	h := hash.XxHash64Seed(data, 0)
+	fingerprint := uint32(h>>32) & (1<<c.fingerprintBits - 1)
+	if fingerprint == 0 {
+		fingerprint = 1
+	}
+	return fingerprint, h & (c.buckets - 1)
+}
+
+// alternate returns the other bucket of the fingerprint, the bucket
+// xored with the 64-bit xxHash of the little endian fingerprint.
+func (c *Cuckoo) alternate(index uint64, fingerprint uint32) uint64 {
+	var buffer [4]byte
+	binary.LittleEndian.PutUint32(buffer[:], fingerprint)
+	return (index ^ hash.XxHash64Seed(buffer[:], 0)) & (c.buckets - 1)
+}
+
+func (c *Cuckoo) bucket(index uint64) []uint32 {
+	return c.slots[index*uint64(c.bucketSize) : (index+1)*uint64(c.bucketSize)]
+}
+
+func (c *Cuckoo) put(index uint64, fingerprint uint32) bool {
+	bucket := c.bucket(index)
+	for i := range bucket {
+		if bucket[i] == 0 {
+			bucket[i] = fingerprint
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cuckoo) random() uint64 {
+	c.kick ^= c.kick << 13
+	c.kick ^= c.kick >> 7
+	c.kick ^= c.kick << 17
+	return c.kick
+}
+
+// Insert adds the item to the filter. An item can be inserted several
+// times, at most 2*BucketSize, and must then be deleted as many times.
+// When no room is found the filter is full: the insert fails with
+// ErrFilterFull, like every later one until an item is deleted.
+func (c *Cuckoo) Insert(data []byte) error {
+	if c.victim != 0 {
+		return ErrFilterFull
+	}
+	fingerprint, i1 := c.locate(data)
+	i2 := c.alternate(i1, fingerprint)
+	if c.put(i1, fingerprint) || c.put(i2, fingerprint) {
+		c.count++
+		return nil
+	}
+	index := i1
+	if c.random()%2 == 1 {
+		index = i2
+	}
+	for kick := 0; kick < c.maxKicks; kick++ {
+		bucket := c.bucket(index)
+		slot := c.random() % uint64(c.bucketSize)
+		fingerprint, bucket[slot] = bucket[slot], fingerprint
+		index = c.alternate(index, fingerprint)
+		if c.put(index, fingerprint) {
+			c.count++
+			return nil
+		}
+	}
+	c.victim, c.victimIndex = fingerprint, index
+	c.count++
+	return ErrFilterFull
+}
+
+// InsertString adds the string to the filter.
+func (c *Cuckoo) InsertString(s string) error {
+	return c.Insert([]byte(s))
+}
+
+func contains(bucket []uint32, fingerprint uint32) bool {
+	for _, f := range bucket {
+		if f == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup tells whether the filter may contain the item; false means it
+// certainly doesn't.
+func (c *Cuckoo) Lookup(data []byte) bool {
+	fingerprint, i1 := c.locate(data)
+	i2 := c.alternate(i1, fingerprint)
+	if c.victim == fingerprint && (c.victimIndex == i1 || c.victimIndex == i2) {
+		return true
+	}
+	return contains(c.bucket(i1), fingerprint) || contains(c.bucket(i2), fingerprint)
+}
+
+// LookupString tells whether the filter may contain the string.
+func (c *Cuckoo) LookupString(s string) bool {
+	return c.Lookup([]byte(s))
+}
+
+func (c *Cuckoo) remove(index uint64, fingerprint uint32) bool {
+	bucket := c.bucket(index)
+	for i := range bucket {
+		if bucket[i] == fingerprint {
+			bucket[i] = 0
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes an inserted item from the filter. Deleting an item that
+// was never inserted may delete another one sharing its fingerprint, so
+// items the filter certainly doesn't contain are refused with
+// ErrNotInFilter.
+func (c *Cuckoo) Delete(data []byte) error {
+	fingerprint, i1 := c.locate(data)
+	i2 := c.alternate(i1, fingerprint)
+	switch {
+	case c.victim == fingerprint && (c.victimIndex == i1 || c.victimIndex == i2):
+		c.victim = 0
+	case c.remove(i1, fingerprint), c.remove(i2, fingerprint):
+	default:
+		return ErrNotInFilter
+	}
+	c.count--
+	// Give the victim a bucket again now that there may be room.
+	if c.victim != 0 && (c.put(c.victimIndex, c.victim) || c.put(c.alternate(c.victimIndex, c.victim), c.victim)) {
+		c.victim = 0
+	}
+	return nil
+}
+
+// DeleteString removes an inserted string from the filter.
+func (c *Cuckoo) DeleteString(s string) error {
+	return c.Delete([]byte(s))
+}
+
+// Count returns the number of items in the filter.
+func (c *Cuckoo) Count() uint64 {
+	return c.count
+}
+
+// LoadFactor returns the fraction of the slots of the filter in use.
+func (c *Cuckoo) LoadFactor() float64 {
+	return float64(c.count) / float64(len(c.slots))
+}
+
+// fingerprintBytes is the number of bytes of a serialized fingerprint.
+func (c *Cuckoo) fingerprintBytes() int {
+	return int(c.fingerprintBits+7) / 8
+}
+
+// MarshalBinary encodes the filter as the "HUCF" magic, a version byte,
+// the unsigned varints of the fingerprint bits, the bucket size, the
+// number of buckets, the number of items, the victim fingerprint and
+// its bucket, followed by the fingerprints of the buckets in order, as
+// little endian integers of the fewest bytes holding FingerprintBits.
+func (c *Cuckoo) MarshalBinary() ([]byte, error) {
+	data := appendHeader(nil, cuckooMagic, uint64(c.fingerprintBits), uint64(c.bucketSize),
+		c.buckets, c.count, uint64(c.victim), c.victimIndex)
+	var buffer [4]byte
+	size := c.fingerprintBytes()
+	for _, fingerprint := range c.slots {
+		binary.LittleEndian.PutUint32(buffer[:], fingerprint)
+		data = append(data, buffer[:size]...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a filter encoded by MarshalBinary. The
+// decoded filter relocates fingerprints with the default MaxKicks.
+func (c *Cuckoo) UnmarshalBinary(data []byte) error {
+	parameters, data, err := readHeader(data, cuckooMagic, 6)
+	if err != nil {
+		return err
+	}
+	if parameters[0] == 0 || parameters[0] > 32 || parameters[1] == 0 || parameters[1] > 64 {
+		return ErrInvalidFilter
+	}
+	filter, _ := newCuckoo(CuckooOptions{FingerprintBits: uint(parameters[0]), BucketSize: int(parameters[1])})
+	filter.buckets, filter.count = parameters[2], parameters[3]
+	size := filter.fingerprintBytes()
+	bucketBytes := uint64(filter.bucketSize) * uint64(size)
+	// Bounding the buckets by the data first keeps the size from
+	// overflowing.
+	if filter.buckets == 0 || filter.buckets&(filter.buckets-1) != 0 ||
+		filter.buckets > uint64(len(data))/bucketBytes || uint64(len(data)) != filter.buckets*bucketBytes ||
+		parameters[4] >= 1<<filter.fingerprintBits || parameters[5] >= filter.buckets {
+		return ErrInvalidFilter
+	}
+	filter.victim, filter.victimIndex = uint32(parameters[4]), parameters[5]
+	filter.slots = make([]uint32, filter.buckets*uint64(filter.bucketSize))
+	var buffer [4]byte
+	for i := range filter.slots {
+		copy(buffer[:], data[i*size:(i+1)*size])
+		filter.slots[i] = binary.LittleEndian.Uint32(buffer[:])
+	}
+	*c = *filter
+	return nil
+}
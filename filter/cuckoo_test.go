@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCuckoo(t *testing.T) {
+	c, err := NewCuckoo(10000, CuckooOptions{})
+	require.NoError(t, err, "Error creating filter")
+	for i := 0; i < 10000; i++ {
+		require.NoError(t, c.InsertString(fmt.Sprintf("item-%d", i)), "Error inserting item-%d", i)
+	}
+	assert.Equal(t, uint64(10000), c.Count())
+	assert.InDelta(t, 10000.0/16384, c.LoadFactor(), 0.001)
+	for i := 0; i < 10000; i++ {
+		assert.True(t, c.LookupString(fmt.Sprintf("item-%d", i)), "No false negatives")
+	}
+	falsePositives := 0
+	for i := 0; i < 100000; i++ {
+		if c.LookupString(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+	// About 2*4/2^16.
+	assert.True(t, falsePositives < 50, "%d false positives", falsePositives)
+
+	for i := 0; i < 5000; i++ {
+		require.NoError(t, c.DeleteString(fmt.Sprintf("item-%d", i)), "Error deleting item-%d", i)
+	}
+	assert.Equal(t, uint64(5000), c.Count())
+	for i := 5000; i < 10000; i++ {
+		assert.True(t, c.LookupString(fmt.Sprintf("item-%d", i)), "No false negatives after deletions")
+	}
+	assert.Equal(t, ErrNotInFilter, c.DeleteString("never inserted"))
+}
+
+func TestCuckooFull(t *testing.T) {
+	c, err := NewCuckoo(64, CuckooOptions{FingerprintBits: 8, BucketSize: 2, MaxKicks: 50})
+	require.NoError(t, err, "Error creating filter")
+	inserted := 0
+	for ; inserted < 1000; inserted++ {
+		if err = c.InsertString(fmt.Sprintf("item-%d", inserted)); err != nil {
+			break
+		}
+	}
+	require.Equal(t, ErrFilterFull, err)
+	assert.True(t, c.LoadFactor() > 0.5, "load factor %f", c.LoadFactor())
+	for i := 0; i <= inserted; i++ {
+		assert.True(t, c.LookupString(fmt.Sprintf("item-%d", i)), "The failed insert is kept")
+	}
+	assert.Equal(t, ErrFilterFull, c.InsertString("more"))
+
+	require.NoError(t, c.DeleteString("item-0"))
+	for i := 1; i <= inserted; i++ {
+		assert.True(t, c.LookupString(fmt.Sprintf("item-%d", i)), "No false negatives after deletion")
+	}
+}
+
+func TestCuckooOptions(t *testing.T) {
+	_, err := NewCuckoo(0, CuckooOptions{})
+	assert.Equal(t, ErrInvalidCapacity, err)
+	_, err = NewCuckoo(10, CuckooOptions{FingerprintBits: 33})
+	assert.Equal(t, ErrInvalidFingerprint, err)
+	_, err = NewCuckoo(10, CuckooOptions{BucketSize: 65})
+	assert.Equal(t, ErrInvalidBucketSize, err)
+
+	c, err := NewCuckoo(10, CuckooOptions{FingerprintBits: 32, BucketSize: 1})
+	require.NoError(t, err, "Error creating filter")
+	require.NoError(t, c.InsertString("foo"))
+	assert.True(t, c.LookupString("foo"))
+	assert.False(t, c.LookupString("bar"))
+}
+
+func TestCuckooMarshalBinary(t *testing.T) {
+	c, err := NewCuckoo(100, CuckooOptions{FingerprintBits: 12, BucketSize: 2})
+	require.NoError(t, err, "Error creating filter")
+	for i := 0; i < 50; i++ {
+		require.NoError(t, c.InsertString(fmt.Sprintf("item-%d", i)))
+	}
+	data, err := c.MarshalBinary()
+	require.NoError(t, err, "Error encoding filter")
+	assert.Equal(t, []byte("HUCF\x01\x0c\x02\x40\x32\x00\x00"), data[:11])
+	assert.Len(t, data, 11+64*2*2)
+
+	var decoded Cuckoo
+	require.NoError(t, decoded.UnmarshalBinary(data), "Error decoding filter")
+	assert.Equal(t, c.slots, decoded.slots)
+	assert.Equal(t, uint64(50), decoded.Count())
+	for i := 0; i < 50; i++ {
+		assert.True(t, decoded.LookupString(fmt.Sprintf("item-%d", i)))
+	}
+	require.NoError(t, decoded.DeleteString("item-0"))
+
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary(data[:len(data)-1]))
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary([]byte("HUCF\x01\x0c\x02\x03\x00\x00\x00")))
+	// 2^61 buckets of 8 bytes, whose size overflows to 0.
+	assert.Equal(t, ErrInvalidFilter, decoded.UnmarshalBinary([]byte("HUCF\x01\x10\x04\x80\x80\x80\x80\x80\x80\x80\x80\x20\x00\x00\x00")))
+}
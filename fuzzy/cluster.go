@@ -0,0 +1,157 @@
+package fuzzy
+
+import (
+	"errors"
+	"os"
+	"sort"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+var ErrUnsupportedMethod = errors.New("hashutils: unsupported fuzzy hashing method")
+
+// Method is a fuzzy hashing algorithm.
+type Method string
+
+const (
+	MethodSSDeep Method = "ssdeep"
+	MethodTLSH   Method = "tlsh"
+)
+
+// DefaultThreshold returns the threshold above which files are deemed
+// similar: a score of 50 for ssdeep and a distance of 100 for TLSH.
+func (m Method) DefaultThreshold() int {
+	if m == MethodTLSH {
+		return 100
+	}
+	return 50
+}
+
+// Digest hashes the file with the method.
+func (m Method) Digest(path string) (string, error) {
+	switch m {
+	case MethodSSDeep:
+		return SSDeepFile(path)
+	case MethodTLSH:
+		return TLSHFile(path)
+	}
+	return "", ErrUnsupportedMethod
+}
+
+// Similar tells whether the digests are similar enough for the
+// threshold: a score at least as high for ssdeep, a distance at most as
+// high for TLSH.
+func (m Method) Similar(digest1 string, digest2 string, threshold int) (bool, error) {
+	switch m {
+	case MethodSSDeep:
+		score, err := CompareSSDeep(digest1, digest2)
+		return score > 0 && score >= threshold, err
+	case MethodTLSH:
+		distance, err := DistanceTLSH(digest1, digest2)
+		return distance <= threshold, err
+	}
+	return false, ErrUnsupportedMethod
+}
+
+// FileDigest is the fuzzy digest of a file.
+type FileDigest struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+}
+
+// ClusterOptions selects the files compared by ClusterFiles.
+type ClusterOptions struct {
+	Method Method
+	// Threshold is the score or the distance of similar files; 0 selects
+	// the default threshold of the method.
+	Threshold int
+	// MinSize is the size in bytes below which files are ignored.
+	MinSize int64
+	// Walk selects the files compared under the paths. Only regular
+	// files are hashed, followed symbolic links included.
+	Walk hash.WalkOptions
+}
+
+// ClusterFiles hashes the regular files under the paths selected by the
+// walk options and groups the similar ones, a file joining a cluster
+// when it is similar to any of its files. Only clusters of at least two
+// files are returned, sorted by path. Files TLSH can't hash, being too
+// short or too uniform, are ignored.
+func ClusterFiles(options ClusterOptions, paths ...string) ([][]FileDigest, error) {
+	if options.Method != MethodSSDeep && options.Method != MethodTLSH {
+		return nil, ErrUnsupportedMethod
+	}
+	var digests []FileDigest
+	for _, root := range paths {
+		err := hash.WalkTree(root, options.Walk, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() || info.Size() < options.MinSize {
+				return nil
+			}
+			digest, err := options.Method.Digest(path)
+			if err == ErrTLSHTooShort || err == ErrTLSHLowVariation {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			digests = append(digests, FileDigest{Path: path, Digest: digest})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ClusterDigests(options.Method, options.Threshold, digests)
+}
+
+// ClusterDigests groups the similar digests the way ClusterFiles does.
+func ClusterDigests(method Method, threshold int, digests []FileDigest) ([][]FileDigest, error) {
+	if threshold == 0 {
+		threshold = method.DefaultThreshold()
+	}
+	// parent is a union-find forest of the indexes of the digests.
+	parent := make([]int, len(digests))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range digests {
+		for j := i + 1; j < len(digests); j++ {
+			if find(i) == find(j) {
+				continue
+			}
+			similar, err := method.Similar(digests[i].Digest, digests[j].Digest, threshold)
+			if err != nil {
+				return nil, err
+			}
+			if similar {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]FileDigest)
+	for i, digest := range digests {
+		root := find(i)
+		groups[root] = append(groups[root], digest)
+	}
+	var clusters [][]FileDigest
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].Path < group[j].Path })
+		clusters = append(clusters, group)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i][0].Path < clusters[j][0].Path })
+	return clusters, nil
+}
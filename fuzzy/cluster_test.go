@@ -0,0 +1,60 @@
+package fuzzy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuzzy")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	first, second := randomText(1, 20000), randomData(2, 20000)
+	files := map[string][]byte{
+		"a/report.txt":   first,
+		"b/report.txt":   edit(first, 100, 10000),
+		"c/data.bin":     second,
+		"c/data.bak":     edit(second, 5000),
+		"unrelated.bin":  randomData(3, 20000),
+		"tiny.txt":       []byte("tiny"),
+		"sub/report.old": first[:19500],
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, content, 0644))
+	}
+
+	for _, method := range []Method{MethodSSDeep, MethodTLSH} {
+		clusters, err := ClusterFiles(ClusterOptions{Method: method, MinSize: 10}, dir)
+		require.NoError(t, err, "Error clustering files")
+		var paths [][]string
+		for _, cluster := range clusters {
+			var names []string
+			for _, digest := range cluster {
+				relative, err := filepath.Rel(dir, digest.Path)
+				require.NoError(t, err)
+				names = append(names, filepath.ToSlash(relative))
+			}
+			paths = append(paths, names)
+		}
+		assert.Equal(t, [][]string{
+			{"a/report.txt", "b/report.txt", "sub/report.old"},
+			{"c/data.bak", "c/data.bin"},
+		}, paths, "method %s", method)
+	}
+
+	clusters, err := ClusterFiles(ClusterOptions{Method: MethodSSDeep, Walk: hash.WalkOptions{Exclude: []string{"sub/", "*.bak"}}}, dir)
+	require.NoError(t, err, "Error clustering files")
+	require.Len(t, clusters, 1)
+	assert.Len(t, clusters[0], 2)
+
+	_, err = ClusterFiles(ClusterOptions{Method: "md5"}, dir)
+	assert.Equal(t, ErrUnsupportedMethod, err)
+}
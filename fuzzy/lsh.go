@@ -0,0 +1,175 @@
+package fuzzy
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+var ErrDuplicateKey = errors.New("hashutils: key already in the LSH index")
+
+var ErrInvalidThreshold = errors.New("hashutils: LSH threshold must be between 0 and 1")
+
+// LSHIndex finds the stored MinHash signatures similar to a signature
+// without comparing it to all of them. Signatures are split into bands
+// of rows; two signatures are candidates when all the rows of one of
+// their bands are equal, which happens with a probability of
+// 1-(1-J^rows)^bands for a Jaccard similarity J.
+type LSHIndex struct {
+	threshold  float64
+	bands      int
+	rows       int
+	buckets    []map[string][]string
+	signatures map[string]MinHashSignature
+}
+
+// LSHMatch is a stored signature returned by a query.
+type LSHMatch struct {
+	Key        string  `json:"key"`
+	Similarity float64 `json:"similarity"`
+}
+
+// NewLSHIndex returns an index of signatures of the given size, as
+// computed by a MinHasher with that many permutations, answering which
+// signatures have a similarity of at least threshold. The bands and rows
+// are chosen to minimize the probabilities of missing similar signatures
+// and of comparing dissimilar ones.
+func NewLSHIndex(permutations int, threshold float64) (*LSHIndex, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, ErrInvalidThreshold
+	}
+	if permutations <= 0 {
+		permutations = 128
+	}
+	bands, rows := lshParameters(permutations, threshold)
+	return newLSHIndex(threshold, bands, rows), nil
+}
+
+func newLSHIndex(threshold float64, bands int, rows int) *LSHIndex {
+	x := &LSHIndex{
+		threshold:  threshold,
+		bands:      bands,
+		rows:       rows,
+		buckets:    make([]map[string][]string, bands),
+		signatures: make(map[string]MinHashSignature),
+	}
+	for i := range x.buckets {
+		x.buckets[i] = make(map[string][]string)
+	}
+	return x
+}
+
+// lshParameters returns the bands and rows, using at most the given
+// number of permutations, minimizing the sum of the false positive and
+// false negative probabilities around the threshold.
+func lshParameters(permutations int, threshold float64) (int, int) {
+	bestBands, bestRows, bestError := 1, permutations, math.Inf(1)
+	for bands := 1; bands <= permutations; bands++ {
+		for rows := 1; bands*rows <= permutations; rows++ {
+			candidate := func(j float64) float64 {
+				return 1 - math.Pow(1-math.Pow(j, float64(rows)), float64(bands))
+			}
+			falsePositive := integrate(candidate, 0, threshold)
+			falseNegative := integrate(func(j float64) float64 { return 1 - candidate(j) }, threshold, 1)
+			if e := falsePositive + falseNegative; e < bestError {
+				bestBands, bestRows, bestError = bands, rows, e
+			}
+		}
+	}
+	return bestBands, bestRows
+}
+
+// integrate approximates the integral of f from a to b with the
+// trapezoidal rule.
+func integrate(f func(float64) float64, a float64, b float64) float64 {
+	const steps = 100
+	width := (b - a) / steps
+	area := (f(a) + f(b)) / 2
+	for i := 1; i < steps; i++ {
+		area += f(a + float64(i)*width)
+	}
+	return area * width
+}
+
+// Bands returns the number of bands and of rows per band of the index.
+func (x *LSHIndex) Bands() (int, int) {
+	return x.bands, x.rows
+}
+
+// Len returns the number of signatures in the index.
+func (x *LSHIndex) Len() int {
+	return len(x.signatures)
+}
+
+// bandKey returns the bucket key of a band of the signature.
+func (x *LSHIndex) bandKey(signature MinHashSignature, band int) string {
+	key := make([]byte, 8*x.rows)
+	for i, value := range signature[band*x.rows : (band+1)*x.rows] {
+		binary.LittleEndian.PutUint64(key[8*i:], value)
+	}
+	return string(key)
+}
+
+func (x *LSHIndex) checkSize(signature MinHashSignature) error {
+	if len(signature) < x.bands*x.rows {
+		return ErrSignatureMismatch
+	}
+	for _, stored := range x.signatures {
+		if len(stored) != len(signature) {
+			return ErrSignatureMismatch
+		}
+		break
+	}
+	return nil
+}
+
+// Add stores the signature under the key.
+func (x *LSHIndex) Add(key string, signature MinHashSignature) error {
+	if _, ok := x.signatures[key]; ok {
+		return ErrDuplicateKey
+	}
+	if err := x.checkSize(signature); err != nil {
+		return err
+	}
+	x.signatures[key] = signature
+	for band := range x.buckets {
+		bandKey := x.bandKey(signature, band)
+		x.buckets[band][bandKey] = append(x.buckets[band][bandKey], key)
+	}
+	return nil
+}
+
+// Query returns the stored signatures whose estimated similarity to the
+// signature is at least the threshold of the index, the most similar
+// first. Only the signatures sharing a band with it are compared, so a
+// few similar signatures may be missed.
+func (x *LSHIndex) Query(signature MinHashSignature) ([]LSHMatch, error) {
+	if err := x.checkSize(signature); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var matches []LSHMatch
+	for band := range x.buckets {
+		for _, key := range x.buckets[band][x.bandKey(signature, band)] {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			similarity, err := signature.Similarity(x.signatures[key])
+			if err != nil {
+				return nil, err
+			}
+			if similarity >= x.threshold {
+				matches = append(matches, LSHMatch{Key: key, Similarity: similarity})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].Key < matches[j].Key
+	})
+	return matches, nil
+}
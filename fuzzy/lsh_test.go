@@ -0,0 +1,54 @@
+package fuzzy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLSHParameters(t *testing.T) {
+	for _, threshold := range []float64{0.3, 0.5, 0.8, 0.9} {
+		bands, rows := lshParameters(128, threshold)
+		assert.True(t, bands*rows <= 128)
+		// The threshold of a banding is about (1/bands)^(1/rows).
+		assert.InDelta(t, threshold, math.Pow(1/float64(bands), 1/float64(rows)), 0.15, "threshold %f", threshold)
+	}
+}
+
+func TestLSHIndex(t *testing.T) {
+	minHasher, err := NewMinHasher(MinHashOptions{Shingles: ShingleOptions{Size: 2}})
+	require.NoError(t, err, "Error creating MinHasher")
+	index, err := NewLSHIndex(128, 0.7)
+	require.NoError(t, err, "Error creating index")
+
+	add := func(key string, text string) {
+		signature, err := minHasher.Signature(text)
+		require.NoError(t, err, "Error computing signature")
+		require.NoError(t, index.Add(key, signature), "Error adding %s", key)
+	}
+	add("original", document)
+	add("copy", strings.Replace(document, "single", "lone", 1))
+	for i := 0; i < 50; i++ {
+		add(fmt.Sprintf("other-%d", i), string(randomText(int64(i), len(document))))
+	}
+	assert.Equal(t, 52, index.Len())
+
+	signature, err := minHasher.Signature(strings.Replace(document, "whole", "entire", 1))
+	require.NoError(t, err, "Error computing signature")
+	matches, err := index.Query(signature)
+	require.NoError(t, err, "Error querying index")
+	require.Len(t, matches, 2)
+	assert.Equal(t, "original", matches[0].Key)
+	assert.Equal(t, "copy", matches[1].Key)
+	assert.True(t, matches[0].Similarity >= 0.7 && matches[0].Similarity < 1)
+
+	assert.Equal(t, ErrDuplicateKey, index.Add("copy", signature))
+	_, err = index.Query(signature[:64])
+	assert.Equal(t, ErrSignatureMismatch, err)
+	_, err = NewLSHIndex(128, 1.5)
+	assert.Equal(t, ErrInvalidThreshold, err)
+}
@@ -0,0 +1,111 @@
+package fuzzy
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"math/rand"
+)
+
+var ErrSignatureMismatch = errors.New("hashutils: MinHash signatures have different sizes")
+
+// mersennePrime61 is the modulus of the permutations of MinHash.
+const mersennePrime61 = 1<<61 - 1
+
+// MinHashOptions configures a MinHasher.
+type MinHashOptions struct {
+	// Permutations is the size of the signatures; 0 selects 128. The
+	// error of the similarity estimate is about 1/sqrt(Permutations).
+	Permutations int
+	// Seed selects the permutations; signatures are only comparable when
+	// computed with the same seed.
+	Seed     int64
+	Shingles ShingleOptions
+}
+
+// MinHasher computes MinHash signatures, estimating the Jaccard
+// similarity of the sets of shingles of texts.
+type MinHasher struct {
+	options MinHashOptions
+	a, b    []uint64
+}
+
+// MinHashSignature holds the minimum of every permutation of the
+// shingle hashes of a text.
+type MinHashSignature []uint64
+
+// NewMinHasher returns a MinHasher whose permutations are the universal
+// hashes (a*x + b) mod 2^61-1, with a and b drawn from the seed.
+func NewMinHasher(options MinHashOptions) (*MinHasher, error) {
+	if options.Permutations <= 0 {
+		options.Permutations = 128
+	}
+	if _, err := shingleHash(options.Shingles); err != nil {
+		return nil, err
+	}
+	random := rand.New(rand.NewSource(options.Seed))
+	m := &MinHasher{
+		options: options,
+		a:       make([]uint64, options.Permutations),
+		b:       make([]uint64, options.Permutations),
+	}
+	for i := range m.a {
+		m.a[i] = 1 + uint64(random.Int63n(mersennePrime61-1))
+		m.b[i] = uint64(random.Int63n(mersennePrime61))
+	}
+	return m, nil
+}
+
+// mulMod61 returns a*x + b modulo 2^61-1 for values below the modulus.
+func mulMod61(a uint64, x uint64, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, x)
+	lo, carry := bits.Add64(lo, b, 0)
+	hi += carry
+	// 2^64 is 2^3 modulo 2^61-1.
+	sum := lo&mersennePrime61 + (lo>>61 | hi<<3)
+	sum = sum&mersennePrime61 + sum>>61
+	if sum >= mersennePrime61 {
+		sum -= mersennePrime61
+	}
+	return sum
+}
+
+// Signature returns the MinHash signature of the text. The signature of
+// a text without shingles has the maximum value everywhere.
+func (m *MinHasher) Signature(text string) (MinHashSignature, error) {
+	features, err := hashShingles(text, m.options.Shingles)
+	if err != nil {
+		return nil, err
+	}
+	signature := make(MinHashSignature, len(m.a))
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+	for feature := range features {
+		x := feature % mersennePrime61
+		for i := range signature {
+			if h := mulMod61(m.a[i], x, m.b[i]); h < signature[i] {
+				signature[i] = h
+			}
+		}
+	}
+	return signature, nil
+}
+
+// Similarity estimates the Jaccard similarity of the texts of the
+// signatures, the fraction of their permutations with the same minimum.
+func (s MinHashSignature) Similarity(other MinHashSignature) (float64, error) {
+	if len(s) != len(other) {
+		return 0, ErrSignatureMismatch
+	}
+	if len(s) == 0 {
+		return 0, nil
+	}
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(s)), nil
+}
@@ -0,0 +1,78 @@
+package fuzzy
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jaccard returns the Jaccard similarity of the shingles of the texts.
+func jaccard(text1 string, text2 string, options ShingleOptions) float64 {
+	set := make(map[string]bool)
+	for _, shingle := range Shingles(text1, options) {
+		set[shingle] = true
+	}
+	union := make(map[string]bool)
+	intersection := make(map[string]bool)
+	for _, shingle := range Shingles(text2, options) {
+		if set[shingle] {
+			intersection[shingle] = true
+		}
+		union[shingle] = true
+	}
+	for shingle := range set {
+		union[shingle] = true
+	}
+	return float64(len(intersection)) / float64(len(union))
+}
+
+func TestMinHash(t *testing.T) {
+	options := MinHashOptions{Permutations: 256, Seed: 7, Shingles: ShingleOptions{Size: 2}}
+	minHasher, err := NewMinHasher(options)
+	require.NoError(t, err, "Error creating MinHasher")
+	signature, err := minHasher.Signature(document)
+	require.NoError(t, err, "Error computing signature")
+	assert.Len(t, signature, 256)
+
+	similarity, err := signature.Similarity(signature)
+	require.NoError(t, err, "Error comparing signatures")
+	assert.Equal(t, 1.0, similarity)
+
+	edited := strings.Replace(strings.Replace(document, "single", "lone", 1), "whole", "entire", 1)
+	editedSignature, err := minHasher.Signature(edited)
+	require.NoError(t, err, "Error computing signature")
+	similarity, err = signature.Similarity(editedSignature)
+	require.NoError(t, err, "Error comparing signatures")
+	expected := jaccard(document, edited, options.Shingles)
+	assert.True(t, math.Abs(similarity-expected) < 0.1, "estimated %f, expected %f", similarity, expected)
+
+	other, err := minHasher.Signature(string(randomText(1, len(document))))
+	require.NoError(t, err, "Error computing signature")
+	similarity, err = signature.Similarity(other)
+	require.NoError(t, err, "Error comparing signatures")
+	assert.True(t, similarity < 0.1, "similarity %f", similarity)
+
+	_, err = signature.Similarity(signature[:10])
+	assert.Equal(t, ErrSignatureMismatch, err)
+}
+
+func TestMinHasherDefaults(t *testing.T) {
+	minHasher, err := NewMinHasher(MinHashOptions{})
+	require.NoError(t, err, "Error creating MinHasher")
+	signature, err := minHasher.Signature("")
+	require.NoError(t, err, "Error computing signature")
+	assert.Len(t, signature, 128)
+	assert.Equal(t, uint64(math.MaxUint64), signature[0])
+
+	_, err = NewMinHasher(MinHashOptions{Shingles: ShingleOptions{Hash: "md5"}})
+	assert.Error(t, err)
+}
+
+func TestMulMod61(t *testing.T) {
+	assert.Equal(t, uint64(7), mulMod61(2, 3, 1))
+	assert.Equal(t, uint64(0), mulMod61(mersennePrime61-1, 1, 1))
+	assert.Equal(t, uint64(1), mulMod61(mersennePrime61-1, mersennePrime61-1, 0))
+}
@@ -0,0 +1,98 @@
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+// Tokenizer splits a text into the tokens shingles are made of.
+type Tokenizer func(text string) []string
+
+// Words splits the text into lowercase words, the runs of letters and
+// digits, so that punctuation, case and spacing changes are ignored.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Characters splits the text into its characters, white space included.
+func Characters(text string) []string {
+	tokens := make([]string, 0, len(text))
+	for _, r := range text {
+		tokens = append(tokens, string(r))
+	}
+	return tokens
+}
+
+// ShingleOptions selects the features SimHash and MinHash compute from
+// a text: its shingles, the sequences of consecutive tokens.
+type ShingleOptions struct {
+	// Tokenizer splits the text into tokens; nil selects Words.
+	Tokenizer Tokenizer
+	// Size is the number of tokens of a shingle; 0 selects 1.
+	Size int
+	// Hash is the 64-bit hash of the shingles, xxhash64, fnv64 or
+	// fnv64a; "" selects xxhash64.
+	Hash hash.Algorithm
+}
+
+// Shingles returns the shingles of the text, in order and with
+// repetitions, each being its tokens joined by a space. A text with
+// fewer tokens than the size of the shingles is a single shingle.
+func Shingles(text string, options ShingleOptions) []string {
+	tokenizer := options.Tokenizer
+	if tokenizer == nil {
+		tokenizer = Words
+	}
+	size := options.Size
+	if size <= 0 {
+		size = 1
+	}
+	tokens := tokenizer(text)
+	if len(tokens) == 0 {
+		return nil
+	}
+	if len(tokens) < size {
+		return []string{strings.Join(tokens, " ")}
+	}
+	shingles := make([]string, 0, len(tokens)-size+1)
+	for i := 0; i+size <= len(tokens); i++ {
+		shingles = append(shingles, strings.Join(tokens[i:i+size], " "))
+	}
+	return shingles
+}
+
+// shingleHash returns the hash function of the shingles.
+func shingleHash(options ShingleOptions) (func(string) (uint64, error), error) {
+	switch options.Hash {
+	case "", hash.XxHash64Hash:
+		return hash.XxHash64, nil
+	case hash.Fnv64Hash:
+		return hash.Fnv64, nil
+	case hash.Fnv64aHash:
+		return hash.Fnv64a, nil
+	}
+	return nil, hash.ErrUnsupportedAlgorithm
+}
+
+// hashShingles returns the distinct hashes of the shingles of the text
+// with the number of times they occur.
+func hashShingles(text string, options ShingleOptions) (map[uint64]int, error) {
+	fn, err := shingleHash(options)
+	if err != nil {
+		return nil, err
+	}
+	shingles := Shingles(text, options)
+	features := make(map[uint64]int, len(shingles))
+	for _, shingle := range shingles {
+		sum, err := fn(shingle)
+		if err != nil {
+			return nil, err
+		}
+		features[sum]++
+	}
+	return features, nil
+}
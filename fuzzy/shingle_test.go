@@ -0,0 +1,33 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShingles(t *testing.T) {
+	assert.Equal(t, []string{"the", "quick", "brown", "fox"}, Words("The quick, brown\tfox!"))
+	assert.Equal(t, []string{"f", "o", "x", " "}, Characters("fox "))
+
+	text := "The quick brown fox jumps"
+	assert.Equal(t, []string{"the", "quick", "brown", "fox", "jumps"}, Shingles(text, ShingleOptions{}))
+	assert.Equal(t, []string{"the quick brown", "quick brown fox", "brown fox jumps"}, Shingles(text, ShingleOptions{Size: 3}))
+	assert.Equal(t, []string{"the quick brown fox jumps"}, Shingles(text, ShingleOptions{Size: 8}))
+	assert.Equal(t, []string{"f o x", "o x !"}, Shingles("fox!", ShingleOptions{Tokenizer: Characters, Size: 3}))
+	assert.Empty(t, Shingles(" ... ", ShingleOptions{}))
+}
+
+func TestHashShingles(t *testing.T) {
+	features, err := hashShingles("to be or not to be", ShingleOptions{Size: 2, Hash: hash.Fnv64aHash})
+	require.NoError(t, err, "Error hashing shingles")
+	toBe, err := hash.Fnv64a("to be")
+	require.NoError(t, err)
+	assert.Len(t, features, 4)
+	assert.Equal(t, 2, features[toBe])
+
+	_, err = hashShingles("to be", ShingleOptions{Hash: hash.Sha256Hash})
+	assert.Equal(t, hash.ErrUnsupportedAlgorithm, err)
+}
@@ -0,0 +1,37 @@
+package fuzzy
+
+import "math/bits"
+
+// SimHash returns the 64-bit SimHash fingerprint of the text: every bit
+// is set when most of the shingles, weighted by the number of times they
+// occur, have it set in their hash. Similar texts have fingerprints at a
+// small Hamming distance.
+func SimHash(text string, options ShingleOptions) (uint64, error) {
+	features, err := hashShingles(text, options)
+	if err != nil {
+		return 0, err
+	}
+	var weights [64]int
+	for feature, count := range features {
+		for i := range weights {
+			if feature&(1<<uint(i)) != 0 {
+				weights[i] += count
+			} else {
+				weights[i] -= count
+			}
+		}
+	}
+	var fingerprint uint64
+	for i, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+	return fingerprint, nil
+}
+
+// HammingDistance returns the number of bits that differ between the
+// fingerprints.
+func HammingDistance(fingerprint1 uint64, fingerprint2 uint64) int {
+	return bits.OnesCount64(fingerprint1 ^ fingerprint2)
+}
@@ -0,0 +1,41 @@
+package fuzzy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const document = `Exact digests tell whether two documents are identical, but a single
+edited word changes them completely. Locality sensitive hashes instead map
+similar documents to similar values, so that near duplicates can be found
+by comparing fingerprints or signatures rather than whole documents.`
+
+func TestSimHash(t *testing.T) {
+	for _, algorithm := range []hash.Algorithm{hash.XxHash64Hash, hash.Fnv64Hash, hash.Fnv64aHash} {
+		options := ShingleOptions{Size: 2, Hash: algorithm}
+		fingerprint, err := SimHash(document, options)
+		require.NoError(t, err, "Error computing SimHash using %s", algorithm)
+
+		reformatted, err := SimHash(strings.ToUpper(strings.Join(strings.Fields(document), " ")), options)
+		require.NoError(t, err, "Error computing SimHash using %s", algorithm)
+		assert.Equal(t, fingerprint, reformatted)
+
+		edited, err := SimHash(strings.Replace(document, "single", "lone", 1), options)
+		require.NoError(t, err, "Error computing SimHash using %s", algorithm)
+		other, err := SimHash(string(randomText(1, len(document))), options)
+		require.NoError(t, err, "Error computing SimHash using %s", algorithm)
+		assert.True(t, HammingDistance(fingerprint, edited) < HammingDistance(fingerprint, other),
+			"%s: %d >= %d", algorithm, HammingDistance(fingerprint, edited), HammingDistance(fingerprint, other))
+		assert.True(t, HammingDistance(fingerprint, edited) <= 10, "%s: %d", algorithm, HammingDistance(fingerprint, edited))
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	assert.Equal(t, 0, HammingDistance(42, 42))
+	assert.Equal(t, 64, HammingDistance(0, ^uint64(0)))
+	assert.Equal(t, 2, HammingDistance(0x5, 0x0))
+}
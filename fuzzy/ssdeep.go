@@ -0,0 +1,272 @@
+package fuzzy
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidSSDeep = errors.New("hashutils: invalid ssdeep signature")
+
+const (
+	// spamsumLength is the maximum length of the first part of an
+	// ssdeep signature; the second part is half as long.
+	spamsumLength = 64
+	minBlockSize  = 3
+	rollingWindow = 7
+	hashPrime     = 0x01000193
+	hashInit      = 0x28021967
+	alphabetB64   = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+)
+
+// rollingHash is the rolling hash of the last rollingWindow bytes that
+// triggers the end of a piece of the input.
+type rollingHash struct {
+	window     [rollingWindow]byte
+	h1, h2, h3 uint32
+	n          uint32
+}
+
+func (r *rollingHash) roll(c byte) uint32 {
+	r.h2 -= r.h1
+	r.h2 += rollingWindow * uint32(c)
+	r.h1 += uint32(c)
+	r.h1 -= uint32(r.window[r.n%rollingWindow])
+	r.window[r.n%rollingWindow] = c
+	r.n++
+	r.h3 <<= 5
+	r.h3 ^= uint32(c)
+	return r.h1 + r.h2 + r.h3
+}
+
+// SSDeep returns the context triggered piecewise hash of the data, in
+// the "blocksize:signature:signature" format of ssdeep and spamsum.
+func SSDeep(data []byte) string {
+	blockSize := uint32(minBlockSize)
+	for blockSize*spamsumLength < uint32(len(data)) {
+		blockSize *= 2
+	}
+	for {
+		first, second, pieces := ssdeepSignatures(data, blockSize)
+		// Retry with a smaller block size when the signature is too short
+		// to be meaningful.
+		if blockSize > minBlockSize && pieces < spamsumLength/2 {
+			blockSize /= 2
+			continue
+		}
+		return strconv.FormatUint(uint64(blockSize), 10) + ":" + first + ":" + second
+	}
+}
+
+// ssdeepSignatures computes the signatures of the data for the block
+// size and for twice the block size, and returns them along with the
+// number of pieces the data was split into with the block size.
+func ssdeepSignatures(data []byte, blockSize uint32) (string, string, int) {
+	var first [spamsumLength]byte
+	var second [spamsumLength / 2]byte
+	var roll rollingHash
+	var h uint32
+	h1, h2 := uint32(hashInit), uint32(hashInit)
+	j, k := 0, 0
+	for _, c := range data {
+		h = roll.roll(c)
+		h1 = (h1 * hashPrime) ^ uint32(c)
+		h2 = (h2 * hashPrime) ^ uint32(c)
+		if h%blockSize == blockSize-1 {
+			first[j] = alphabetB64[h1%64]
+			if j < spamsumLength-1 {
+				h1 = hashInit
+				j++
+			}
+		}
+		if h%(2*blockSize) == 2*blockSize-1 {
+			second[k] = alphabetB64[h2%64]
+			if k < spamsumLength/2-1 {
+				h2 = hashInit
+				k++
+			}
+		}
+	}
+	pieces := j
+	// The last piece is always part of the signature.
+	if h != 0 {
+		first[j] = alphabetB64[h1%64]
+		second[k] = alphabetB64[h2%64]
+	}
+	if first[j] != 0 {
+		j++
+	}
+	if second[k] != 0 {
+		k++
+	}
+	return string(first[:j]), string(second[:k]), pieces
+}
+
+// SSDeepReader returns the ssdeep signature of the content of the
+// reader, which is read entirely in memory.
+func SSDeepReader(reader io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return SSDeep(data), nil
+}
+
+// SSDeepFile returns the ssdeep signature of the file.
+func SSDeepFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return SSDeepReader(file)
+}
+
+type ssdeepSignature struct {
+	blockSize     uint32
+	first, second string
+}
+
+func parseSSDeep(signature string) (ssdeepSignature, error) {
+	parts := strings.SplitN(signature, ":", 3)
+	if len(parts) != 3 {
+		return ssdeepSignature{}, ErrInvalidSSDeep
+	}
+	// Some tools append the file name after a comma.
+	second := parts[2]
+	if i := strings.IndexByte(second, ','); i >= 0 {
+		second = second[:i]
+	}
+	blockSize, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil || blockSize < minBlockSize || len(parts[1]) > spamsumLength || len(second) > spamsumLength {
+		return ssdeepSignature{}, ErrInvalidSSDeep
+	}
+	return ssdeepSignature{
+		blockSize: uint32(blockSize),
+		first:     eliminateSequences(parts[1]),
+		second:    eliminateSequences(second),
+	}, nil
+}
+
+// eliminateSequences shortens the runs of more than three identical
+// characters to three characters, as they carry little information.
+func eliminateSequences(s string) string {
+	b := []byte(s)
+	n := 0
+	for i := range b {
+		if i >= 3 && s[i] == s[i-1] && s[i] == s[i-2] && s[i] == s[i-3] {
+			continue
+		}
+		b[n] = s[i]
+		n++
+	}
+	return string(b[:n])
+}
+
+// CompareSSDeep returns the similarity of the data two ssdeep
+// signatures were computed from, from 0 for no similarity to 100 for
+// identical data, using the scoring of ssdeep. Signatures whose block
+// sizes are neither equal nor a factor of two apart can't be compared
+// and score 0.
+func CompareSSDeep(signature1 string, signature2 string) (int, error) {
+	s1, err := parseSSDeep(signature1)
+	if err != nil {
+		return 0, err
+	}
+	s2, err := parseSSDeep(signature2)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case s1.blockSize == s2.blockSize:
+		if s1.first == s2.first && s1.second == s2.second {
+			return 100, nil
+		}
+		score1 := scoreSignatures(s1.first, s2.first, s1.blockSize)
+		score2 := scoreSignatures(s1.second, s2.second, 2*s1.blockSize)
+		if score2 > score1 {
+			return score2, nil
+		}
+		return score1, nil
+	case s1.blockSize == 2*s2.blockSize:
+		return scoreSignatures(s1.first, s2.second, s1.blockSize), nil
+	case 2*s1.blockSize == s2.blockSize:
+		return scoreSignatures(s1.second, s2.first, s2.blockSize), nil
+	}
+	return 0, nil
+}
+
+// scoreSignatures scores two signatures of the same block size. Only
+// signatures sharing a run of rollingWindow characters are considered
+// related.
+func scoreSignatures(s1 string, s2 string, blockSize uint32) int {
+	if !hasCommonSubstring(s1, s2) {
+		return 0
+	}
+	score := editDistance(s1, s2) * spamsumLength / (len(s1) + len(s2))
+	score = 100 * score / spamsumLength
+	if score >= 100 {
+		return 0
+	}
+	score = 100 - score
+	// Short signatures of small block sizes match too easily, so their
+	// score is capped by the length of the signatures.
+	if blockSize >= (99+rollingWindow)/rollingWindow*minBlockSize {
+		return score
+	}
+	length := len(s1)
+	if len(s2) < length {
+		length = len(s2)
+	}
+	if limit := int(blockSize) / minBlockSize * length; score > limit {
+		return limit
+	}
+	return score
+}
+
+func hasCommonSubstring(s1 string, s2 string) bool {
+	if len(s1) < rollingWindow || len(s2) < rollingWindow {
+		return false
+	}
+	substrings := make(map[string]bool, len(s1)-rollingWindow+1)
+	for i := 0; i+rollingWindow <= len(s1); i++ {
+		substrings[s1[i:i+rollingWindow]] = true
+	}
+	for i := 0; i+rollingWindow <= len(s2); i++ {
+		if substrings[s2[i:i+rollingWindow]] {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance is the Levenshtein distance where a substitution costs as
+// much as a deletion and an insertion.
+func editDistance(s1 string, s2 string) int {
+	previous := make([]int, len(s2)+1)
+	current := make([]int, len(s2)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(s1); i++ {
+		current[0] = i
+		for j := 1; j <= len(s2); j++ {
+			cost := previous[j-1]
+			if s1[i-1] != s2[j-1] {
+				cost += 2
+			}
+			if previous[j]+1 < cost {
+				cost = previous[j] + 1
+			}
+			if current[j-1]+1 < cost {
+				cost = current[j-1] + 1
+			}
+			current[j] = cost
+		}
+		previous, current = current, previous
+	}
+	return previous[len(s2)]
+}
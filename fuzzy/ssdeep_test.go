@@ -0,0 +1,161 @@
+package fuzzy
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomText returns deterministic text of the length made of words.
+func randomText(seed int64, length int) []byte {
+	words := []string{"hash", "file", "block", "piece", "digest", "fuzzy", "signature", "rolling", "window", "data"}
+	random := rand.New(rand.NewSource(seed))
+	var b strings.Builder
+	for b.Len() < length {
+		b.WriteString(words[random.Intn(len(words))])
+		b.WriteByte(" \n"[random.Intn(2)])
+	}
+	return []byte(b.String()[:length])
+}
+
+// randomData returns deterministic random bytes.
+func randomData(seed int64, length int) []byte {
+	data := make([]byte, length)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+// edit returns a copy of the data with a few bytes overwritten.
+func edit(data []byte, offsets ...int) []byte {
+	edited := append([]byte(nil), data...)
+	for _, offset := range offsets {
+		edited[offset] = '#'
+	}
+	return edited
+}
+
+func TestSSDeep(t *testing.T) {
+	assert.Equal(t, "3::", SSDeep(nil))
+
+	data := randomText(1, 20000)
+	signature := SSDeep(data)
+	assert.Equal(t, signature, SSDeep(data))
+	parts := strings.Split(signature, ":")
+	require.Len(t, parts, 3)
+	assert.True(t, len(parts[1]) > spamsumLength/2 && len(parts[1]) <= spamsumLength, signature)
+	assert.True(t, len(parts[2]) <= spamsumLength/2, signature)
+}
+
+func TestCompareSSDeep(t *testing.T) {
+	data := randomText(1, 20000)
+	signature := SSDeep(data)
+
+	score, err := CompareSSDeep(signature, signature)
+	require.NoError(t, err, "Error comparing signatures")
+	assert.Equal(t, 100, score)
+
+	score, err = CompareSSDeep(signature, SSDeep(edit(data, 5000, 15000)))
+	require.NoError(t, err, "Error comparing signatures")
+	assert.True(t, score > 50 && score < 100, "score %d", score)
+
+	score, err = CompareSSDeep(signature, SSDeep(randomData(2, 20000)))
+	require.NoError(t, err, "Error comparing signatures")
+	assert.Equal(t, 0, score)
+
+	score, err = CompareSSDeep(signature, SSDeep(data[:19000])+`,"file.txt"`)
+	require.NoError(t, err, "Error comparing signatures")
+	assert.True(t, score > 50, "score %d", score)
+
+	_, err = CompareSSDeep(signature, "garbage")
+	assert.Equal(t, ErrInvalidSSDeep, err)
+	_, err = CompareSSDeep("1:abc:abc", signature)
+	assert.Equal(t, ErrInvalidSSDeep, err)
+}
+
+// TestSSDeepGolden checks signatures and scores of the ssdeep tool.
+func TestSSDeepGolden(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for _, test := range []struct {
+		size      int
+		signature string
+	}{
+		{4097, "96:yNDH/iNQaSXRLmOSxu1aQP4iWgC8JbkiA5Ix:yNLaNQhSxEgVYkiA5Ix"},
+		{45056, "768:mlHmRZnCRFRwSuK/UiwY37TMbsDEsb1Jqi6dcXoWpKXIUxpQDOAvWpPK:mqhCJwjmJD31DzbDwd+oGo9AvOi"},
+		{86016, "1536:Jdr3F6yZG0agLg/b6G6REjI+WUhWDKRSpzKjSUT4plmjvX6ex7RwdsHIGV:PrVbZG0BuuGzc+WcdRilmbPx7RwGV"},
+		{126976, "3072:pwP2ZmVLsvDAyshOZIzFkGxIE++3ysSsZCj3JwAjpn:ps2/DAyKIaRyE++RSsUj3JwaJ"},
+		{167936, "3072:20RnMAMjfifg0w9B9pd4RcuCOpjSFkhfZn8bA7KT3Dwp8iKXDgBU7bocn2INL9WJ:zRfvw9B9pd47+qfZ0A+T3DWFK04kcXNe"},
+	} {
+		data := make([]byte, test.size)
+		random.Read(data)
+		assert.Equal(t, test.signature, SSDeep(data), "size %d", test.size)
+	}
+
+	for _, test := range []struct {
+		signature1, signature2 string
+		score                  int
+	}{
+		{
+			"192:MUPMinqP6+wNQ7Q40L/iB3n2rIBrP0GZKF4jsef+0FVQLSwbLbj41iH8nFVYv980:x0CllivQiFmt",
+			"192:MUPMinqP6+wNQ7Q40L/iB3n2rIBrP0GZKF4jsef+0FVQLSwbLbj41iH8nFVYv980:x0CllivQiFmt",
+			100,
+		},
+		{
+			"192:MUPMinqP6+wNQ7Q40L/iB3n2rIBrP0GZKF4jsef+0FVQLSwbLbj41iH8nFVYv980:x0CllivQiFmt",
+			"192:JkjRcePWsNVQza3ntZStn5VfsoXMhRD9+xJMinqF6+wNQ7Q40L/i737rPVt:JkjlQyIrx+kll2",
+			35,
+		},
+		{
+			"196608:pDSC8olnoL1v/uawvbQD7XlZUFYzYyMb615NktYHF7dREN/JNnQrmhnUPI+/n2Yr:5DHoJXv7XOq7Mb2TwYHXREN/3QrmktPd",
+			"196608:7DSC8olnoL1v/uawvbQD7XlZUFYzYyMb615NktYHF7dREN/JNnQrmhnUPI+/n2Y7:3DHoJXv7XOq7Mb2TwYHXREN/3QrmktPt",
+			97,
+		},
+		{
+			"196608:pDSC8olnoL1v/uawvbQD7XlZUFYzYyMb615NktYHF7dREN/JNnQrmhnUPI+/n2Yr:5DHoJXv7XOq7Mb2TwYHXREN/3QrmktPd,\"/home/user/images/disk-2019-08.img\"",
+			"196608:7DSC8olnoL1v/uawvbQD7XlZUFYzYyMb615NktYHF7dREN/JNnQrmhnUPI+/n2Y7:3DHoJXv7XOq7Mb2TwYHXREN/3QrmktPt,\"/home/user/images/disk-2019-09.img\"",
+			97,
+		},
+		{
+			"24:YDVLfsT1ds/1H9Wpgq7n4XMijV6h4Z3QCw4qat:YD51H9CiMuV6uACwVat",
+			"24:YDVLfyvDj+C+opg8DV0Mdle6hPZ3QCw4qat:YDMvDj+C+kBOM+6HACwVat",
+			54,
+		},
+	} {
+		score, err := CompareSSDeep(test.signature1, test.signature2)
+		require.NoError(t, err, "Error comparing signatures")
+		assert.Equal(t, test.score, score, test.signature1)
+	}
+}
+
+func TestSSDeepFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuzzy")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	data := randomText(1, 4096)
+	path := filepath.Join(dir, "data")
+	require.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+	signature, err := SSDeepFile(path)
+	require.NoError(t, err, "Error hashing file")
+	assert.Equal(t, SSDeep(data), signature)
+
+	_, err = SSDeepFile(filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestEliminateSequences(t *testing.T) {
+	assert.Equal(t, "aaabccc", eliminateSequences("aaaaabcccc"))
+	assert.Equal(t, "", eliminateSequences(""))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("abc", "abc"))
+	assert.Equal(t, 2, editDistance("abc", "abd"))
+	assert.Equal(t, 1, editDistance("abc", "ab"))
+	assert.Equal(t, 3, editDistance("", "abc"))
+}
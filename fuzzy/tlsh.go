@@ -0,0 +1,248 @@
+package fuzzy
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+var ErrTLSHTooShort = errors.New("hashutils: TLSH needs at least 50 bytes of input")
+
+var ErrTLSHLowVariation = errors.New("hashutils: input has too little variation for TLSH")
+
+var ErrInvalidTLSH = errors.New("hashutils: invalid TLSH digest")
+
+const (
+	tlshBuckets       = 128
+	tlshCodeSize      = tlshBuckets / 4
+	tlshMinDataLength = 50
+	tlshWindow        = 5
+	// tlshVersion prefixes the digests of the current TLSH version.
+	tlshVersion = "T1"
+)
+
+// pearsonTable is the permutation of the bytes used by the Pearson
+// hashes of TLSH.
+var pearsonTable = [256]byte{
+	1, 87, 49, 12, 176, 178, 102, 166, 121, 193, 6, 84, 249, 230, 44, 163,
+	14, 197, 213, 181, 161, 85, 218, 80, 64, 239, 24, 226, 236, 142, 38, 200,
+	110, 177, 104, 103, 141, 253, 255, 50, 77, 101, 81, 18, 45, 96, 31, 222,
+	25, 107, 190, 70, 86, 237, 240, 34, 72, 242, 20, 214, 244, 227, 149, 235,
+	97, 234, 57, 22, 60, 250, 82, 175, 208, 5, 127, 199, 111, 62, 135, 248,
+	174, 169, 211, 58, 66, 154, 106, 195, 245, 171, 17, 187, 182, 179, 0, 243,
+	132, 56, 148, 75, 128, 133, 158, 100, 130, 126, 91, 13, 153, 246, 216, 219,
+	119, 68, 223, 78, 83, 88, 201, 99, 122, 11, 92, 32, 136, 114, 52, 10,
+	138, 30, 48, 183, 156, 35, 61, 26, 143, 74, 251, 94, 129, 162, 63, 152,
+	170, 7, 115, 167, 241, 206, 3, 150, 55, 59, 151, 220, 90, 53, 23, 131,
+	125, 173, 15, 238, 79, 95, 89, 16, 105, 137, 225, 224, 217, 160, 37, 123,
+	118, 73, 2, 157, 46, 116, 9, 145, 134, 228, 207, 212, 202, 215, 69, 229,
+	27, 188, 67, 124, 168, 252, 42, 4, 29, 108, 21, 247, 19, 205, 39, 203,
+	233, 40, 186, 147, 198, 192, 155, 33, 164, 191, 98, 204, 165, 180, 117, 76,
+	140, 36, 210, 172, 41, 54, 159, 8, 185, 232, 113, 196, 231, 47, 146, 120,
+	51, 65, 28, 144, 254, 221, 93, 189, 194, 139, 112, 43, 71, 109, 184, 209,
+}
+
+func pearson(salt byte, i byte, j byte, k byte) byte {
+	h := pearsonTable[salt]
+	h = pearsonTable[h^i]
+	h = pearsonTable[h^j]
+	return pearsonTable[h^k]
+}
+
+// tlshDigest is a decoded TLSH digest.
+type tlshDigest struct {
+	checksum byte
+	lValue   byte
+	q1Ratio  byte
+	q2Ratio  byte
+	code     [tlshCodeSize]byte
+}
+
+// TLSH returns the Trend Micro Locality Sensitive Hash of the data, as
+// the 72 characters hexadecimal digest with the "T1" version prefix of
+// the reference implementation, using 128 buckets and a 1 byte
+// checksum. The data must be at least 50 bytes long and varied enough
+// to fill more than half the buckets.
+func TLSH(data []byte) (string, error) {
+	if len(data) < tlshMinDataLength {
+		return "", ErrTLSHTooShort
+	}
+	var buckets [256]uint32
+	var digest tlshDigest
+	for i := tlshWindow - 1; i < len(data); i++ {
+		w0, w1, w2, w3, w4 := data[i], data[i-1], data[i-2], data[i-3], data[i-4]
+		digest.checksum = pearson(0, w0, w1, digest.checksum)
+		buckets[pearson(2, w0, w1, w2)]++
+		buckets[pearson(3, w0, w1, w3)]++
+		buckets[pearson(5, w0, w2, w3)]++
+		buckets[pearson(7, w0, w2, w4)]++
+		buckets[pearson(11, w0, w1, w4)]++
+		buckets[pearson(13, w0, w3, w4)]++
+	}
+
+	nonZero := 0
+	sorted := make([]uint32, tlshBuckets)
+	for i := range sorted {
+		sorted[i] = buckets[i]
+		if buckets[i] > 0 {
+			nonZero++
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	q1, q2, q3 := sorted[tlshBuckets/4-1], sorted[tlshBuckets/2-1], sorted[tlshBuckets*3/4-1]
+	if nonZero <= tlshBuckets/2 || q3 == 0 {
+		return "", ErrTLSHLowVariation
+	}
+
+	for i := range digest.code {
+		var h byte
+		for j := 0; j < 4; j++ {
+			switch k := buckets[4*i+j]; {
+			case q3 < k:
+				h += 3 << (uint(j) * 2)
+			case q2 < k:
+				h += 2 << (uint(j) * 2)
+			case q1 < k:
+				h += 1 << (uint(j) * 2)
+			}
+		}
+		digest.code[i] = h
+	}
+	digest.lValue = tlshLength(len(data))
+	digest.q1Ratio = byte(uint32(float32(q1*100)/float32(q3)) % 16)
+	digest.q2Ratio = byte(uint32(float32(q2*100)/float32(q3)) % 16)
+	return digest.String(), nil
+}
+
+// tlshLength encodes the length of the data on a logarithmic scale.
+func tlshLength(length int) byte {
+	l := math.Log(float64(length))
+	var i float64
+	switch {
+	case length <= 656:
+		i = math.Floor(l / 0.4054651)
+	case length <= 3199:
+		i = math.Floor(l/0.26236426 - 8.72777)
+	default:
+		i = math.Floor(l/0.095310180 - 62.5472)
+	}
+	return byte(int(i) & 0xff)
+}
+
+func swapNibbles(b byte) byte {
+	return b>>4 | b<<4
+}
+
+// String encodes the digest the way the reference implementation does:
+// the checksum, length and quartile ratios with their nibbles swapped,
+// followed by the code in reverse order.
+func (d tlshDigest) String() string {
+	raw := make([]byte, 0, 3+tlshCodeSize)
+	raw = append(raw, swapNibbles(d.checksum), swapNibbles(d.lValue), d.q1Ratio<<4|d.q2Ratio)
+	for i := tlshCodeSize - 1; i >= 0; i-- {
+		raw = append(raw, d.code[i])
+	}
+	return tlshVersion + strings.ToUpper(hex.EncodeToString(raw))
+}
+
+func parseTLSH(text string) (tlshDigest, error) {
+	var d tlshDigest
+	raw, err := hex.DecodeString(strings.TrimPrefix(text, tlshVersion))
+	if err != nil || len(raw) != 3+tlshCodeSize {
+		return d, ErrInvalidTLSH
+	}
+	d.checksum = swapNibbles(raw[0])
+	d.lValue = swapNibbles(raw[1])
+	d.q1Ratio, d.q2Ratio = raw[2]>>4, raw[2]&0x0f
+	for i := range d.code {
+		d.code[i] = raw[3+tlshCodeSize-1-i]
+	}
+	return d, nil
+}
+
+// TLSHReader returns the TLSH digest of the content of the reader,
+// which is read entirely in memory.
+func TLSHReader(reader io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return TLSH(data)
+}
+
+// TLSHFile returns the TLSH digest of the file.
+func TLSHFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return TLSHReader(file)
+}
+
+// DistanceTLSH returns the distance between the data two TLSH digests
+// were computed from, 0 for identical data and growing as they differ,
+// including the difference of their lengths. A distance under 100 is
+// usually considered similar.
+func DistanceTLSH(digest1 string, digest2 string) (int, error) {
+	d1, err := parseTLSH(digest1)
+	if err != nil {
+		return 0, err
+	}
+	d2, err := parseTLSH(digest2)
+	if err != nil {
+		return 0, err
+	}
+
+	diff := 0
+	switch lDiff := modDiff(int(d1.lValue), int(d2.lValue), 256); lDiff {
+	case 0, 1:
+		diff += lDiff
+	default:
+		diff += lDiff * 12
+	}
+	for _, qDiff := range []int{
+		modDiff(int(d1.q1Ratio), int(d2.q1Ratio), 16),
+		modDiff(int(d1.q2Ratio), int(d2.q2Ratio), 16),
+	} {
+		if qDiff <= 1 {
+			diff += qDiff
+		} else {
+			diff += (qDiff - 1) * 12
+		}
+	}
+	if d1.checksum != d2.checksum {
+		diff++
+	}
+	for i := range d1.code {
+		for j := uint(0); j < 8; j += 2 {
+			x, y := int(d1.code[i]>>j&3), int(d2.code[i]>>j&3)
+			d := x - y
+			if d < 0 {
+				d = -d
+			}
+			if d == 3 {
+				d = 6
+			}
+			diff += d
+		}
+	}
+	return diff, nil
+}
+
+// modDiff is the distance between x and y on a circle of size r.
+func modDiff(x int, y int, r int) int {
+	d := x - y
+	if d < 0 {
+		d = -d
+	}
+	if r-d < d {
+		return r - d
+	}
+	return d
+}
@@ -0,0 +1,124 @@
+package fuzzy
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSH(t *testing.T) {
+	data := randomText(1, 20000)
+	digest, err := TLSH(data)
+	require.NoError(t, err, "Error hashing data")
+	assert.Len(t, digest, 72)
+	assert.True(t, strings.HasPrefix(digest, "T1"), digest)
+
+	parsed, err := parseTLSH(digest)
+	require.NoError(t, err, "Error parsing digest")
+	assert.Equal(t, digest, parsed.String())
+
+	_, err = TLSH(data[:49])
+	assert.Equal(t, ErrTLSHTooShort, err)
+	_, err = TLSH(bytes.Repeat([]byte("a"), 1000))
+	assert.Equal(t, ErrTLSHLowVariation, err)
+}
+
+func TestDistanceTLSH(t *testing.T) {
+	data := randomText(1, 20000)
+	digest, err := TLSH(data)
+	require.NoError(t, err, "Error hashing data")
+
+	distance, err := DistanceTLSH(digest, digest)
+	require.NoError(t, err, "Error comparing digests")
+	assert.Equal(t, 0, distance)
+
+	edited, err := TLSH(edit(data, 100, 5000, 15000))
+	require.NoError(t, err, "Error hashing data")
+	similar, err := DistanceTLSH(digest, edited)
+	require.NoError(t, err, "Error comparing digests")
+	assert.True(t, similar > 0 && similar < 50, "distance %d", similar)
+
+	other, err := TLSH(randomData(2, 20000))
+	require.NoError(t, err, "Error hashing data")
+	unrelated, err := DistanceTLSH(digest, other)
+	require.NoError(t, err, "Error comparing digests")
+	assert.True(t, unrelated > 100, "distance %d", unrelated)
+
+	reversed, err := DistanceTLSH(other, digest)
+	require.NoError(t, err, "Error comparing digests")
+	assert.Equal(t, unrelated, reversed)
+
+	_, err = DistanceTLSH(digest, "T1XYZ")
+	assert.Equal(t, ErrInvalidTLSH, err)
+}
+
+// TestTLSHGolden checks digests and distances of the tlsh tool, the
+// test files and their digests coming from its test suite.
+func TestTLSHGolden(t *testing.T) {
+	for _, test := range []struct {
+		path   string
+		digest string
+	}{
+		{"testdata/tlsh_1.txt", "T18ED02202FC30802303A002B03B33300FC30A82F83008C2FA000A0080B8BA0E02CCA0C3"},
+		{"testdata/tlsh_2.txt", "T1B2319634F5C033244EB792AA3168A366E737553DA305A28440CE842D7B57A2CC63B6EC"},
+		{"testdata/tlsh_3.txt", "T1EA31834386C503B62A920319BA4F92D3BF6FC2B863384515A4EA5638450BC1E9376AE9"},
+		{"testdata/tlsh_4.txt", "T15111421E72610B73189A13A055B8A8D9B22BB25B7AAF2A84146DF245232A06CD5FB854"},
+	} {
+		digest, err := TLSHFile(test.path)
+		require.NoError(t, err, "Error hashing file")
+		assert.Equal(t, test.digest, digest, test.path)
+	}
+
+	const (
+		file1 = "T18ED02202FC30802303A002B03B33300FC30A82F83008C2FA000A0080B8BA0E02CCA0C3"
+		file2 = "T1B2319634F5C033244EB792AA3168A366E737553DA305A28440CE842D7B57A2CC63B6EC"
+		file3 = "T1EA31834386C503B62A920319BA4F92D3BF6FC2B863384515A4EA5638450BC1E9376AE9"
+		jpeg  = "T185C2F1CE3D989428683106EBE5EAAAC924F2D5020B38B1550DA8E5F0DD8C65DECF7037"
+		png   = "T1F7A433B5648BCC69DD48E1DDF1A1876C56E08C0BB264438FAB412C4686FA3F3DB05E36"
+	)
+	for _, test := range []struct {
+		digest1, digest2 string
+		distance         int
+	}{
+		{file1, file1, 0},
+		{file1, file2, 418},
+		{file1, png, 1014},
+		{file3, file1, 374},
+		{file3, png, 967},
+		{jpeg, png, 619},
+	} {
+		distance, err := DistanceTLSH(test.digest1, test.digest2)
+		require.NoError(t, err, "Error comparing digests")
+		assert.Equal(t, test.distance, distance)
+	}
+}
+
+func TestTLSHFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fuzzy")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	data := randomText(1, 4096)
+	path := filepath.Join(dir, "data")
+	require.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+	digest, err := TLSHFile(path)
+	require.NoError(t, err, "Error hashing file")
+	expected, err := TLSH(data)
+	require.NoError(t, err, "Error hashing data")
+	assert.Equal(t, expected, digest)
+}
+
+func TestTLSHLength(t *testing.T) {
+	previous := tlshLength(tlshMinDataLength)
+	for _, length := range []int{100, 656, 657, 3199, 3200, 1 << 20} {
+		l := tlshLength(length)
+		assert.True(t, l >= previous, "length %d", length)
+		previous = l
+	}
+}
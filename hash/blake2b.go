@@ -0,0 +1,143 @@
+package hash
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// blake2bIV is the initialization vector of BLAKE2b, the one of SHA-512.
+var blake2bIV = [8]uint64{
+	0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+	0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+}
+
+// blake2bSigma holds the message word permutations of the rounds.
+var blake2bSigma = [12][16]byte{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+}
+
+// blake2b is the streaming state of unkeyed BLAKE2b-512, as defined in
+// RFC 7693, which consumes the input in blocks of 128 bytes. The last
+// block is kept buffered, as it is compressed with the final flag.
+type blake2b struct {
+	h      [8]uint64
+	t      [2]uint64
+	buffer [128]byte
+	n      int
+}
+
+// NewBlake2b512 returns a new hash.Hash computing the unkeyed BLAKE2b-512
+// checksum, the default of b2sum.
+func NewBlake2b512() hash.Hash {
+	h := &blake2b{}
+	h.Reset()
+	return h
+}
+
+// Blake2b512 returns the BLAKE2b-512 checksum of a text as bytes.
+func Blake2b512(text string) ([]byte, error) {
+	return hashText(NewBlake2b512(), text)
+}
+
+func (h *blake2b) Reset() {
+	h.h = blake2bIV
+	// Parameter block: a digest length of 64 bytes, no key, fanout and
+	// depth of 1.
+	h.h[0] ^= 0x01010000 | 64
+	h.t = [2]uint64{}
+	h.n = 0
+}
+
+func (h *blake2b) Size() int {
+	return 64
+}
+
+func (h *blake2b) BlockSize() int {
+	return len(h.buffer)
+}
+
+func (h *blake2b) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		if h.n == len(h.buffer) {
+			h.increment(uint64(len(h.buffer)))
+			h.compress(h.buffer[:], false)
+			h.n = 0
+		}
+		copied := copy(h.buffer[h.n:], p)
+		h.n += copied
+		p = p[copied:]
+	}
+	return written, nil
+}
+
+func (h *blake2b) increment(n uint64) {
+	var carry uint64
+	h.t[0], carry = bits.Add64(h.t[0], n, 0)
+	h.t[1] += carry
+}
+
+func (h *blake2b) compress(block []byte, last bool) {
+	var v [16]uint64
+	copy(v[:8], h.h[:])
+	copy(v[8:], blake2bIV[:])
+	v[12] ^= h.t[0]
+	v[13] ^= h.t[1]
+	if last {
+		v[14] = ^v[14]
+	}
+	var m [16]uint64
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint64(block[8*i:])
+	}
+	g := func(a, b, c, d int, x, y uint64) {
+		v[a] += v[b] + x
+		v[d] = bits.RotateLeft64(v[d]^v[a], -32)
+		v[c] += v[d]
+		v[b] = bits.RotateLeft64(v[b]^v[c], -24)
+		v[a] += v[b] + y
+		v[d] = bits.RotateLeft64(v[d]^v[a], -16)
+		v[c] += v[d]
+		v[b] = bits.RotateLeft64(v[b]^v[c], -63)
+	}
+	for _, s := range blake2bSigma {
+		g(0, 4, 8, 12, m[s[0]], m[s[1]])
+		g(1, 5, 9, 13, m[s[2]], m[s[3]])
+		g(2, 6, 10, 14, m[s[4]], m[s[5]])
+		g(3, 7, 11, 15, m[s[6]], m[s[7]])
+		g(0, 5, 10, 15, m[s[8]], m[s[9]])
+		g(1, 6, 11, 12, m[s[10]], m[s[11]])
+		g(2, 7, 8, 13, m[s[12]], m[s[13]])
+		g(3, 4, 9, 14, m[s[14]], m[s[15]])
+	}
+	for i := range h.h {
+		h.h[i] ^= v[i] ^ v[i+8]
+	}
+}
+
+func (h *blake2b) Sum(b []byte) []byte {
+	// Finalize a copy, so that more data can still be written.
+	final := *h
+	final.increment(uint64(final.n))
+	for i := final.n; i < len(final.buffer); i++ {
+		final.buffer[i] = 0
+	}
+	final.compress(final.buffer[:], true)
+	var sum [64]byte
+	for i, word := range final.h {
+		binary.LittleEndian.PutUint64(sum[8*i:], word)
+	}
+	return append(b, sum[:]...)
+}
@@ -0,0 +1,45 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlake2b512(t *testing.T) {
+	tests := []struct {
+		text string
+		sum  string
+	}{
+		{"", "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{"abc", "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{"The quick brown fox jumps over the lazy dog", "a8add4bdddfd93e4877d2746e62817b116364a1fa7bc148d95090bc7333b3673f82401cf7aa2e4cb1ecd90296e3f14cb5413f8ed77be73045b13914cdcd6a918"},
+		{string(bytes.Repeat([]byte("a"), 128)), "fc6c71f688f43ea7d60817478808f3cac753e61571865c95adbc2d9122c943a76b92c2cb1047ef3fe7bf6e436ec1d0a99a9e5b216780bf7fed9d7ca91d3a8f3b"},
+	}
+	for _, test := range tests {
+		sum, err := Blake2b512(test.text)
+		require.NoError(t, err, "Error hashing text to using %s", Blake2bHash)
+		assert.Equal(t, test.sum, hex.EncodeToString(sum), test.text)
+	}
+}
+
+func TestBlake2b512Streaming(t *testing.T) {
+	data := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz0123456789"), 10)
+	h := NewBlake2b512()
+	for i := 0; i < len(data); i += 7 {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		h.Write(data[i:end])
+	}
+	const sum = "dfd0aa2c874d8cf4aa9f27f45e8e0ed0ebdd4c1433ca10cd0a1141977ab537e5210c1e5ff384c1464767caed7265af8128255d37094e8a1d2b0af83973560e88"
+	assert.Equal(t, sum, hex.EncodeToString(h.Sum(nil)))
+	assert.Equal(t, sum, hex.EncodeToString(h.Sum(nil)), "Sum changed the state")
+
+	h.Reset()
+	assert.Equal(t, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce", hex.EncodeToString(h.Sum(nil)))
+}
@@ -0,0 +1,186 @@
+package hash
+
+import (
+	"container/list"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RacyWindow is how recently a file may have been modified before its
+// hashing started for its checksum to still be cached. Like the racy
+// entries of the git index, a file modified within the timestamp
+// granularity of the file system could be modified again, after it was
+// read, without its modification time changing. The window is wide
+// enough for file systems with 2 second granularity such as FAT.
+const RacyWindow = 2 * time.Second
+
+// CacheKey identifies the content of a file by its metadata, so that
+// its checksum can be looked up without reading the file. Path is only
+// set on platforms without device and inode numbers.
+type CacheKey struct {
+	Device    uint64
+	Inode     uint64
+	Path      string
+	Size      int64
+	ModTime   int64
+	Algorithm Algorithm
+}
+
+// Cache stores the checksums of files, to skip rehashing files that
+// didn't change. Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key CacheKey) ([]byte, bool)
+	Put(key CacheKey, hash []byte)
+}
+
+// cacheKey returns the cache key of the file.
+func cacheKey(algorithm Algorithm, path string, info os.FileInfo) CacheKey {
+	key := CacheKey{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Algorithm: algorithm}
+	if device, inode, ok := fileID(info); ok {
+		key.Device, key.Inode = device, inode
+	} else {
+		key.Path, _ = filepath.Abs(path)
+	}
+	return key
+}
+
+// cachedHashFile returns the checksum of the file from the cache, or
+// hashes the file and caches the checksum. The checksum isn't cached if
+// the file changed while it was hashed or was modified within the
+// RacyWindow before hashing started.
+func cachedHashFile(cache Cache, algorithm Algorithm, path string) ([]byte, error) {
+	hash, err := newHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	key := cacheKey(algorithm, path, before)
+	if sum, ok := cache.Get(key); ok {
+		return sum, nil
+	}
+	start := time.Now()
+	sum, err := hashFile(hash, path)
+	if err != nil {
+		return nil, err
+	}
+	after, err := os.Stat(path)
+	if err != nil || cacheKey(algorithm, path, after) != key {
+		return sum, nil
+	}
+	if before.ModTime().Before(start.Add(-RacyWindow)) {
+		cache.Put(key, sum)
+	}
+	return sum, nil
+}
+
+// FileCache is a Cache kept in memory and persisted to a file. It holds
+// at most a given number of checksums, evicting the least recently used
+// ones first.
+type FileCache struct {
+	mu       sync.Mutex
+	path     string
+	capacity int
+	entries  map[CacheKey]*list.Element
+	order    *list.List
+}
+
+// cacheRecord is a checksum as stored in the cache file.
+type cacheRecord struct {
+	Key  CacheKey
+	Hash []byte
+}
+
+// OpenFileCache opens the cache persisted at path, or an empty one if
+// the file doesn't exist, holding at most capacity checksums.
+func OpenFileCache(path string, capacity int) (*FileCache, error) {
+	cache := &FileCache{
+		path:     path,
+		capacity: capacity,
+		entries:  make(map[CacheKey]*list.Element),
+		order:    list.New(),
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []cacheRecord
+	if err := gob.NewDecoder(file).Decode(&records); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		cache.Put(record.Key, record.Hash)
+	}
+	return cache, nil
+}
+
+// Get returns the checksum cached for the key, if any.
+func (c *FileCache) Get(key CacheKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToBack(element)
+	return element.Value.(cacheRecord).Hash, true
+}
+
+// Put caches the checksum for the key.
+func (c *FileCache) Put(key CacheKey, hash []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value = cacheRecord{Key: key, Hash: hash}
+		c.order.MoveToBack(element)
+		return
+	}
+	c.entries[key] = c.order.PushBack(cacheRecord{Key: key, Hash: hash})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(cacheRecord).Key)
+	}
+}
+
+// Len returns the number of cached checksums.
+func (c *FileCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Save persists the cache to its file. The file is replaced atomically,
+// so a crash never leaves a truncated cache behind.
+func (c *FileCache) Save() error {
+	c.mu.Lock()
+	records := make([]cacheRecord, 0, c.order.Len())
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		records = append(records, element.Value.(cacheRecord))
+	}
+	c.mu.Unlock()
+
+	file, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := gob.NewEncoder(file).Encode(records); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), c.path)
+}
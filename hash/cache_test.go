@@ -0,0 +1,87 @@
+package hash
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedHashFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	foo := filepath.Join(dir, "foo")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+
+	cache, err := OpenFileCache(filepath.Join(dir, "cache"), 10)
+	require.NoError(t, err, "Error opening cache")
+	maker := New().Algorithm(Sha256Hash).Encoding(Hex).Cache(cache).Build()
+
+	// The file was just written, so its checksum is racy and not cached.
+	hash, err := maker.HashFile(foo)
+	require.NoError(t, err, "Error hashing file using %s", Sha256Hash)
+	assert.Equal(t, sha256Foo, hash)
+	assert.Equal(t, 0, cache.Len())
+
+	hour := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(foo, hour, hour))
+	hash, err = maker.HashFile(foo)
+	require.NoError(t, err, "Error hashing file using %s", Sha256Hash)
+	assert.Equal(t, sha256Foo, hash)
+	assert.Equal(t, 1, cache.Len())
+
+	// A cached checksum is returned without reading the file again.
+	info, err := os.Stat(foo)
+	require.NoError(t, err, "Error reading file info")
+	cache.Put(cacheKey(Sha256Hash, foo, info), []byte{0xca, 0xfe})
+	hash, err = maker.HashFile(foo)
+	require.NoError(t, err, "Error hashing file using %s", Sha256Hash)
+	assert.Equal(t, "cafe", hash)
+
+	// Changing the file changes its key.
+	require.NoError(t, ioutil.WriteFile(foo, []byte("bar"), 0644))
+	require.NoError(t, os.Chtimes(foo, hour, hour.Add(time.Second)))
+	hash, err = maker.HashFile(foo)
+	require.NoError(t, err, "Error hashing file using %s", Sha256Hash)
+	assert.Equal(t, sha256Bar, hash)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestFileCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cache")
+	cache, err := OpenFileCache(path, 2)
+	require.NoError(t, err, "Error opening cache")
+
+	foo := CacheKey{Inode: 1, Size: 3, ModTime: 1, Algorithm: Md5Hash}
+	bar := CacheKey{Inode: 2, Size: 3, ModTime: 1, Algorithm: Md5Hash}
+	baz := CacheKey{Inode: 3, Size: 3, ModTime: 1, Algorithm: Md5Hash}
+	cache.Put(foo, []byte("foo"))
+	cache.Put(bar, []byte("bar"))
+	_, ok := cache.Get(foo)
+	assert.True(t, ok)
+	// bar is the least recently used checksum, so it is evicted.
+	cache.Put(baz, []byte("baz"))
+	_, ok = cache.Get(bar)
+	assert.False(t, ok)
+	assert.Equal(t, 2, cache.Len())
+
+	require.NoError(t, cache.Save())
+	cache, err = OpenFileCache(path, 2)
+	require.NoError(t, err, "Error opening cache")
+	hash, ok := cache.Get(foo)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("foo"), hash)
+	hash, ok = cache.Get(baz)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("baz"), hash)
+}
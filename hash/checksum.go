@@ -0,0 +1,315 @@
+package hash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+)
+
+var ErrUnsupportedChecksumFormat = errors.New("hashutils: unsupported checksum file format")
+
+// ChecksumFormat is the line format of a checksum file.
+type ChecksumFormat string
+
+const (
+	// GNUFormat is the format of sha256sum, md5sum and b2sum:
+	// "<checksum>  <path>", or "<checksum> *<path>" in binary mode.
+	GNUFormat ChecksumFormat = "gnu"
+	// BSDFormat is the tagged format of BSD tools and of the --tag
+	// option of coreutils: "SHA256 (<path>) = <checksum>".
+	BSDFormat = "bsd"
+)
+
+// bsdTags maps the algorithms to the tags of the BSD format. Other
+// algorithms are tagged with their upper case name.
+var bsdTags = map[Algorithm]string{
+	Md5Hash:    "MD5",
+	Sha1Hash:   "SHA1",
+	Sha224Hash: "SHA224",
+	Sha256Hash: "SHA256",
+	Sha384Hash: "SHA384",
+	Sha512Hash: "SHA512",
+	// The tag of b2sum --tag.
+	Blake2bHash: "BLAKE2b",
+}
+
+// gnuLengths maps the length of hexadecimal checksums to the algorithm
+// producing them, to guess the algorithm of lines in GNU format. The 128
+// digits of sha512sum and b2sum are ambiguous, so they are not guessed.
+var gnuLengths = map[int]Algorithm{
+	32: Md5Hash,
+	40: Sha1Hash,
+	56: Sha224Hash,
+	64: Sha256Hash,
+	96: Sha384Hash,
+}
+
+// gnuAmbiguousLengths maps the lengths of hexadecimal checksums shared by
+// several algorithms to those algorithms.
+var gnuAmbiguousLengths = map[int][]Algorithm{
+	128: {Sha512Hash, Blake2bHash},
+}
+
+// ChecksumEntry is a line of a checksum file.
+type ChecksumEntry struct {
+	// Algorithm used to compute the checksum. Lines in GNU format don't
+	// name it, so it is guessed from the checksum length and left empty
+	// if the length is unknown or shared by several algorithms, like the
+	// SHA-512 and BLAKE2b-512 ones. Lines in BSD format of algorithms
+	// this package doesn't support, such as SHA3-256, hold the lower
+	// case tag.
+	Algorithm Algorithm
+	// Checksum in lower case hexadecimal encoding.
+	Checksum string
+	Path     string
+	// Binary is the binary mode marker ('*') of the GNU format.
+	Binary bool
+	Format ChecksumFormat
+}
+
+// CandidateAlgorithms returns the algorithms the checksum of the entry
+// may have been computed with: its algorithm if known, or every
+// algorithm producing checksums of its length for the ambiguous lines
+// in GNU format, such as SHA-512 and BLAKE2b-512 for 128 digits.
+func CandidateAlgorithms(entry ChecksumEntry) []Algorithm {
+	if entry.Algorithm != "" {
+		return []Algorithm{entry.Algorithm}
+	}
+	if entry.Format == GNUFormat {
+		return gnuAmbiguousLengths[len(entry.Checksum)]
+	}
+	return nil
+}
+
+// ChecksumFile is the parsed content of a checksum file.
+type ChecksumFile struct {
+	Entries []ChecksumEntry
+	// Malformed holds the numbers, starting at 1, of the lines which are
+	// not properly formatted checksum lines.
+	Malformed []int
+}
+
+// HashChecksums computes the checksums of the files with the algorithm
+// and returns them as entries of a checksum file.
+func HashChecksums(algorithm Algorithm, binary bool, paths ...string) ([]ChecksumEntry, error) {
+	entries := make([]ChecksumEntry, 0, len(paths))
+	for _, path := range paths {
+		hash, err := newHash(algorithm)
+		if err != nil {
+			return entries, err
+		}
+		sum, err := hashFile(hash, path)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, ChecksumEntry{
+			Algorithm: algorithm,
+			Checksum:  hex.EncodeToString(sum),
+			Path:      path,
+			Binary:    binary,
+		})
+	}
+	return entries, nil
+}
+
+// FormatChecksum formats the entry as a line of a checksum file,
+// without the line terminator. Unless zero is set, paths containing
+// backslashes, carriage returns or newlines are escaped the way
+// coreutils does: the line starts with a backslash and those characters
+// are written as "\\", "\r" and "\n".
+func FormatChecksum(entry ChecksumEntry, format ChecksumFormat, zero bool) (string, error) {
+	path, prefix := entry.Path, ""
+	if !zero && strings.ContainsAny(path, "\\\r\n") {
+		path, prefix = escapePath(path), "\\"
+	}
+	switch format {
+	case GNUFormat:
+		mode := " "
+		if entry.Binary {
+			mode = "*"
+		}
+		return prefix + entry.Checksum + " " + mode + path, nil
+	case BSDFormat:
+		return prefix + bsdTag(entry.Algorithm) + " (" + path + ") = " + entry.Checksum, nil
+	}
+	return "", ErrUnsupportedChecksumFormat
+}
+
+// WriteChecksums writes the entries as a checksum file. Lines end with
+// a newline, or with a NUL byte if zero is set, like the --zero option
+// of coreutils.
+func WriteChecksums(w io.Writer, format ChecksumFormat, zero bool, entries ...ChecksumEntry) error {
+	terminator := "\n"
+	if zero {
+		terminator = "\x00"
+	}
+	for _, entry := range entries {
+		line, err := FormatChecksum(entry, format, zero)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, line+terminator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseChecksums reads a checksum file holding lines in GNU format, BSD
+// format or a mix of both. Lines are NUL terminated if zero is set.
+// Empty lines are skipped and other lines that can't be parsed are
+// recorded as malformed instead of failing the whole file, so callers
+// can decide how strict to be.
+func ParseChecksums(r io.Reader, zero bool) (*ChecksumFile, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if zero {
+		scanner.Split(scanNull)
+	}
+	file := &ChecksumFile{}
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		if !zero {
+			line = strings.TrimSuffix(line, "\r")
+		}
+		if line == "" {
+			continue
+		}
+		entry, ok := parseChecksum(line, zero)
+		if !ok {
+			file.Malformed = append(file.Malformed, number)
+			continue
+		}
+		file.Entries = append(file.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// parseChecksum parses a single line in either GNU or BSD format.
+func parseChecksum(line string, zero bool) (ChecksumEntry, bool) {
+	escaped := false
+	if !zero && strings.HasPrefix(line, "\\") {
+		line, escaped = line[1:], true
+	}
+	entry, ok := parseBSDChecksum(line)
+	if !ok {
+		entry, ok = parseGNUChecksum(line)
+	}
+	if !ok {
+		return entry, false
+	}
+	if escaped {
+		if entry.Path, ok = unescapePath(entry.Path); !ok {
+			return entry, false
+		}
+	}
+	return entry, entry.Path != ""
+}
+
+func parseGNUChecksum(line string) (ChecksumEntry, bool) {
+	space := strings.IndexByte(line, ' ')
+	if space < 0 || space+2 > len(line) {
+		return ChecksumEntry{}, false
+	}
+	checksum, mode, path := line[:space], line[space+1], line[space+2:]
+	if mode != ' ' && mode != '*' || !isHex(checksum) {
+		return ChecksumEntry{}, false
+	}
+	return ChecksumEntry{
+		Algorithm: gnuLengths[len(checksum)],
+		Checksum:  strings.ToLower(checksum),
+		Path:      path,
+		Binary:    mode == '*',
+		Format:    GNUFormat,
+	}, true
+}
+
+func parseBSDChecksum(line string) (ChecksumEntry, bool) {
+	open := strings.Index(line, " (")
+	closing := strings.LastIndex(line, ") = ")
+	if open <= 0 || closing < open+2 || strings.ContainsRune(line[:open], ' ') {
+		return ChecksumEntry{}, false
+	}
+	checksum := line[closing+4:]
+	if !isHex(checksum) {
+		return ChecksumEntry{}, false
+	}
+	return ChecksumEntry{
+		Algorithm: bsdAlgorithm(line[:open]),
+		Checksum:  strings.ToLower(checksum),
+		Path:      line[open+2 : closing],
+		Format:    BSDFormat,
+	}, true
+}
+
+func bsdTag(algorithm Algorithm) string {
+	if tag, ok := bsdTags[algorithm]; ok {
+		return tag
+	}
+	return strings.ToUpper(string(algorithm))
+}
+
+func bsdAlgorithm(tag string) Algorithm {
+	for algorithm, t := range bsdTags {
+		if t == tag {
+			return algorithm
+		}
+	}
+	return Algorithm(strings.ToLower(tag))
+}
+
+func isHex(text string) bool {
+	if len(text) == 0 || len(text)%2 != 0 {
+		return false
+	}
+	_, err := hex.DecodeString(text)
+	return err == nil
+}
+
+func escapePath(path string) string {
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(path)
+}
+
+func unescapePath(path string) (string, bool) {
+	var unescaped strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '\\' {
+			unescaped.WriteByte(path[i])
+			continue
+		}
+		if i++; i == len(path) {
+			return "", false
+		}
+		switch path[i] {
+		case '\\':
+			unescaped.WriteByte('\\')
+		case 'n':
+			unescaped.WriteByte('\n')
+		case 'r':
+			unescaped.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return unescaped.String(), true
+}
+
+// scanNull is a bufio.SplitFunc splitting NUL terminated lines.
+func scanNull(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
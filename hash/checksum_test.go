@@ -0,0 +1,98 @@
+package hash
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sha256Foo = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	sha256Bar = "fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"
+)
+
+func TestHashChecksums(t *testing.T) {
+	foo, err := ioutil.TempFile("", "foo.*")
+	require.NoError(t, err, "Error creating temporary file")
+	_, err = foo.WriteString("foo")
+	require.NoError(t, err, "Error writing to temporary file")
+	defer os.Remove(foo.Name())
+
+	entries, err := HashChecksums(Sha256Hash, true, foo.Name())
+	require.NoError(t, err, "Error hashing files using %s", Sha256Hash)
+	assert.Equal(t, []ChecksumEntry{{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: foo.Name(), Binary: true}}, entries)
+
+	_, err = HashChecksums(Sha256Hash, false, foo.Name()+".missing")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWriteChecksums(t *testing.T) {
+	entries := []ChecksumEntry{
+		{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: "foo"},
+		{Algorithm: Sha256Hash, Checksum: sha256Bar, Path: "bar", Binary: true},
+		{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: "a\\b"},
+		{Algorithm: Sha256Hash, Checksum: sha256Bar, Path: "nl\nx"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteChecksums(&buf, GNUFormat, false, entries...))
+	assert.Equal(t, sha256Foo+"  foo\n"+
+		sha256Bar+" *bar\n"+
+		"\\"+sha256Foo+"  a\\\\b\n"+
+		"\\"+sha256Bar+"  nl\\nx\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteChecksums(&buf, BSDFormat, false, entries...))
+	assert.Equal(t, "SHA256 (foo) = "+sha256Foo+"\n"+
+		"SHA256 (bar) = "+sha256Bar+"\n"+
+		"\\SHA256 (a\\\\b) = "+sha256Foo+"\n"+
+		"\\SHA256 (nl\\nx) = "+sha256Bar+"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteChecksums(&buf, GNUFormat, true, entries[2:]...))
+	assert.Equal(t, sha256Foo+"  a\\b\x00"+sha256Bar+"  nl\nx\x00", buf.String())
+
+	assert.Equal(t, ErrUnsupportedChecksumFormat, WriteChecksums(&buf, "json", false, entries...))
+}
+
+func TestParseChecksums(t *testing.T) {
+	text := sha256Foo + "  foo\n" +
+		sha256Bar + " *bar\n" +
+		"\\" + sha256Foo + "  a\\\\b\n" +
+		"\n" +
+		"SHA256 (nl\\nx) = " + sha256Bar + "\n" +
+		"\\SHA256 (nl\\nx) = " + sha256Bar + "\n" +
+		"BLAKE2b (baz) = " + sha256Foo + sha256Foo + "\n" +
+		sha256Foo + sha256Foo + "  b2\n" +
+		"SHA3-256 (sha3) = " + sha256Foo + "\n" +
+		"acbd18db4cc2f85cedef654fccc4a4d8  qux\r\n" +
+		"not a checksum line\n"
+
+	file, err := ParseChecksums(strings.NewReader(text), false)
+	require.NoError(t, err, "Error parsing checksum file")
+	assert.Equal(t, []ChecksumEntry{
+		{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: "foo", Format: GNUFormat},
+		{Algorithm: Sha256Hash, Checksum: sha256Bar, Path: "bar", Binary: true, Format: GNUFormat},
+		{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: "a\\b", Format: GNUFormat},
+		{Algorithm: Sha256Hash, Checksum: sha256Bar, Path: "nl\\nx", Format: BSDFormat},
+		{Algorithm: Sha256Hash, Checksum: sha256Bar, Path: "nl\nx", Format: BSDFormat},
+		{Algorithm: Blake2bHash, Checksum: sha256Foo + sha256Foo, Path: "baz", Format: BSDFormat},
+		{Checksum: sha256Foo + sha256Foo, Path: "b2", Format: GNUFormat},
+		{Algorithm: "sha3-256", Checksum: sha256Foo, Path: "sha3", Format: BSDFormat},
+		{Algorithm: Md5Hash, Checksum: "acbd18db4cc2f85cedef654fccc4a4d8", Path: "qux", Format: GNUFormat},
+	}, file.Entries)
+	assert.Equal(t, []int{11}, file.Malformed)
+
+	file, err = ParseChecksums(strings.NewReader(sha256Foo+"  nl\nx\x00"+sha256Bar+" *a\\b\x00"), true)
+	require.NoError(t, err, "Error parsing checksum file")
+	assert.Equal(t, []ChecksumEntry{
+		{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: "nl\nx", Format: GNUFormat},
+		{Algorithm: Sha256Hash, Checksum: sha256Bar, Path: "a\\b", Binary: true, Format: GNUFormat},
+	}, file.Entries)
+	assert.Empty(t, file.Malformed)
+}
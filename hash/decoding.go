@@ -0,0 +1,266 @@
+package hash
+
+import (
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidEncodedText = errors.New("hashutils: text is not valid for the encoding")
+
+var ErrUndetectableEncoding = errors.New("hashutils: unable to detect the encoding of the checksum")
+
+// detectionOrder lists the encodings tried by DetectEncoding. Encodings
+// sharing an alphabet are ambiguous for some inputs, in which case the
+// one listed first wins.
+var detectionOrder = []Encoding{
+	Hex,
+	HexUpper,
+	HexColon,
+	Base64,
+	Base64URL,
+	Base64Raw,
+	Base64RawURL,
+	Base32,
+	Base32Crockford,
+	Base58,
+	Base62,
+	Ascii85,
+	Z85,
+	Decimal,
+}
+
+// Decode decodes the text encoded with the given encoding back into
+// the checksum bytes. Decimal text decodes to the shortest big-endian
+// representation of the number; use DecodeChecksum to restore the
+// leading zero bytes of a checksum.
+func Decode(encoding Encoding, text string) ([]byte, error) {
+	var decoded []byte
+	var err error
+	switch encoding {
+	case Hex, HexUpper:
+		decoded, err = hex.DecodeString(text)
+	case HexColon:
+		decoded, err = hex.DecodeString(strings.Replace(text, ":", "", -1))
+	case Base64:
+		decoded, err = base64.StdEncoding.DecodeString(text)
+	case Base64URL:
+		decoded, err = base64.URLEncoding.DecodeString(text)
+	case Base64Raw:
+		decoded, err = base64.RawStdEncoding.DecodeString(text)
+	case Base64RawURL:
+		decoded, err = base64.RawURLEncoding.DecodeString(text)
+	case Base32:
+		decoded, err = base32.StdEncoding.DecodeString(text)
+	case Base32Crockford:
+		decoded, err = crockfordDecode(text)
+	case Base58:
+		decoded, err = radixDecode(text, alphabetBase58)
+	case Base62:
+		decoded, err = radixDecode(text, alphabetBase62)
+	case Ascii85:
+		decoded, err = ascii85Decode(text)
+	case Z85:
+		decoded, err = z85Decode(text)
+	case Decimal:
+		decoded, err = decimalDecode(text)
+	default:
+		return nil, ErrUnsupportedEncoding
+	}
+	if err != nil {
+		return nil, ErrInvalidEncodedText
+	}
+	return decoded, nil
+}
+
+// Convert decodes the text using one encoding and encodes the result
+// using another one, e.g. to turn a base64 checksum into hexadecimal.
+func Convert(text string, from Encoding, to Encoding) (string, error) {
+	decoded, err := Decode(from, text)
+	if err != nil {
+		return "", err
+	}
+	return Encode(to, decoded)
+}
+
+// Size returns the number of bytes in a checksum of the algorithm.
+func Size(algorithm Algorithm) (int, error) {
+	hash, err := newHash(algorithm)
+	if err != nil {
+		return 0, err
+	}
+	return hash.Size(), nil
+}
+
+// DetectEncoding guesses the encoding of a checksum computed with the
+// given algorithm. An encoding matches if the text only uses characters
+// of its alphabet and decodes to exactly the checksum size of the
+// algorithm. When several encodings match, hexadecimal is preferred over
+// base64, base64 over base32 and so on, in the order listed by the
+// Encoding constants.
+func DetectEncoding(algorithm Algorithm, text string) (Encoding, error) {
+	size, err := Size(algorithm)
+	if err != nil {
+		return "", err
+	}
+	for _, encoding := range detectionOrder {
+		if !inAlphabet(encoding, text) {
+			continue
+		}
+		decoded, err := Decode(encoding, text)
+		if err != nil {
+			continue
+		}
+		if len(decoded) == size || (encoding == Decimal && len(decoded) < size) {
+			return encoding, nil
+		}
+	}
+	return "", ErrUndetectableEncoding
+}
+
+// DecodeChecksum detects the encoding of a checksum computed with the
+// given algorithm and decodes it, returning the checksum bytes along
+// with the detected encoding.
+func DecodeChecksum(algorithm Algorithm, text string) ([]byte, Encoding, error) {
+	encoding, err := DetectEncoding(algorithm, text)
+	if err != nil {
+		return nil, "", err
+	}
+	decoded, err := Decode(encoding, text)
+	if err != nil {
+		return nil, "", err
+	}
+	size, _ := Size(algorithm)
+	if len(decoded) < size {
+		decoded = append(make([]byte, size-len(decoded)), decoded...)
+	}
+	return decoded, encoding, nil
+}
+
+// inAlphabet reports whether the text is non-empty and made up only of
+// characters the encoding produces. The decoders alone are too lenient
+// to tell the encodings apart, e.g. hexadecimal decoding accepts both
+// upper and lower case digits.
+func inAlphabet(encoding Encoding, text string) bool {
+	var alphabet string
+	switch encoding {
+	case Hex:
+		alphabet = "0123456789abcdef"
+	case HexUpper:
+		alphabet = "0123456789ABCDEF"
+	case HexColon:
+		alphabet = "0123456789ABCDEF:"
+	case Base64:
+		alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/="
+	case Base64URL:
+		alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_="
+	case Base64Raw:
+		alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	case Base64RawURL:
+		alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	case Base32:
+		alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567="
+	case Base32Crockford:
+		alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	case Base58:
+		alphabet = alphabetBase58
+	case Base62:
+		alphabet = alphabetBase62
+	case Ascii85:
+		alphabet = "!\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstu" + "z" // z abbreviates four zero bytes
+	case Z85:
+		alphabet = alphabetZ85
+	case Decimal:
+		alphabet = "0123456789"
+	default:
+		return false
+	}
+	if len(text) == 0 {
+		return false
+	}
+	for _, r := range text {
+		if !strings.ContainsRune(alphabet, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// crockfordDecode decodes Crockford base32 text. Decoding is case
+// insensitive, ignores hyphens and reads O as 0 and I and L as 1, as
+// the Crockford specification requires.
+func crockfordDecode(text string) ([]byte, error) {
+	text = strings.ToUpper(strings.Replace(text, "-", "", -1))
+	text = strings.NewReplacer("O", "0", "I", "1", "L", "1").Replace(text)
+	return crockfordEncoding.DecodeString(text)
+}
+
+// radixDecode is the inverse of radixEncode.
+func radixDecode(text string, alphabet string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(text) && text[zeros] == alphabet[0] {
+		zeros++
+	}
+	radix := big.NewInt(int64(len(alphabet)))
+	number := new(big.Int)
+	for _, r := range text[zeros:] {
+		digit := strings.IndexRune(alphabet, r)
+		if digit < 0 {
+			return nil, ErrInvalidEncodedText
+		}
+		number.Mul(number, radix)
+		number.Add(number, big.NewInt(int64(digit)))
+	}
+	return append(make([]byte, zeros), number.Bytes()...), nil
+}
+
+// ascii85Decode decodes Ascii85 text as produced by Encode, without the
+// <~ and ~> delimiters.
+func ascii85Decode(text string) ([]byte, error) {
+	decoded := make([]byte, 4*len(text))
+	n, _, err := ascii85.Decode(decoded, []byte(text), true)
+	if err != nil {
+		return nil, err
+	}
+	return decoded[:n], nil
+}
+
+// z85Decode is the inverse of z85Encode.
+func z85Decode(text string) ([]byte, error) {
+	if len(text)%5 != 0 {
+		return nil, ErrInvalidLength
+	}
+	decoded := make([]byte, 0, len(text)/5*4)
+	for i := 0; i < len(text); i += 5 {
+		var value uint64
+		for j := 0; j < 5; j++ {
+			digit := strings.IndexByte(alphabetZ85, text[i+j])
+			if digit < 0 {
+				return nil, ErrInvalidEncodedText
+			}
+			value = value*85 + uint64(digit)
+		}
+		if value > 0xffffffff {
+			return nil, ErrInvalidEncodedText
+		}
+		decoded = append(decoded, byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+	}
+	return decoded, nil
+}
+
+// decimalDecode decodes a non-negative decimal number into its shortest
+// big-endian representation.
+func decimalDecode(text string) ([]byte, error) {
+	if !inAlphabet(Decimal, text) {
+		return nil, ErrInvalidEncodedText
+	}
+	number, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return nil, ErrInvalidEncodedText
+	}
+	return number.Bytes(), nil
+}
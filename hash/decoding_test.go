@@ -0,0 +1,94 @@
+package hash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	md5, err := Md5("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Md5Hash)
+
+	for _, encoding := range detectionOrder {
+		encoded, err := Encode(encoding, md5)
+		require.NoError(t, err, "Error encoding checksum using %s", encoding)
+		decoded, err := Decode(encoding, encoded)
+		require.NoError(t, err, "Error decoding checksum using %s", encoding)
+		assert.Equal(t, md5, decoded, "Round trip failed using %s", encoding)
+	}
+
+	decoded, err := Decode(Base58, "11233QC4")
+	require.NoError(t, err, "Error decoding using %s", Base58)
+	assert.Equal(t, []byte{0x00, 0x00, 0x28, 0x7f, 0xb4, 0xcd}, decoded)
+
+	decoded, err = Decode(Base32Crockford, "njyhhptcrbw5svffcn7wsh54vo")
+	require.NoError(t, err, "Error decoding using %s", Base32Crockford)
+	assert.Equal(t, md5, decoded)
+
+	_, err = Decode(Hex, "xyz")
+	assert.Equal(t, ErrInvalidEncodedText, err)
+
+	_, err = Decode(Decimal, "-42")
+	assert.Equal(t, ErrInvalidEncodedText, err)
+
+	_, err = Decode("base1", "foo")
+	assert.Equal(t, ErrUnsupportedEncoding, err)
+}
+
+func TestConvert(t *testing.T) {
+	hex, err := Convert("LCa0a2j/xo/5m0U8HTBBNBNCLXBkg7+g+YpeiGJm564=", Base64, Hex)
+	require.NoError(t, err, "Error converting from %s to %s", Base64, Hex)
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", hex)
+
+	base64, err := Convert("2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", Hex, Base64RawURL)
+	require.NoError(t, err, "Error converting from %s to %s", Hex, Base64RawURL)
+	assert.Equal(t, "LCa0a2j_xo_5m0U8HTBBNBNCLXBkg7-g-YpeiGJm564", base64)
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := map[string]Encoding{
+		"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae": Hex,
+		"2C26B46B68FFC68FF99B453C1D30413413422D706483BFA0F98A5E886266E7AE": HexUpper,
+		"LCa0a2j/xo/5m0U8HTBBNBNCLXBkg7+g+YpeiGJm564=":                     Base64,
+		"LCa0a2j_xo_5m0U8HTBBNBNCLXBkg7-g-YpeiGJm564=":                     Base64URL,
+		"LCa0a2j/xo/5m0U8HTBBNBNCLXBkg7+g+YpeiGJm564":                      Base64Raw,
+		"LCa0a2j_xo_5m0U8HTBBNBNCLXBkg7-g-YpeiGJm564":                      Base64RawURL,
+	}
+	for text, expected := range tests {
+		encoding, err := DetectEncoding(Sha256Hash, text)
+		require.NoError(t, err, "Error detecting encoding of %s", text)
+		assert.Equal(t, expected, encoding, "Unexpected encoding of %s", text)
+	}
+
+	md5, err := Md5("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Md5Hash)
+	for _, encoding := range []Encoding{HexColon, Base32, Base32Crockford, Ascii85, Decimal} {
+		encoded, err := Encode(encoding, md5)
+		require.NoError(t, err, "Error encoding checksum using %s", encoding)
+		detected, err := DetectEncoding(Md5Hash, encoded)
+		require.NoError(t, err, "Error detecting encoding of %s", encoded)
+		assert.Equal(t, encoding, detected)
+	}
+
+	_, err = DetectEncoding(Sha256Hash, "acbd18db4cc2f85cedef654fccc4a4d8")
+	assert.Equal(t, ErrUndetectableEncoding, err)
+
+	_, err = DetectEncoding("md4", "acbd18db4cc2f85cedef654fccc4a4d8")
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
+
+func TestDecodeChecksum(t *testing.T) {
+	decoded, encoding, err := DecodeChecksum(Crc32Hash, "255")
+	require.NoError(t, err, "Error decoding checksum")
+	assert.Equal(t, Encoding(Decimal), encoding)
+	assert.Equal(t, []byte{0x00, 0x00, 0x00, 0xff}, decoded)
+
+	decoded, encoding, err = DecodeChecksum(Md5Hash, "rL0Y20zC+Fzt72VPzMSk2A==")
+	require.NoError(t, err, "Error decoding checksum")
+	assert.Equal(t, Encoding(Base64), encoding)
+	hex, err := Encode(Hex, decoded)
+	require.NoError(t, err, "Error encoding checksum using %s", Hex)
+	assert.Equal(t, "acbd18db4cc2f85cedef654fccc4a4d8", hex)
+}
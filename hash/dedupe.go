@@ -0,0 +1,211 @@
+package hash
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var ErrUnsupportedLinkMode = errors.New("hashutils: unsupported link mode")
+
+var ErrReflinkUnsupported = errors.New("hashutils: reflinks are not supported by the file system")
+
+// LinkMode is the way a duplicate file is replaced by its original.
+type LinkMode string
+
+const (
+	// HardLink replaces the duplicate with a hard link to the original,
+	// so both paths share the metadata of the original.
+	HardLink LinkMode = "hard"
+	// Reflink replaces the duplicate with a copy-on-write clone of the
+	// original (FICLONE, Linux only), which keeps the mode, owner and
+	// modification time of the duplicate.
+	Reflink = "reflink"
+)
+
+// DedupeStatus is the outcome of deduplicating a file.
+type DedupeStatus string
+
+const (
+	DedupeLinked        DedupeStatus = "linked"
+	DedupeWouldLink                  = "would link"
+	DedupeLinkedAlready              = "already linked"
+	DedupeDiffers                    = "differs"
+	DedupeChanged                    = "changed"
+	DedupeFailed                     = "failed"
+)
+
+// DedupeResult is the result of deduplicating a file of a duplicate set.
+type DedupeResult struct {
+	// Original is the file the duplicate was linked to, the first path
+	// of its set.
+	Original  string
+	Duplicate string
+	Size      int64
+	Status    DedupeStatus
+	// Err is the error of a failed file.
+	Err error
+}
+
+// Deduplicate replaces every file of the duplicate sets, except the
+// first one of each set, with a hard link or a reflink to that first
+// file. Sets are typically the result of FindDuplicates, but nothing is
+// taken on trust: a file is only replaced once it has been compared byte
+// for byte with the original, and neither of them has changed while it
+// was compared or linked. The link is created next to the duplicate and
+// renamed over it, so the duplicate is never lost, even if the process
+// is interrupted. With dryRun, the files are compared but not replaced.
+// A file that can't be replaced is reported as failed and doesn't stop
+// the other files from being processed.
+func Deduplicate(sets []DuplicateSet, mode LinkMode, dryRun bool) ([]DedupeResult, error) {
+	if mode != HardLink && mode != Reflink {
+		return nil, ErrUnsupportedLinkMode
+	}
+	var results []DedupeResult
+	for _, set := range sets {
+		if len(set.Paths) < 2 {
+			continue
+		}
+		original := set.Paths[0]
+		for _, duplicate := range set.Paths[1:] {
+			result := DedupeResult{Original: original, Duplicate: duplicate, Size: set.Size}
+			result.Status, result.Err = dedupeFile(original, duplicate, mode, dryRun)
+			if result.Err != nil {
+				result.Status = DedupeFailed
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func dedupeFile(original string, duplicate string, mode LinkMode, dryRun bool) (DedupeStatus, error) {
+	originalInfo, err := os.Lstat(original)
+	if err != nil {
+		return "", err
+	}
+	duplicateInfo, err := os.Lstat(duplicate)
+	if err != nil {
+		return "", err
+	}
+	if !originalInfo.Mode().IsRegular() || !duplicateInfo.Mode().IsRegular() {
+		return DedupeDiffers, nil
+	}
+	if os.SameFile(originalInfo, duplicateInfo) {
+		return DedupeLinkedAlready, nil
+	}
+	same, err := compareFiles(original, duplicate)
+	if err != nil || !same {
+		return DedupeDiffers, err
+	}
+	if dryRun {
+		return DedupeWouldLink, nil
+	}
+
+	temp, err := linkTemp(original, duplicate, duplicateInfo, mode)
+	if err != nil {
+		return "", err
+	}
+	if unchanged(original, originalInfo) && unchanged(duplicate, duplicateInfo) {
+		if err := os.Rename(temp, duplicate); err != nil {
+			os.Remove(temp)
+			return "", err
+		}
+		return DedupeLinked, nil
+	}
+	os.Remove(temp)
+	return DedupeChanged, nil
+}
+
+// linkTemp links the original to a new file in the directory of the
+// duplicate and returns its path.
+func linkTemp(original string, duplicate string, info os.FileInfo, mode LinkMode) (string, error) {
+	file, err := ioutil.TempFile(filepath.Dir(duplicate), "."+filepath.Base(duplicate)+".")
+	if err != nil {
+		return "", err
+	}
+	temp := file.Name()
+	if mode == HardLink {
+		file.Close()
+		if err := os.Remove(temp); err != nil {
+			return "", err
+		}
+		return temp, os.Link(original, temp)
+	}
+	err = cloneFile(file, original, info)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temp)
+		return "", err
+	}
+	return temp, nil
+}
+
+// cloneFile reflinks the original into the file and gives the file the
+// mode, owner and modification time of the duplicate it replaces.
+func cloneFile(file *os.File, original string, info os.FileInfo) error {
+	src, err := os.Open(original)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := reflink(file, src); err != nil {
+		return err
+	}
+	if err := file.Chmod(info.Mode().Perm()); err != nil {
+		return err
+	}
+	if uid, gid, ok := fileOwner(info); ok {
+		if err := file.Chown(uid, gid); err != nil {
+			return err
+		}
+	}
+	return os.Chtimes(file.Name(), info.ModTime(), info.ModTime())
+}
+
+// unchanged reports whether the file is still the one described by
+// info, with the same size and modification time.
+func unchanged(path string, info os.FileInfo) bool {
+	current, err := os.Lstat(path)
+	return err == nil && os.SameFile(current, info) &&
+		current.Size() == info.Size() && current.ModTime().Equal(info.ModTime())
+}
+
+// compareFiles compares the content of the files byte for byte.
+func compareFiles(path1 string, path2 string) (bool, error) {
+	file1, err := os.Open(path1)
+	if err != nil {
+		return false, err
+	}
+	defer file1.Close()
+	file2, err := os.Open(path2)
+	if err != nil {
+		return false, err
+	}
+	defer file2.Close()
+
+	buf1 := make([]byte, 64*1024)
+	buf2 := make([]byte, len(buf1))
+	for {
+		n1, err1 := io.ReadFull(file1, buf1)
+		n2, err2 := io.ReadFull(file2, buf2)
+		if !bytes.Equal(buf1[:n1], buf2[:n2]) {
+			return false, nil
+		}
+		if err1 != nil && err1 != io.EOF && err1 != io.ErrUnexpectedEOF {
+			return false, err1
+		}
+		if err2 != nil && err2 != io.EOF && err2 != io.ErrUnexpectedEOF {
+			return false, err2
+		}
+		if err1 != nil {
+			// Both files ended with the same bytes.
+			return true, nil
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package hash
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeduplicateHardLink(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "foo", "b": "foo", "c": "fox", "d": "foo"})
+	defer os.RemoveAll(dir)
+	a, b, c, d := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c"), filepath.Join(dir, "d")
+	require.NoError(t, os.Remove(d))
+	require.NoError(t, os.Link(a, d))
+
+	sets := []DuplicateSet{{Size: 3, Checksum: sha256Foo, Paths: []string{a, b, c, d}}}
+	results, err := Deduplicate(sets, HardLink, false)
+	require.NoError(t, err, "Error deduplicating files")
+	assert.Equal(t, []DedupeResult{
+		{Original: a, Duplicate: b, Size: 3, Status: DedupeLinked},
+		{Original: a, Duplicate: c, Size: 3, Status: DedupeDiffers},
+		{Original: a, Duplicate: d, Size: 3, Status: DedupeLinkedAlready},
+	}, results)
+
+	infoA, err := os.Stat(a)
+	require.NoError(t, err)
+	infoB, err := os.Stat(b)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(infoA, infoB))
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4, "Temporary files left behind")
+}
+
+func TestDeduplicateDryRun(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "foo", "b": "foo"})
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+
+	sets := []DuplicateSet{{Size: 3, Checksum: sha256Foo, Paths: []string{a, b}}}
+	results, err := Deduplicate(sets, HardLink, true)
+	require.NoError(t, err, "Error deduplicating files")
+	assert.Equal(t, []DedupeResult{{Original: a, Duplicate: b, Size: 3, Status: DedupeWouldLink}}, results)
+
+	infoA, err := os.Stat(a)
+	require.NoError(t, err)
+	infoB, err := os.Stat(b)
+	require.NoError(t, err)
+	assert.False(t, os.SameFile(infoA, infoB))
+}
+
+func TestDeduplicateMissing(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "foo"})
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+
+	results, err := Deduplicate([]DuplicateSet{{Size: 3, Paths: []string{a, b}}}, HardLink, false)
+	require.NoError(t, err, "Error deduplicating files")
+	require.Len(t, results, 1)
+	assert.Equal(t, DedupeStatus(DedupeFailed), results[0].Status)
+	assert.True(t, os.IsNotExist(results[0].Err))
+}
+
+func TestDeduplicateReflink(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "foo", "b": "foo"})
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(t, os.Chmod(b, 0600))
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(b, modTime, modTime))
+
+	results, err := Deduplicate([]DuplicateSet{{Size: 3, Paths: []string{a, b}}}, Reflink, false)
+	require.NoError(t, err, "Error deduplicating files")
+	require.Len(t, results, 1)
+	if results[0].Err == ErrReflinkUnsupported {
+		t.Skip("The file system of the temporary directory doesn't support reflinks")
+	}
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, DedupeStatus(DedupeLinked), results[0].Status)
+	info, err := os.Stat(b)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.True(t, modTime.Equal(info.ModTime()))
+	content, err := ioutil.ReadFile(b)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(content))
+}
+
+func TestDeduplicateUnsupportedMode(t *testing.T) {
+	_, err := Deduplicate(nil, LinkMode("symbolic"), false)
+	assert.Equal(t, ErrUnsupportedLinkMode, err)
+}
+
+func TestCompareFiles(t *testing.T) {
+	large := make([]byte, 200*1024)
+	dir := writeTree(t, map[string]string{"a": string(large), "b": string(large), "c": string(large[1:])})
+	defer os.RemoveAll(dir)
+
+	same, err := compareFiles(filepath.Join(dir, "a"), filepath.Join(dir, "b"))
+	require.NoError(t, err)
+	assert.True(t, same)
+	same, err = compareFiles(filepath.Join(dir, "a"), filepath.Join(dir, "c"))
+	require.NoError(t, err)
+	assert.False(t, same)
+}
@@ -0,0 +1,121 @@
+package hash
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// ChangeStatus is the kind of difference between two trees.
+type ChangeStatus string
+
+const (
+	ChangeAdded    ChangeStatus = "added"
+	ChangeRemoved               = "removed"
+	ChangeModified              = "modified"
+	ChangeRenamed               = "renamed"
+	// ChangeMode is a file whose content is unchanged but whose mode
+	// differs, as reported by DiffManifests.
+	ChangeMode = "mode"
+)
+
+// TreeChange is a difference between an old and a new tree. Paths are
+// slash separated and relative to the root of their tree.
+type TreeChange struct {
+	Status ChangeStatus `json:"status"`
+	// Path is the path of the file in the new tree, or in the old tree
+	// for a removed file.
+	Path string `json:"path"`
+	// OldPath is the path of a renamed file in the old tree.
+	OldPath string `json:"oldPath,omitempty"`
+	// OldChecksum and Checksum are the hexadecimal checksums of the file
+	// in the old and in the new tree, when it exists there.
+	OldChecksum string `json:"oldChecksum,omitempty"`
+	Checksum    string `json:"checksum,omitempty"`
+}
+
+// CompareTrees hashes the files of both directories selected by the
+// walk options with the algorithm and returns how the new tree differs
+// from the old one, sorted by path. A file that only exists in the new
+// tree but has the content of a file that only exists in the old one is
+// reported as renamed rather than as added and removed.
+func CompareTrees(algorithm Algorithm, walk WalkOptions, oldDir string, newDir string) ([]TreeChange, error) {
+	if _, err := newHash(algorithm); err != nil {
+		return nil, err
+	}
+	oldTree, err := hashTree(algorithm, walk, oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := hashTree(algorithm, walk, newDir)
+	if err != nil {
+		return nil, err
+	}
+	return diffTrees(oldTree, newTree), nil
+}
+
+// hashTree returns the hexadecimal checksums of the files under the
+// directory selected by the walk options, keyed by their slash
+// separated relative path.
+func hashTree(algorithm Algorithm, walk WalkOptions, dir string) (map[string]string, error) {
+	tree := make(map[string]string)
+	err := walkFiles([]string{dir}, walk, func(path string, info os.FileInfo) error {
+		hash, _ := newHash(algorithm)
+		sum, err := hashContent(hash, path, info)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		tree[filepath.ToSlash(relative)] = hex.EncodeToString(sum)
+		return nil
+	})
+	return tree, err
+}
+
+// diffTrees compares two trees given as checksums keyed by path. Files
+// removed from the old tree are paired with added files of the same
+// content, in path order, to detect renames.
+func diffTrees(oldTree map[string]string, newTree map[string]string) []TreeChange {
+	var changes []TreeChange
+	removed := make(map[string][]string)
+	for _, path := range sortedKeys(oldTree) {
+		if _, ok := newTree[path]; !ok {
+			removed[oldTree[path]] = append(removed[oldTree[path]], path)
+		}
+	}
+	for _, path := range sortedKeys(newTree) {
+		checksum := newTree[path]
+		oldChecksum, ok := oldTree[path]
+		switch {
+		case ok && oldChecksum != checksum:
+			changes = append(changes, TreeChange{Status: ChangeModified, Path: path, OldChecksum: oldChecksum, Checksum: checksum})
+		case ok:
+		case len(removed[checksum]) > 0:
+			oldPath := removed[checksum][0]
+			removed[checksum] = removed[checksum][1:]
+			changes = append(changes, TreeChange{Status: ChangeRenamed, Path: path, OldPath: oldPath, OldChecksum: checksum, Checksum: checksum})
+		default:
+			changes = append(changes, TreeChange{Status: ChangeAdded, Path: path, Checksum: checksum})
+		}
+	}
+	for checksum, paths := range removed {
+		for _, path := range paths {
+			changes = append(changes, TreeChange{Status: ChangeRemoved, Path: path, OldChecksum: checksum})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func sortedKeys(tree map[string]string) []string {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
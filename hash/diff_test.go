@@ -0,0 +1,42 @@
+package hash
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareTrees(t *testing.T) {
+	oldDir := writeTree(t, map[string]string{"same": "same", "changed": "foo", "old/name": "bar", "gone": "gone"})
+	defer os.RemoveAll(oldDir)
+	newDir := writeTree(t, map[string]string{"same": "same", "changed": "bar", "new/name": "bar", "added": "new"})
+	defer os.RemoveAll(newDir)
+
+	changes, err := CompareTrees(Sha256Hash, WalkOptions{}, oldDir, newDir)
+	require.NoError(t, err, "Error comparing trees")
+	assert.Equal(t, []TreeChange{
+		{Status: ChangeAdded, Path: "added", Checksum: mustEncode(t, Hex, mustSha256(t, "new"))},
+		{Status: ChangeModified, Path: "changed", OldChecksum: sha256Foo, Checksum: sha256Bar},
+		{Status: ChangeRemoved, Path: "gone", OldChecksum: mustEncode(t, Hex, mustSha256(t, "gone"))},
+		{Status: ChangeRenamed, Path: "new/name", OldPath: "old/name", OldChecksum: sha256Bar, Checksum: sha256Bar},
+	}, changes)
+
+	changes, err = CompareTrees(Sha256Hash, WalkOptions{}, oldDir, oldDir)
+	require.NoError(t, err, "Error comparing trees")
+	assert.Empty(t, changes)
+
+	_, err = CompareTrees(Algorithm("whirlpool"), WalkOptions{}, oldDir, newDir)
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
+
+func TestDiffTreesRenamedCopies(t *testing.T) {
+	oldTree := map[string]string{"a": "1", "b": "1"}
+	newTree := map[string]string{"c": "1", "d": "1", "e": "1"}
+	assert.Equal(t, []TreeChange{
+		{Status: ChangeRenamed, Path: "c", OldPath: "a", OldChecksum: "1", Checksum: "1"},
+		{Status: ChangeRenamed, Path: "d", OldPath: "b", OldChecksum: "1", Checksum: "1"},
+		{Status: ChangeAdded, Path: "e", Checksum: "1"},
+	}, diffTrees(oldTree, newTree))
+}
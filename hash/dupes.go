@@ -0,0 +1,177 @@
+package hash
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+)
+
+// partialBlockSize is the size of the first and last blocks of a file
+// hashed to rule out most files of the same size cheaply.
+const partialBlockSize = 4096
+
+// DuplicateOptions configures the search for duplicate files.
+type DuplicateOptions struct {
+	// Algorithm used to compare the content of the files.
+	Algorithm Algorithm
+	// MinSize is the size in bytes below which files are ignored.
+	MinSize int64
+	// Walk selects the files searched under the roots. Only regular
+	// files are compared, followed symbolic links included.
+	Walk WalkOptions
+	// OnError, when set, is called with the path and the error of every
+	// file or directory that can't be walked or read, which is left out
+	// while the search goes on. When nil, the first error ends the
+	// search.
+	OnError func(path string, err error)
+}
+
+// DuplicateSet is a set of files with identical content.
+type DuplicateSet struct {
+	Size int64 `json:"size"`
+	// Checksum of the content in lower case hexadecimal encoding.
+	Checksum string   `json:"checksum"`
+	Paths    []string `json:"paths"`
+}
+
+// FindDuplicates walks the roots and returns the sets of files with
+// identical content. Files are grouped by size first, then by the
+// checksum of their first and last blocks and finally by the checksum
+// of their whole content, so that most files are never read entirely.
+// Hard links to an already seen file are not reported as duplicates.
+// Sets are sorted by decreasing size, paths within a set by name.
+func FindDuplicates(options DuplicateOptions, roots ...string) ([]DuplicateSet, error) {
+	if _, err := newHash(options.Algorithm); err != nil {
+		return nil, err
+	}
+	bySize, err := filesBySize(options, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []DuplicateSet
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		groups := [][]string{paths}
+		if size > 2*partialBlockSize {
+			if groups, err = groupBy(options, paths, func(path string) (string, error) {
+				return partialChecksum(options.Algorithm, path, size)
+			}); err != nil {
+				return nil, err
+			}
+		}
+		for _, group := range groups {
+			checksums := make(map[string]string, len(group))
+			full, err := groupBy(options, group, func(path string) (string, error) {
+				hash, _ := newHash(options.Algorithm)
+				sum, err := hashFile(hash, path)
+				checksums[path] = hex.EncodeToString(sum)
+				return checksums[path], err
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, duplicates := range full {
+				sort.Strings(duplicates)
+				sets = append(sets, DuplicateSet{Size: size, Checksum: checksums[duplicates[0]], Paths: duplicates})
+			}
+		}
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Size != sets[j].Size {
+			return sets[i].Size > sets[j].Size
+		}
+		return sets[i].Paths[0] < sets[j].Paths[0]
+	})
+	return sets, nil
+}
+
+// filesBySize walks the roots and groups the regular files selected by
+// the walk options by size.
+func filesBySize(options DuplicateOptions, roots []string) (map[int64][]string, error) {
+	type id struct{ device, inode uint64 }
+	seen := make(map[id]bool)
+	bySize := make(map[int64][]string)
+	for _, root := range roots {
+		err := WalkTree(root, options.Walk, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return options.report(path, err)
+			}
+			if !info.Mode().IsRegular() || info.Size() < options.MinSize {
+				return nil
+			}
+			if device, inode, ok := fileID(info); ok {
+				if seen[id{device, inode}] {
+					return nil
+				}
+				seen[id{device, inode}] = true
+			}
+			bySize[info.Size()] = append(bySize[info.Size()], path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bySize, nil
+}
+
+// report passes the error of the path to OnError and returns nil, or
+// returns it if OnError is nil.
+func (options DuplicateOptions) report(path string, err error) error {
+	if options.OnError == nil {
+		return err
+	}
+	options.OnError(path, err)
+	return nil
+}
+
+// groupBy groups the paths by the key computed for each of them and
+// returns the groups holding more than one path. Paths whose key can't
+// be computed are reported and left out.
+func groupBy(options DuplicateOptions, paths []string, key func(path string) (string, error)) ([][]string, error) {
+	byKey := make(map[string][]string)
+	for _, path := range paths {
+		k, err := key(path)
+		if err != nil {
+			if err := options.report(path, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		byKey[k] = append(byKey[k], path)
+	}
+	var groups [][]string
+	for _, group := range byKey {
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// partialChecksum hashes the first and last blocks of the file.
+func partialChecksum(algorithm Algorithm, path string, size int64) (string, error) {
+	hash, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := io.CopyN(hash, file, partialBlockSize); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(size-partialBlockSize, io.SeekStart); err != nil {
+		return "", err
+	}
+	if _, err := io.CopyN(hash, file, partialBlockSize); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
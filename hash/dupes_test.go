@@ -0,0 +1,91 @@
+package hash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	large := strings.Repeat("0123456789", 1000)
+	changed := large[:5000] + "x" + large[5001:]
+	dir := writeTree(t, map[string]string{
+		"foo":            "foo",
+		"a/foo":          "foo",
+		"a/bar":          "bar",
+		"b/large":        large,
+		"b/large.copy":   large,
+		"b/large.middle": changed,
+		"c/foo.tmp":      "foo",
+		"node_modules/x": "foo",
+	})
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Link(filepath.Join(dir, "foo"), filepath.Join(dir, "foo.link")))
+
+	options := DuplicateOptions{Algorithm: Sha256Hash, Walk: WalkOptions{Exclude: []string{"*.tmp", "node_modules/"}}}
+	sets, err := FindDuplicates(options, dir)
+	require.NoError(t, err, "Error finding duplicates")
+	assert.Equal(t, []DuplicateSet{
+		{
+			Size:     10000,
+			Checksum: mustEncode(t, Hex, mustSha256(t, large)),
+			Paths:    []string{filepath.Join(dir, "b/large"), filepath.Join(dir, "b/large.copy")},
+		},
+		{
+			Size:     3,
+			Checksum: sha256Foo,
+			Paths:    []string{filepath.Join(dir, "a/foo"), filepath.Join(dir, "foo")},
+		},
+	}, sets)
+
+	options.MinSize = 4
+	sets, err = FindDuplicates(options, dir)
+	require.NoError(t, err, "Error finding duplicates")
+	assert.Len(t, sets, 1)
+
+	// A path that can't be walked is reported without hiding the others.
+	missing := filepath.Join(dir, "missing")
+	_, err = FindDuplicates(options, missing, dir)
+	assert.True(t, os.IsNotExist(err))
+	var failed []string
+	options.OnError = func(path string, err error) {
+		assert.True(t, os.IsNotExist(err))
+		failed = append(failed, path)
+	}
+	sets, err = FindDuplicates(options, missing, dir)
+	require.NoError(t, err, "Error finding duplicates")
+	assert.Len(t, sets, 1)
+	assert.Equal(t, []string{missing}, failed)
+
+	_, err = FindDuplicates(DuplicateOptions{Algorithm: "md4"}, dir)
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
+
+func TestFindDuplicatesUnreadable(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "foo", "b": "foo", "c": "foo"})
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Chmod(filepath.Join(dir, "c"), 0))
+	if file, err := os.Open(filepath.Join(dir, "c")); err == nil {
+		file.Close()
+		t.Skip("Skipping unreadable files, which the user can read")
+	}
+
+	var failed []string
+	options := DuplicateOptions{Algorithm: Sha256Hash, OnError: func(path string, err error) {
+		failed = append(failed, path)
+	}}
+	sets, err := FindDuplicates(options, dir)
+	require.NoError(t, err, "Error finding duplicates")
+	assert.Equal(t, []DuplicateSet{{Size: 3, Checksum: sha256Foo, Paths: []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}}}, sets)
+	assert.Equal(t, []string{filepath.Join(dir, "c")}, failed)
+}
+
+func mustSha256(t *testing.T, text string) []byte {
+	sum, err := Sha256(text)
+	require.NoError(t, err, "Error hashing text to using %s", Sha256Hash)
+	return sum
+}
@@ -0,0 +1,120 @@
+package hash
+
+import (
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var ErrUnsupportedEncoding = errors.New("hashutils: unsupported encoding")
+
+var ErrInvalidLength = errors.New("hashutils: input length is not valid for the encoding")
+
+const (
+	alphabetBase58 = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	alphabetBase62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	alphabetZ85    = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-:+=^!/*?&<>()[]{}@%$#"
+)
+
+// crockfordEncoding is the Crockford base32 alphabet, which leaves out
+// the easily confused letters I, L, O and U. Crockford base32 is not
+// padded.
+var crockfordEncoding = base32.NewEncoding("0123456789ABCDEFGHJKMNPQRSTVWXYZ").WithPadding(base32.NoPadding)
+
+// Encode encodes the checksum bytes using the given encoding.
+func Encode(encoding Encoding, hash []byte) (string, error) {
+	switch encoding {
+	case Hex:
+		return hex.EncodeToString(hash), nil
+	case HexUpper:
+		return strings.ToUpper(hex.EncodeToString(hash)), nil
+	case HexColon:
+		return hexColonEncode(hash), nil
+	case Base64:
+		return base64.StdEncoding.EncodeToString(hash), nil
+	case Base64URL:
+		return base64.URLEncoding.EncodeToString(hash), nil
+	case Base64Raw:
+		return base64.RawStdEncoding.EncodeToString(hash), nil
+	case Base64RawURL:
+		return base64.RawURLEncoding.EncodeToString(hash), nil
+	case Base32:
+		return base32.StdEncoding.EncodeToString(hash), nil
+	case Base32Crockford:
+		return crockfordEncoding.EncodeToString(hash), nil
+	case Base58:
+		return radixEncode(hash, alphabetBase58), nil
+	case Base62:
+		return radixEncode(hash, alphabetBase62), nil
+	case Ascii85:
+		buf := make([]byte, ascii85.MaxEncodedLen(len(hash)))
+		n := ascii85.Encode(buf, hash)
+		return string(buf[:n]), nil
+	case Z85:
+		return z85Encode(hash)
+	case Decimal:
+		return new(big.Int).SetBytes(hash).String(), nil
+	}
+	return "", ErrUnsupportedEncoding
+}
+
+// hexColonEncode encodes the bytes as upper case hexadecimal pairs
+// separated by colons, the way certificate and SSH key fingerprints
+// are usually printed.
+func hexColonEncode(hash []byte) string {
+	pairs := make([]string, len(hash))
+	for i, b := range hash {
+		pairs[i] = strings.ToUpper(hex.EncodeToString([]byte{b}))
+	}
+	return strings.Join(pairs, ":")
+}
+
+// radixEncode encodes the bytes as a big-endian number written with the
+// given alphabet. Every leading zero byte is written as the first
+// character of the alphabet, so that the length of the input survives
+// a round trip; this is the convention of the Bitcoin base58 encoding.
+func radixEncode(hash []byte, alphabet string) string {
+	zeros := 0
+	for zeros < len(hash) && hash[zeros] == 0 {
+		zeros++
+	}
+	radix := big.NewInt(int64(len(alphabet)))
+	number := new(big.Int).SetBytes(hash[zeros:])
+	mod := new(big.Int)
+	var digits []byte
+	for number.Sign() > 0 {
+		number.DivMod(number, radix, mod)
+		digits = append(digits, alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		digits = append(digits, alphabet[0])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+// z85Encode encodes the bytes using the ZeroMQ Base-85 encoding (Z85).
+// Z85 only encodes inputs whose length is a multiple of 4, which holds
+// for the checksums of every algorithm in this package.
+func z85Encode(hash []byte) (string, error) {
+	if len(hash)%4 != 0 {
+		return "", ErrInvalidLength
+	}
+	encoded := make([]byte, 0, len(hash)/4*5)
+	for i := 0; i < len(hash); i += 4 {
+		value := uint32(hash[i])<<24 | uint32(hash[i+1])<<16 | uint32(hash[i+2])<<8 | uint32(hash[i+3])
+		var chunk [5]byte
+		for j := 4; j >= 0; j-- {
+			chunk[j] = alphabetZ85[value%85]
+			value /= 85
+		}
+		encoded = append(encoded, chunk[:]...)
+	}
+	return string(encoded), nil
+}
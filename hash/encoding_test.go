@@ -0,0 +1,59 @@
+package hash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	md5, err := Md5("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Md5Hash)
+
+	tests := map[Encoding]string{
+		Hex:             "acbd18db4cc2f85cedef654fccc4a4d8",
+		HexUpper:        "ACBD18DB4CC2F85CEDEF654FCCC4A4D8",
+		HexColon:        "AC:BD:18:DB:4C:C2:F8:5C:ED:EF:65:4F:CC:C4:A4:D8",
+		Base64:          "rL0Y20zC+Fzt72VPzMSk2A==",
+		Base64URL:       "rL0Y20zC-Fzt72VPzMSk2A==",
+		Base64Raw:       "rL0Y20zC+Fzt72VPzMSk2A",
+		Base64RawURL:    "rL0Y20zC-Fzt72VPzMSk2A",
+		Base32:          "VS6RRW2MYL4FZ3PPMVH4ZRFE3A======",
+		Base32Crockford: "NJYHHPTCRBW5SVFFCN7WSH54V0",
+		Base58:          "NLApjPvzbiB1xJrqGMdfMy",
+		Base62:          "5Fx649sTEM9Fet0Ld46ZvE",
+		Ascii85:         "XM#J*9Z$R0mI+U^bf$=0",
+		Z85:             "TI2F9oV3Nf)EaQZ+/3sf",
+		Decimal:         "229609063533823256041787889330700985560",
+	}
+	for encoding, expected := range tests {
+		encoded, err := Encode(encoding, md5)
+		require.NoError(t, err, "Error encoding checksum using %s", encoding)
+		assert.Equal(t, expected, encoded, "Unexpected checksum using %s", encoding)
+	}
+
+	_, err = Encode("base1", md5)
+	assert.Equal(t, ErrUnsupportedEncoding, err)
+}
+
+func TestEncodeReferenceVectors(t *testing.T) {
+	encoded, err := Encode(Z85, []byte{0x86, 0x4F, 0xD2, 0x6F, 0xB5, 0x59, 0xF7, 0x5B})
+	require.NoError(t, err, "Error encoding using %s", Z85)
+	assert.Equal(t, "HelloWorld", encoded)
+
+	_, err = Encode(Z85, []byte{0x86, 0x4F, 0xD2})
+	assert.Equal(t, ErrInvalidLength, err)
+
+	encoded, err = Encode(Base58, []byte("Hello World!"))
+	require.NoError(t, err, "Error encoding using %s", Base58)
+	assert.Equal(t, "2NEpo7TZRRrLZSi2U", encoded)
+
+	encoded, err = Encode(Base58, []byte{0x00, 0x00, 0x28, 0x7f, 0xb4, 0xcd})
+	require.NoError(t, err, "Error encoding using %s", Base58)
+	assert.Equal(t, "11233QC4", encoded)
+
+	encoded, err = Encode(Decimal, []byte{0xcf, 0xc4, 0xae, 0x1d})
+	require.NoError(t, err, "Error encoding using %s", Decimal)
+	assert.Equal(t, "3485773341", encoded)
+}
@@ -5,7 +5,6 @@ import (
 	"hash"
 	"io"
 	"os"
-	"path/filepath"
 )
 
 var ErrNeitherFileNorDir = errors.New("hashutils: path doesn't look like a file or directory")
@@ -24,28 +23,113 @@ func hashFile(hash hash.Hash, path string) ([]byte, error) {
 		return nil, err
 	}
 	defer file.Close()
-	if _, err := io.Copy(hash, file); err != nil {
+	return hashReader(hash, file)
+}
+
+func hashReader(hash hash.Hash, reader io.Reader) ([]byte, error) {
+	if _, err := io.Copy(hash, reader); err != nil {
 		return nil, err
 	}
 	return hash.Sum(nil), nil
 }
 
 func hashPath(hash hash.Hash, path string) ([]byte, error) {
+	return hashPathWalk(hash, path, WalkOptions{})
+}
+
+// hashPathWalk is hashPath hashing directories with hashDirWalk, and
+// named pipes and character devices too if the options allow it.
+func hashPathWalk(hash hash.Hash, path string, options WalkOptions) ([]byte, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
-	switch mode := info.Mode(); {
-	case mode.IsDir():
-		return hashDir(hash, path)
-	case mode.IsRegular():
-		return hashFile(hash, path)
+	switch {
+	case info.IsDir():
+		return hashDirWalk(hash, path, options)
+	case hasContent(info, options):
+		return hashContent(hash, path, info)
 	}
 	return nil, ErrNeitherFileNorDir
 }
 
+// hashContent hashes the content of a file for which hasContent holds.
+func hashContent(hash hash.Hash, path string, info os.FileInfo) ([]byte, error) {
+	content, err := openContent(path, info)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	return hashReader(hash, content)
+}
+
+// hashFileMulti reads the content of a file for which hasContent holds
+// once and computes the checksums of all the algorithms, returning them
+// along with the size of the content.
+func hashFileMulti(path string, info os.FileInfo, algorithms []Algorithm) (int64, map[Algorithm][]byte, error) {
+	content, err := openContent(path, info)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer content.Close()
+	return hashReaderMulti(content, algorithms)
+}
+
+// hashReaderMulti is hashFileMulti reading from a reader.
+func hashReaderMulti(reader io.Reader, algorithms []Algorithm) (int64, map[Algorithm][]byte, error) {
+	hashes := make(map[Algorithm]hash.Hash, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		hash, err := newHash(algorithm)
+		if err != nil {
+			return 0, nil, err
+		}
+		hashes[algorithm] = hash
+		writers = append(writers, hash)
+	}
+	size, err := io.Copy(io.MultiWriter(writers...), reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	sums := make(map[Algorithm][]byte, len(hashes))
+	for algorithm, hash := range hashes {
+		sums[algorithm] = hash.Sum(nil)
+	}
+	return size, sums, nil
+}
+
+// walkFiles calls fn for every file found under the roots, selected by
+// the options and with a content to hash under them, see hasContent. A
+// root may also be a file itself.
+func walkFiles(roots []string, options WalkOptions, fn func(path string, info os.FileInfo) error) error {
+	for _, root := range roots {
+		err := WalkTree(root, options, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !hasContent(info, options) {
+				return nil
+			}
+			return fn(path, info)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func hashDir(hash hash.Hash, path string) ([]byte, error) {
-	err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+	return hashDirWalk(hash, path, WalkOptions{})
+}
+
+// hashDirWalk hashes the paths of the directory and of the files and
+// directories under it that are selected by the options.
+func hashDirWalk(hash hash.Hash, path string, options WalkOptions) ([]byte, error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	err := WalkTree(path, options, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
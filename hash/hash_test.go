@@ -49,6 +49,34 @@ func TestHashText(t *testing.T) {
 	hash, err = maker.HashText("foo")
 	require.NoError(t, err, "Error hashing text to using %s", Base64)
 	assert.Equal(t, "9/u6bgY2+JDlb7vzKD5STG+jIErimDgtYkdB0NxmODJuKCxBvl5CVNiCB3LFUYosWowMf37aGVlKfrU5RT4e1w==", hash)
+
+	maker = New().Algorithm(Sha256Hash).Encoding(Base64RawURL).Build()
+	hash, err = maker.HashText("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Base64RawURL)
+	assert.Equal(t, "LCa0a2j_xo_5m0U8HTBBNBNCLXBkg7-g-YpeiGJm564", hash)
+
+	maker = New().Algorithm(Sha1Hash).Encoding(Base58).Build()
+	hash, err = maker.HashText("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Base58)
+	assert.Equal(t, "AeF1qioaaY9WkLaDm4f7A6nUbTY", hash)
+
+	maker = New().Algorithm(Crc32Hash).Encoding(Decimal).Build()
+	hash, err = maker.HashText("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Crc32Hash)
+	assert.Equal(t, "3485773341", hash)
+
+	maker = New().Algorithm(Fnv32aHash).Encoding(HexUpper).Build()
+	hash, err = maker.HashText("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Fnv32aHash)
+	assert.Equal(t, "A9F37ED7", hash)
+
+	maker = New().Algorithm(Md5Hash).Encoding("base1").Build()
+	_, err = maker.HashText("foo")
+	assert.Equal(t, ErrUnsupportedEncoding, err)
+
+	maker = New().Algorithm("md4").Encoding(Hex).Build()
+	_, err = maker.HashText("foo")
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
 }
 
 func TestHashFile(t *testing.T) {
@@ -0,0 +1,208 @@
+package hash
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidHashDeep = errors.New("hashutils: invalid hashdeep file")
+
+const hashDeepHeader = "%%%% HASHDEEP-1.0"
+
+// HashDeepEntry is a file listed in a hashdeep file.
+type HashDeepEntry struct {
+	Size int64
+	// Checksums maps the algorithms to the checksums of the file in
+	// lower case hexadecimal encoding.
+	Checksums map[Algorithm]string
+	Path      string
+}
+
+// AuditStatus is the outcome of auditing a file against known files.
+type AuditStatus string
+
+const (
+	// AuditMatched is a file whose path and content are both known.
+	AuditMatched AuditStatus = "matched"
+	// AuditMoved is a file whose content is known under another path.
+	AuditMoved = "moved"
+	// AuditChanged is a file whose path is known with another content,
+	// which isn't known either.
+	AuditChanged = "changed"
+	// AuditNew is a file whose path and content are both unknown.
+	AuditNew = "new"
+	// AuditMissing is a known file neither found at its path nor moved.
+	AuditMissing = "missing"
+)
+
+// AuditResult is the audit of a single file.
+type AuditResult struct {
+	Status AuditStatus
+	Path   string
+	// KnownPath is the path of the known file a moved file matches.
+	KnownPath string
+}
+
+// HashDeep walks the roots and computes the size and the checksums of
+// every file selected by the walk options with all the algorithms,
+// reading each file once.
+func HashDeep(algorithms []Algorithm, walk WalkOptions, roots ...string) ([]HashDeepEntry, error) {
+	var entries []HashDeepEntry
+	err := walkFiles(roots, walk, func(path string, info os.FileInfo) error {
+		size, sums, err := hashFileMulti(path, info, algorithms)
+		if err != nil {
+			return err
+		}
+		checksums := make(map[Algorithm]string, len(sums))
+		for algorithm, sum := range sums {
+			checksums[algorithm] = hex.EncodeToString(sum)
+		}
+		entries = append(entries, HashDeepEntry{Size: size, Checksums: checksums, Path: path})
+		return nil
+	})
+	return entries, err
+}
+
+// WriteHashDeep writes the entries in the hashdeep file format, listing
+// the checksums of the algorithms in the given order.
+func WriteHashDeep(w io.Writer, algorithms []Algorithm, entries ...HashDeepEntry) error {
+	names := []string{"size"}
+	for _, algorithm := range algorithms {
+		names = append(names, string(algorithm))
+	}
+	names = append(names, "filename")
+	if _, err := fmt.Fprintf(w, "%s\n%%%%%%%% %s\n", hashDeepHeader, strings.Join(names, ",")); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		fields := []string{strconv.FormatInt(entry.Size, 10)}
+		for _, algorithm := range algorithms {
+			fields = append(fields, entry.Checksums[algorithm])
+		}
+		fields = append(fields, entry.Path)
+		if _, err := io.WriteString(w, strings.Join(fields, ",")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadHashDeep reads a hashdeep file and returns the algorithms it
+// lists, in order, along with its entries. Comment lines, starting
+// with "#", are skipped.
+func ReadHashDeep(r io.Reader) ([]Algorithm, []HashDeepEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != hashDeepHeader {
+		return nil, nil, ErrInvalidHashDeep
+	}
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), "%%%% ") {
+		return nil, nil, ErrInvalidHashDeep
+	}
+	names := strings.Split(strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "%%%% ")), ",")
+	if len(names) < 3 || names[0] != "size" || names[len(names)-1] != "filename" {
+		return nil, nil, ErrInvalidHashDeep
+	}
+	var algorithms []Algorithm
+	for _, name := range names[1 : len(names)-1] {
+		algorithms = append(algorithms, Algorithm(name))
+	}
+
+	var entries []HashDeepEntry
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// The file name is last and may contain commas itself.
+		fields := strings.SplitN(line, ",", len(names))
+		if len(fields) != len(names) {
+			return nil, nil, ErrInvalidHashDeep
+		}
+		size, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, nil, ErrInvalidHashDeep
+		}
+		checksums := make(map[Algorithm]string, len(algorithms))
+		for i, algorithm := range algorithms {
+			checksums[algorithm] = strings.ToLower(fields[i+1])
+		}
+		entries = append(entries, HashDeepEntry{Size: size, Checksums: checksums, Path: fields[len(fields)-1]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return algorithms, entries, nil
+}
+
+// AuditHashDeep audits the files against the known ones, the way the
+// audit mode of hashdeep does. Two files have the same content if their
+// sizes and the checksums of every algorithm both list are equal. Like
+// hashdeep, the content is matched before the path: a file whose content
+// is the one of a known file at another path is reported as moved, even
+// if its own path is known. Unlike hashdeep, a known file whose content
+// changed to an unknown one is reported as changed rather than as a new
+// file plus a missing one. Results are sorted by path, missing files
+// last.
+func AuditHashDeep(known []HashDeepEntry, actual []HashDeepEntry) []AuditResult {
+	byPath := make(map[string]HashDeepEntry, len(known))
+	bySize := make(map[int64][]HashDeepEntry)
+	for _, entry := range known {
+		byPath[entry.Path] = entry
+		bySize[entry.Size] = append(bySize[entry.Size], entry)
+	}
+	found := make(map[string]bool, len(known))
+	var results []AuditResult
+	for _, entry := range actual {
+		result := AuditResult{Status: AuditNew, Path: entry.Path}
+		knownEntry, known := byPath[entry.Path]
+		if known {
+			found[entry.Path] = true
+		}
+		if known && sameContent(knownEntry, entry) {
+			result.Status = AuditMatched
+		} else {
+			for _, other := range bySize[entry.Size] {
+				if sameContent(other, entry) {
+					found[other.Path] = true
+					result.Status, result.KnownPath = AuditMoved, other.Path
+					break
+				}
+			}
+			if result.Status == AuditNew && known {
+				result.Status = AuditChanged
+			}
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	for _, entry := range known {
+		if !found[entry.Path] {
+			results = append(results, AuditResult{Status: AuditMissing, Path: entry.Path})
+		}
+	}
+	return results
+}
+
+func sameContent(a HashDeepEntry, b HashDeepEntry) bool {
+	if a.Size != b.Size {
+		return false
+	}
+	common := 0
+	for algorithm, checksum := range a.Checksums {
+		if other, ok := b.Checksums[algorithm]; ok {
+			if other != checksum {
+				return false
+			}
+			common++
+		}
+	}
+	return common > 0
+}
@@ -0,0 +1,95 @@
+package hash
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashDeep(t *testing.T) {
+	dir := writeTree(t, map[string]string{"foo": "foo", "foo.tmp": "bar"})
+	defer os.RemoveAll(dir)
+	foo := filepath.Join(dir, "foo")
+
+	algorithms := []Algorithm{Md5Hash, Sha256Hash}
+	entries, err := HashDeep(algorithms, WalkOptions{Exclude: []string{"*.tmp"}}, dir)
+	require.NoError(t, err, "Error hashing directory")
+	assert.Equal(t, []HashDeepEntry{{
+		Size:      3,
+		Checksums: map[Algorithm]string{Md5Hash: "acbd18db4cc2f85cedef654fccc4a4d8", Sha256Hash: sha256Foo},
+		Path:      foo,
+	}}, entries)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHashDeep(&buf, algorithms, entries...))
+	assert.Equal(t, "%%%% HASHDEEP-1.0\n%%%% size,md5,sha256,filename\n3,acbd18db4cc2f85cedef654fccc4a4d8,"+sha256Foo+","+foo+"\n", buf.String())
+}
+
+func TestReadHashDeep(t *testing.T) {
+	text := "%%%% HASHDEEP-1.0\n" +
+		"%%%% size,md5,sha256,filename\n" +
+		"## Invoked from: /home/foo\n" +
+		"## $ hashdeep -r bar\n" +
+		"##\n" +
+		"3,acbd18db4cc2f85cedef654fccc4a4d8," + sha256Foo + ",bar/foo,1.txt\n"
+
+	algorithms, entries, err := ReadHashDeep(strings.NewReader(text))
+	require.NoError(t, err, "Error reading hashdeep file")
+	assert.Equal(t, []Algorithm{Md5Hash, Sha256Hash}, algorithms)
+	assert.Equal(t, []HashDeepEntry{{
+		Size:      3,
+		Checksums: map[Algorithm]string{Md5Hash: "acbd18db4cc2f85cedef654fccc4a4d8", Sha256Hash: sha256Foo},
+		Path:      "bar/foo,1.txt",
+	}}, entries)
+
+	_, _, err = ReadHashDeep(strings.NewReader("3,acbd18db4cc2f85cedef654fccc4a4d8,foo\n"))
+	assert.Equal(t, ErrInvalidHashDeep, err)
+
+	_, _, err = ReadHashDeep(strings.NewReader("%%%% HASHDEEP-1.0\n%%%% size,md5,filename\nfoo,bar\n"))
+	assert.Equal(t, ErrInvalidHashDeep, err)
+}
+
+func TestAuditHashDeep(t *testing.T) {
+	entry := func(path string, size int64, md5 string) HashDeepEntry {
+		return HashDeepEntry{Size: size, Checksums: map[Algorithm]string{Md5Hash: md5}, Path: path}
+	}
+	known := []HashDeepEntry{
+		entry("same", 1, "aa"),
+		entry("old", 2, "bb"),
+		entry("edited", 3, "cc"),
+		entry("gone", 4, "dd"),
+	}
+	actual := []HashDeepEntry{
+		entry("same", 1, "aa"),
+		entry("new", 2, "bb"),
+		entry("edited", 3, "ee"),
+		entry("added", 5, "ff"),
+	}
+	assert.Equal(t, []AuditResult{
+		{Status: AuditNew, Path: "added"},
+		{Status: AuditChanged, Path: "edited"},
+		{Status: AuditMoved, Path: "new", KnownPath: "old"},
+		{Status: AuditMatched, Path: "same"},
+		{Status: AuditMissing, Path: "gone"},
+	}, AuditHashDeep(known, actual))
+}
+
+func TestAuditHashDeepSwapped(t *testing.T) {
+	entry := func(path string, md5 string) HashDeepEntry {
+		return HashDeepEntry{Size: 1, Checksums: map[Algorithm]string{Md5Hash: md5}, Path: path}
+	}
+	// The content is matched before the path, so known paths holding the
+	// content of other known files are moved rather than changed.
+	known := []HashDeepEntry{entry("a", "aa"), entry("b", "bb"), entry("c", "cc")}
+	actual := []HashDeepEntry{entry("a", "bb"), entry("b", "aa"), entry("c", "bb")}
+	assert.Equal(t, []AuditResult{
+		{Status: AuditMoved, Path: "a", KnownPath: "b"},
+		{Status: AuditMoved, Path: "b", KnownPath: "a"},
+		{Status: AuditMoved, Path: "c", KnownPath: "b"},
+	}, AuditHashDeep(known, actual))
+}
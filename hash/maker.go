@@ -1,7 +1,14 @@
 package hash
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
 	"errors"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
 )
 
 var ErrUnsupportedAlgorithm = errors.New("hashutils: unsupported hashing algorithm")
@@ -11,45 +18,64 @@ var ErrUnsupportedAlgorithm = errors.New("hashutils: unsupported hashing algorit
 type Algorithm string
 
 const (
-	Md5Hash    Algorithm = "md5"
-	Fnv32Hash            = "fnv32"
-	Fnv32aHash           = "fnv32a"
-	Fnv64Hash            = "fnv64"
-	Fnv64aHash           = "fnv64a"
-	Sha1Hash             = "sha1"
-	Sha256Hash           = "sha256"
-	Sha224Hash           = "sha224"
-	Sha512Hash           = "sha512"
-	Sha384Hash           = "sha384"
-	Crc32Hash            = "crc32"
+	Md5Hash      Algorithm = "md5"
+	Fnv32Hash              = "fnv32"
+	Fnv32aHash             = "fnv32a"
+	Fnv64Hash              = "fnv64"
+	Fnv64aHash             = "fnv64a"
+	Sha1Hash               = "sha1"
+	Sha256Hash             = "sha256"
+	Sha224Hash             = "sha224"
+	Sha512Hash             = "sha512"
+	Sha384Hash             = "sha384"
+	Crc32Hash              = "crc32"
+	XxHash64Hash           = "xxhash64"
+	Blake2bHash            = "blake2b"
 )
 
-// An Encoding is a radix 64 encoding/decoding scheme, defined by a
-// 64-character alphabet.
+// An Encoding is a scheme used to turn the checksum bytes into text.
 type Encoding string
 
 const (
-	Hex    Encoding = "hex"
-	Base64          = "base64"
+	Hex             Encoding = "hex"
+	HexUpper                 = "hexupper"
+	HexColon                 = "hexcolon"
+	Base64                   = "base64"
+	Base64URL                = "base64url"
+	Base64Raw                = "base64raw"
+	Base64RawURL             = "base64rawurl"
+	Base32                   = "base32"
+	Base32Crockford          = "base32crockford"
+	Base58                   = "base58"
+	Base62                   = "base62"
+	Ascii85                  = "ascii85"
+	Z85                      = "z85"
+	Decimal                  = "decimal"
 )
 
 type ExtHash interface {
 	HashText(text string) (string, error)
 	HashFile(path string) (string, error)
 	HashFiles(paths ...string) (map[string]string, error)
-	// HashDir(path string) (string, error)
+	HashDir(path string) (string, error)
 	HashPath(path string) (string, error)
 }
 
 type ExtHashBuilder interface {
 	Algorithm(Algorithm) ExtHashBuilder
 	Encoding(Encoding) ExtHashBuilder
+	Multihash(bool) ExtHashBuilder
+	Cache(Cache) ExtHashBuilder
+	Walk(WalkOptions) ExtHashBuilder
 	Build() ExtHash
 }
 
 type hashBuilder struct {
 	algorithm Algorithm
 	encoding  Encoding
+	multihash bool
+	cache     Cache
+	walk      WalkOptions
 }
 
 func (h *hashBuilder) Algorithm(algorithm Algorithm) ExtHashBuilder {
@@ -62,10 +88,35 @@ func (h *hashBuilder) Encoding(encoding Encoding) ExtHashBuilder {
 	return h
 }
 
+// Multihash makes the built hash wrap the checksums into multihash
+// format and prefix the encoded text with the multibase code of the
+// encoding, so DecodeMultihash can recover the algorithm later on.
+func (h *hashBuilder) Multihash(multihash bool) ExtHashBuilder {
+	h.multihash = multihash
+	return h
+}
+
+// Cache makes the built hash look up the checksums of files in the
+// cache, and cache them, instead of reading unchanged files again.
+func (h *hashBuilder) Cache(cache Cache) ExtHashBuilder {
+	h.cache = cache
+	return h
+}
+
+// Walk makes the built hash only take the files and directories
+// selected by the options into account when hashing a directory.
+func (h *hashBuilder) Walk(options WalkOptions) ExtHashBuilder {
+	h.walk = options
+	return h
+}
+
 func (h *hashBuilder) Build() ExtHash {
 	return &hashMaker{
 		algorithm: h.algorithm,
 		encoding:  h.encoding,
+		multihash: h.multihash,
+		cache:     h.cache,
+		walk:      h.walk,
 	}
 }
 
@@ -73,197 +124,109 @@ func New() ExtHashBuilder {
 	return &hashBuilder{}
 }
 
+// newHash returns a new hash.Hash computing the checksum of the given
+// algorithm.
+func newHash(algorithm Algorithm) (hash.Hash, error) {
+	switch algorithm {
+	case Md5Hash:
+		return md5.New(), nil
+	case Sha1Hash:
+		return sha1.New(), nil
+	case Sha224Hash:
+		return sha256.New224(), nil
+	case Sha256Hash:
+		return sha256.New(), nil
+	case Sha384Hash:
+		return sha512.New384(), nil
+	case Sha512Hash:
+		return sha512.New(), nil
+	case Fnv32Hash:
+		return fnv.New32(), nil
+	case Fnv32aHash:
+		return fnv.New32a(), nil
+	case Fnv64Hash:
+		return fnv.New64(), nil
+	case Fnv64aHash:
+		return fnv.New64a(), nil
+	case Crc32Hash:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli)), nil
+	case XxHash64Hash:
+		return NewXxHash64(0), nil
+	case Blake2bHash:
+		return NewBlake2b512(), nil
+	}
+	return nil, ErrUnsupportedAlgorithm
+}
+
 type hashMaker struct {
 	algorithm Algorithm
 	encoding  Encoding
+	multihash bool
+	cache     Cache
+	walk      WalkOptions
 }
 
-func (m *hashMaker) HashText(text string) (string, error) {
-	if m.algorithm == Md5Hash && m.encoding == Hex {
-		return Md5Hex(text)
-	}
-	if m.algorithm == Sha1Hash && m.encoding == Hex {
-		return Sha1Hex(text)
+// hash computes the checksum with the given function and encodes the
+// result using the encoding of the maker.
+func (m *hashMaker) hash(fn func(hash.Hash, string) ([]byte, error), input string) (string, error) {
+	hash, err := newHash(m.algorithm)
+	if err != nil {
+		return "", err
 	}
-	if m.algorithm == Sha224Hash && m.encoding == Hex {
-		return Sha224Hex(text)
-	}
-	if m.algorithm == Sha256Hash && m.encoding == Hex {
-		return Sha256Hex(text)
-	}
-	if m.algorithm == Sha384Hash && m.encoding == Hex {
-		return Sha384Hex(text)
-	}
-	if m.algorithm == Sha512Hash && m.encoding == Hex {
-		return Sha512Hex(text)
+	sum, err := fn(hash, input)
+	if err != nil {
+		return "", err
 	}
+	return m.encode(sum)
+}
 
-	if m.algorithm == Md5Hash && m.encoding == Base64 {
-		return Md5Base64StdEnc(text)
-	}
-	if m.algorithm == Sha1Hash && m.encoding == Base64 {
-		return Sha1Base64StdEnc(text)
-	}
-	if m.algorithm == Sha224Hash && m.encoding == Base64 {
-		return Sha224Base64StdEnc(text)
-	}
-	if m.algorithm == Sha256Hash && m.encoding == Base64 {
-		return Sha256Base64StdEnc(text)
-	}
-	if m.algorithm == Sha384Hash && m.encoding == Base64 {
-		return Sha384Base64StdEnc(text)
-	}
-	if m.algorithm == Sha512Hash && m.encoding == Base64 {
-		return Sha512Base64StdEnc(text)
+// encode encodes the checksum using the encoding of the maker.
+func (m *hashMaker) encode(sum []byte) (string, error) {
+	if m.multihash {
+		multihash, err := Multihash(m.algorithm, sum)
+		if err != nil {
+			return "", err
+		}
+		return MultibaseEncode(m.encoding, multihash)
 	}
+	return Encode(m.encoding, sum)
+}
 
-	return "", ErrUnsupportedAlgorithm
+func (m *hashMaker) HashText(text string) (string, error) {
+	return m.hash(hashText, text)
 }
 
 func (m *hashMaker) HashFile(path string) (string, error) {
-	if m.algorithm == Md5Hash && m.encoding == Hex {
-		return Md5FileHex(path)
-	}
-	if m.algorithm == Sha1Hash && m.encoding == Hex {
-		return Sha1FileHex(path)
+	if m.cache == nil {
+		return m.hash(hashFile, path)
 	}
-	if m.algorithm == Sha224Hash && m.encoding == Hex {
-		return Sha224FileHex(path)
+	sum, err := cachedHashFile(m.cache, m.algorithm, path)
+	if err != nil {
+		return "", err
 	}
-	if m.algorithm == Sha256Hash && m.encoding == Hex {
-		return Sha256FileHex(path)
-	}
-	if m.algorithm == Sha384Hash && m.encoding == Hex {
-		return Sha384FileHex(path)
-	}
-	if m.algorithm == Sha512Hash && m.encoding == Hex {
-		return Sha512FileHex(path)
-	}
-
-	if m.algorithm == Md5Hash && m.encoding == Base64 {
-		return Md5FileBase64StdEnc(path)
-	}
-	if m.algorithm == Sha1Hash && m.encoding == Base64 {
-		return Sha1FileBase64StdEnc(path)
-	}
-	if m.algorithm == Sha224Hash && m.encoding == Base64 {
-		return Sha224FileBase64StdEnc(path)
-	}
-	if m.algorithm == Sha256Hash && m.encoding == Base64 {
-		return Sha256FileBase64StdEnc(path)
-	}
-	if m.algorithm == Sha384Hash && m.encoding == Base64 {
-		return Sha384FileBase64StdEnc(path)
-	}
-	if m.algorithm == Sha512Hash && m.encoding == Base64 {
-		return Sha512FileBase64StdEnc(path)
-	}
-
-	return "", ErrUnsupportedAlgorithm
+	return m.encode(sum)
 }
 
 func (m *hashMaker) HashFiles(paths ...string) (map[string]string, error) {
 	pathHashes := make(map[string]string, len(paths))
 	for _, path := range paths {
-		if m.algorithm == Md5Hash && m.encoding == Hex {
-			hash, err := Md5FileHex(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha1Hash && m.encoding == Hex {
-			hash, err := Sha1FileHex(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha224Hash && m.encoding == Hex {
-			hash, err := Sha224FileHex(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha256Hash && m.encoding == Hex {
-			hash, err := Sha256FileHex(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha384Hash && m.encoding == Hex {
-			hash, err := Sha384FileHex(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha512Hash && m.encoding == Hex {
-			hash, err := Sha512FileHex(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-
-		if m.algorithm == Md5Hash && m.encoding == Base64 {
-			hash, err := Md5FileBase64StdEnc(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha1Hash && m.encoding == Base64 {
-			hash, err := Sha1FileBase64StdEnc(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha224Hash && m.encoding == Base64 {
-			hash, err := Sha224FileBase64StdEnc(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha256Hash && m.encoding == Base64 {
-			hash, err := Sha256FileBase64StdEnc(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha384Hash && m.encoding == Base64 {
-			hash, err := Sha384FileBase64StdEnc(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
-		}
-		if m.algorithm == Sha512Hash && m.encoding == Base64 {
-			hash, err := Sha512FileBase64StdEnc(path)
-			if err != nil {
-				return pathHashes, err
-			}
-			pathHashes[path] = hash
+		hash, err := m.HashFile(path)
+		if err != nil {
+			return pathHashes, err
 		}
+		pathHashes[path] = hash
 	}
 	return pathHashes, nil
 }
 
 func (m *hashMaker) HashDir(path string) (string, error) {
-	if m.algorithm == Md5Hash && m.encoding == Hex {
-		return Md5DirHex(path)
-	}
-	if m.algorithm == Sha1Hash && m.encoding == Hex {
-		return Sha1DirHex(path)
-	}
-	return "", ErrUnsupportedAlgorithm
+	return m.hash(func(hash hash.Hash, path string) ([]byte, error) {
+		return hashDirWalk(hash, path, m.walk)
+	}, path)
 }
 
 func (m *hashMaker) HashPath(path string) (string, error) {
-	return "", ErrUnsupportedAlgorithm
+	return m.hash(func(hash hash.Hash, path string) ([]byte, error) {
+		return hashPathWalk(hash, path, m.walk)
+	}, path)
 }
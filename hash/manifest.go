@@ -0,0 +1,358 @@
+package hash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var ErrInvalidManifest = errors.New("hashutils: invalid manifest")
+
+var ErrUnsupportedManifestFormat = errors.New("hashutils: unsupported manifest format")
+
+var ErrNoCommonAlgorithm = errors.New("hashutils: manifests have no algorithm in common")
+
+// ManifestVersion is the version of the manifest format written by this
+// package. Version 1 manifests, written before the walk options were
+// recorded, are still read, as manifests of the current version walked
+// without options.
+const ManifestVersion = 2
+
+// manifestMagic starts every manifest in the binary format.
+const manifestMagic = "HUMF"
+
+// maxManifestString bounds the length of the strings of a binary
+// manifest, so that a corrupt length doesn't exhaust the memory.
+const maxManifestString = 1 << 16
+
+// ManifestFormat is the serialization of a manifest.
+type ManifestFormat string
+
+const (
+	ManifestJSON   ManifestFormat = "json"
+	ManifestBinary                = "binary"
+)
+
+// ManifestEntry describes a file, directory or symbolic link of a tree.
+type ManifestEntry struct {
+	// Path is slash separated and relative to the root of the tree.
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"modTime"`
+	// LinkTarget is the target of a symbolic link, which is not followed.
+	LinkTarget string `json:"linkTarget,omitempty"`
+	// Checksums maps the algorithms to the lower case hexadecimal
+	// checksums of a regular file.
+	Checksums map[Algorithm]string `json:"checksums,omitempty"`
+}
+
+// Manifest is a snapshot of a directory tree, with the entries sorted by
+// path.
+type Manifest struct {
+	Version    int         `json:"version"`
+	Algorithms []Algorithm `json:"algorithms"`
+	// Walk holds the options the tree was walked with.
+	Walk    WalkOptions     `json:"walk"`
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// CreateManifest walks the directory and records every file, directory
+// and symbolic link under it selected by the walk options, hashing the
+// files with a content under the options, see WalkOptions, with all the
+// algorithms while reading each of them once. The walk options are
+// recorded in the manifest, so the tree can be hashed the same way
+// again.
+func CreateManifest(root string, walk WalkOptions, algorithms ...Algorithm) (*Manifest, error) {
+	for _, algorithm := range algorithms {
+		if _, err := newHash(algorithm); err != nil {
+			return nil, err
+		}
+	}
+	manifest := &Manifest{Version: ManifestVersion, Algorithms: algorithms, Walk: walk, Entries: []ManifestEntry{}}
+	err := WalkTree(root, walk, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		entry := ManifestEntry{
+			Path:    filepath.ToSlash(relative),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime().UTC(),
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if entry.LinkTarget, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		if hasContent(info, walk) {
+			content, err := openContent(path, info)
+			if err != nil {
+				return err
+			}
+			size, sums, err := hashReaderMulti(content, algorithms)
+			content.Close()
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				// The size of a link or stream is the one reported by the
+				// file system, not the length of what was hashed.
+				size = info.Size()
+			}
+			entry.Size = size
+			entry.Checksums = make(map[Algorithm]string, len(sums))
+			for algorithm, sum := range sums {
+				entry.Checksums[algorithm] = hex.EncodeToString(sum)
+			}
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(manifest.Entries, func(i, j int) bool { return manifest.Entries[i].Path < manifest.Entries[j].Path })
+	return manifest, nil
+}
+
+// WriteManifest writes the manifest as indented JSON or in the compact
+// binary format, which stores the checksums as raw bytes.
+func WriteManifest(w io.Writer, manifest *Manifest, format ManifestFormat) error {
+	switch format {
+	case ManifestJSON:
+		bytes, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(bytes, '\n'))
+		return err
+	case ManifestBinary:
+		_, err := w.Write(encodeManifest(manifest))
+		return err
+	}
+	return ErrUnsupportedManifestFormat
+}
+
+// LoadManifest reads a manifest written by WriteManifest in either
+// format.
+func LoadManifest(r io.Reader) (*Manifest, error) {
+	reader := bufio.NewReader(r)
+	magic, err := reader.Peek(len(manifestMagic))
+	if err == nil && string(magic) == manifestMagic {
+		return decodeManifest(reader)
+	}
+	manifest := &Manifest{}
+	if err := json.NewDecoder(reader).Decode(manifest); err != nil {
+		return nil, ErrInvalidManifest
+	}
+	if manifest.Version != 1 && manifest.Version != ManifestVersion {
+		return nil, ErrInvalidManifest
+	}
+	manifest.Version = ManifestVersion
+	return manifest, nil
+}
+
+// encodeManifest encodes the manifest in the binary format: the magic
+// and the version, the algorithms, the walk options, then for each entry
+// its path, size, mode, modification time in nanoseconds, link target
+// and the checksums of every algorithm. Strings and checksums are
+// prefixed with their length, integers are varints. Version 1 lacks the
+// walk options.
+func encodeManifest(manifest *Manifest) []byte {
+	var buf bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	putUvarint := func(x uint64) { buf.Write(scratch[:binary.PutUvarint(scratch[:], x)]) }
+	putBytes := func(b []byte) {
+		putUvarint(uint64(len(b)))
+		buf.Write(b)
+	}
+
+	buf.WriteString(manifestMagic)
+	putUvarint(ManifestVersion)
+	putUvarint(uint64(len(manifest.Algorithms)))
+	for _, algorithm := range manifest.Algorithms {
+		putBytes([]byte(algorithm))
+	}
+	for _, patterns := range [][]string{manifest.Walk.Include, manifest.Walk.Exclude, manifest.Walk.IgnoreFiles} {
+		putUvarint(uint64(len(patterns)))
+		for _, pattern := range patterns {
+			putBytes([]byte(pattern))
+		}
+	}
+	putUvarint(uint64(manifest.Walk.MaxDepth))
+	putBytes([]byte(manifest.Walk.Links))
+	var flags uint64
+	if manifest.Walk.Streams {
+		flags |= 1
+	}
+	if manifest.Walk.OneFileSystem {
+		flags |= 2
+	}
+	putUvarint(flags)
+	putUvarint(uint64(len(manifest.Entries)))
+	for _, entry := range manifest.Entries {
+		putBytes([]byte(entry.Path))
+		putUvarint(uint64(entry.Size))
+		putUvarint(uint64(entry.Mode))
+		buf.Write(scratch[:binary.PutVarint(scratch[:], entry.ModTime.UnixNano())])
+		putBytes([]byte(entry.LinkTarget))
+		for _, algorithm := range manifest.Algorithms {
+			sum, _ := hex.DecodeString(entry.Checksums[algorithm])
+			putBytes(sum)
+		}
+	}
+	return buf.Bytes()
+}
+
+func decodeManifest(reader *bufio.Reader) (*Manifest, error) {
+	var err error
+	readUvarint := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		var x uint64
+		x, err = binary.ReadUvarint(reader)
+		return x
+	}
+	readBytes := func() []byte {
+		length := readUvarint()
+		if err != nil || length > maxManifestString {
+			err = ErrInvalidManifest
+			return nil
+		}
+		b := make([]byte, length)
+		if _, err = io.ReadFull(reader, b); err != nil {
+			return nil
+		}
+		return b
+	}
+
+	if _, err = reader.Discard(len(manifestMagic)); err != nil {
+		return nil, ErrInvalidManifest
+	}
+	version := readUvarint()
+	if err == nil && version != 1 && version != ManifestVersion {
+		return nil, ErrInvalidManifest
+	}
+	manifest := &Manifest{Version: ManifestVersion}
+	for n := readUvarint(); err == nil && n > 0; n-- {
+		manifest.Algorithms = append(manifest.Algorithms, Algorithm(readBytes()))
+	}
+	if version != 1 {
+		for _, patterns := range []*[]string{&manifest.Walk.Include, &manifest.Walk.Exclude, &manifest.Walk.IgnoreFiles} {
+			for n := readUvarint(); err == nil && n > 0; n-- {
+				*patterns = append(*patterns, string(readBytes()))
+			}
+		}
+		manifest.Walk.MaxDepth = int(readUvarint())
+		manifest.Walk.Links = LinkPolicy(readBytes())
+		flags := readUvarint()
+		manifest.Walk.Streams = flags&1 != 0
+		manifest.Walk.OneFileSystem = flags&2 != 0
+	}
+	manifest.Entries = []ManifestEntry{}
+	for n := readUvarint(); err == nil && n > 0; n-- {
+		entry := ManifestEntry{Path: string(readBytes())}
+		entry.Size = int64(readUvarint())
+		entry.Mode = os.FileMode(readUvarint())
+		if err == nil {
+			var modTime int64
+			modTime, err = binary.ReadVarint(reader)
+			entry.ModTime = time.Unix(0, modTime).UTC()
+		}
+		entry.LinkTarget = string(readBytes())
+		for _, algorithm := range manifest.Algorithms {
+			if sum := readBytes(); len(sum) > 0 {
+				if entry.Checksums == nil {
+					entry.Checksums = make(map[Algorithm]string, len(manifest.Algorithms))
+				}
+				entry.Checksums[algorithm] = hex.EncodeToString(sum)
+			}
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	if err != nil {
+		return nil, ErrInvalidManifest
+	}
+	return manifest, nil
+}
+
+// DiffManifests compares two manifests like CompareTrees compares two
+// directories, using the first algorithm of the new manifest that the
+// old one also has. Files are compared by checksum and symbolic links by
+// target; a file or link whose content is unchanged but whose
+// permissions or type bits differ is reported with ChangeMode.
+// Directories and modification times are not compared.
+func DiffManifests(oldManifest *Manifest, newManifest *Manifest) ([]TreeChange, error) {
+	algorithm, ok := commonAlgorithm(oldManifest.Algorithms, newManifest.Algorithms)
+	if !ok {
+		return nil, ErrNoCommonAlgorithm
+	}
+	oldTree, oldEntries := manifestTree(oldManifest, algorithm)
+	newTree, newEntries := manifestTree(newManifest, algorithm)
+	changes := diffTrees(oldTree, newTree)
+	for i := range changes {
+		// The keys of symbolic links are not checksums.
+		oldPath := changes[i].Path
+		if changes[i].OldPath != "" {
+			oldPath = changes[i].OldPath
+		}
+		if changes[i].OldChecksum != "" && oldEntries[oldPath].LinkTarget != "" {
+			changes[i].OldChecksum = ""
+		}
+		if changes[i].Checksum != "" && newEntries[changes[i].Path].LinkTarget != "" {
+			changes[i].Checksum = ""
+		}
+	}
+	for _, path := range sortedKeys(newTree) {
+		oldEntry, ok := oldEntries[path]
+		if ok && oldTree[path] == newTree[path] && oldEntry.Mode != newEntries[path].Mode {
+			changes = append(changes, TreeChange{Status: ChangeMode, Path: path})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func commonAlgorithm(oldAlgorithms []Algorithm, newAlgorithms []Algorithm) (Algorithm, bool) {
+	for _, algorithm := range newAlgorithms {
+		for _, other := range oldAlgorithms {
+			if algorithm == other {
+				return algorithm, true
+			}
+		}
+	}
+	return "", false
+}
+
+// manifestTree returns the keys compared by diffTrees for the regular
+// files and symbolic links of the manifest, along with these entries,
+// both keyed by path.
+func manifestTree(manifest *Manifest, algorithm Algorithm) (map[string]string, map[string]ManifestEntry) {
+	tree := make(map[string]string)
+	entries := make(map[string]ManifestEntry)
+	for _, entry := range manifest.Entries {
+		switch {
+		case entry.Mode&os.ModeSymlink != 0:
+			tree[entry.Path] = "-> " + entry.LinkTarget
+		case entry.Mode.IsRegular() || entry.Checksums[algorithm] != "":
+			tree[entry.Path] = entry.Checksums[algorithm]
+		default:
+			continue
+		}
+		entries[entry.Path] = entry
+	}
+	return tree, entries
+}
@@ -0,0 +1,142 @@
+package hash
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateManifest(t *testing.T) {
+	dir := writeTree(t, map[string]string{"foo": "foo", "sub/bar": "bar"})
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Symlink("sub/bar", filepath.Join(dir, "link")))
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "foo"), modTime, modTime))
+
+	manifest, err := CreateManifest(dir, WalkOptions{}, Sha256Hash, Md5Hash)
+	require.NoError(t, err, "Error creating manifest")
+	assert.Equal(t, ManifestVersion, manifest.Version)
+	assert.Equal(t, []Algorithm{Sha256Hash, Md5Hash}, manifest.Algorithms)
+	require.Len(t, manifest.Entries, 4)
+
+	foo := manifest.Entries[0]
+	assert.Equal(t, "foo", foo.Path)
+	assert.Equal(t, int64(3), foo.Size)
+	assert.Equal(t, os.FileMode(0644), foo.Mode)
+	assert.True(t, modTime.Equal(foo.ModTime))
+	assert.Equal(t, map[Algorithm]string{
+		Sha256Hash: sha256Foo,
+		Md5Hash:    "acbd18db4cc2f85cedef654fccc4a4d8",
+	}, foo.Checksums)
+
+	link := manifest.Entries[1]
+	assert.Equal(t, "link", link.Path)
+	assert.Equal(t, "sub/bar", link.LinkTarget)
+	assert.Nil(t, link.Checksums)
+
+	assert.Equal(t, "sub", manifest.Entries[2].Path)
+	assert.True(t, manifest.Entries[2].Mode.IsDir())
+	assert.Equal(t, "sub/bar", manifest.Entries[3].Path)
+
+	_, err = CreateManifest(dir, WalkOptions{}, Algorithm("whirlpool"))
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	dir := writeTree(t, map[string]string{"foo": "foo", "sub/bar": "bar"})
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Symlink("foo", filepath.Join(dir, "link")))
+	walk := WalkOptions{
+		Include:       []string{"*"},
+		Exclude:       []string{"*.log", "build/"},
+		IgnoreFiles:   []string{".gitignore"},
+		MaxDepth:      3,
+		Links:         LinkTarget,
+		Streams:       true,
+		OneFileSystem: true,
+	}
+	manifest, err := CreateManifest(dir, walk, Sha256Hash, Sha1Hash)
+	require.NoError(t, err, "Error creating manifest")
+	assert.Equal(t, walk, manifest.Walk)
+	assert.Equal(t, mustEncode(t, Hex, mustSha256(t, "foo")), manifest.Entries[1].Checksums[Sha256Hash], "Link target isn't hashed")
+
+	var jsonManifest, binaryManifest bytes.Buffer
+	require.NoError(t, WriteManifest(&jsonManifest, manifest, ManifestJSON))
+	require.NoError(t, WriteManifest(&binaryManifest, manifest, ManifestBinary))
+	assert.True(t, binaryManifest.Len() < jsonManifest.Len()/2, "Binary manifest isn't compact")
+
+	for _, encoded := range []*bytes.Buffer{&jsonManifest, &binaryManifest} {
+		loaded, err := LoadManifest(encoded)
+		require.NoError(t, err, "Error loading manifest")
+		assert.Equal(t, manifest.Algorithms, loaded.Algorithms)
+		require.Len(t, loaded.Entries, len(manifest.Entries))
+		for i, entry := range manifest.Entries {
+			assert.True(t, entry.ModTime.Equal(loaded.Entries[i].ModTime))
+			loaded.Entries[i].ModTime = entry.ModTime
+		}
+		assert.Equal(t, manifest, loaded)
+	}
+
+	assert.Equal(t, ErrUnsupportedManifestFormat, WriteManifest(&jsonManifest, manifest, ManifestFormat("xml")))
+}
+
+func TestLoadInvalidManifest(t *testing.T) {
+	for _, text := range []string{"", "{", `{"version": 3}`, "HUMF", "HUMF\x03", "HUMF\x01\x01\xff\xff\xff\xff\x0f"} {
+		_, err := LoadManifest(bytes.NewBufferString(text))
+		assert.Equal(t, ErrInvalidManifest, err, text)
+	}
+}
+
+func TestLoadManifestVersion1(t *testing.T) {
+	sum := mustSha256(t, "foo")
+	binaryManifest := append([]byte("HUMF\x01\x01\x06sha256\x01\x03foo\x03\xa4\x03\x00\x00\x20"), sum...)
+	jsonManifest := `{"version": 1, "algorithms": ["sha256"], "entries": [{"path": "foo", "size": 3, "mode": 420, "modTime": "1970-01-01T00:00:00Z", "checksums": {"sha256": "` + mustEncode(t, Hex, sum) + `"}}]}`
+	for _, encoded := range [][]byte{binaryManifest, []byte(jsonManifest)} {
+		loaded, err := LoadManifest(bytes.NewReader(encoded))
+		require.NoError(t, err, "Error loading manifest")
+		assert.Equal(t, ManifestVersion, loaded.Version)
+		assert.Equal(t, WalkOptions{}, loaded.Walk)
+		require.Len(t, loaded.Entries, 1)
+		assert.Equal(t, "foo", loaded.Entries[0].Path)
+		assert.Equal(t, int64(3), loaded.Entries[0].Size)
+		assert.Equal(t, os.FileMode(0644), loaded.Entries[0].Mode)
+		assert.Equal(t, mustEncode(t, Hex, sum), loaded.Entries[0].Checksums[Sha256Hash])
+	}
+}
+
+func TestDiffManifests(t *testing.T) {
+	file := func(path string, checksum string, mode os.FileMode) ManifestEntry {
+		return ManifestEntry{Path: path, Mode: mode, Checksums: map[Algorithm]string{Sha256Hash: checksum}}
+	}
+	oldManifest := &Manifest{Algorithms: []Algorithm{Md5Hash, Sha256Hash}, Entries: []ManifestEntry{
+		{Path: "bin", Mode: os.ModeDir | 0755},
+		file("bin/tool", "1", 0644),
+		file("changed", "2", 0644),
+		file("old", "3", 0644),
+		{Path: "link", Mode: os.ModeSymlink | 0777, LinkTarget: "old"},
+	}}
+	newManifest := &Manifest{Algorithms: []Algorithm{Sha256Hash}, Entries: []ManifestEntry{
+		{Path: "bin", Mode: os.ModeDir | 0700},
+		file("bin/tool", "1", 0755),
+		file("changed", "4", 0644),
+		file("new", "3", 0644),
+		{Path: "link", Mode: os.ModeSymlink | 0777, LinkTarget: "new"},
+	}}
+
+	changes, err := DiffManifests(oldManifest, newManifest)
+	require.NoError(t, err, "Error comparing manifests")
+	assert.Equal(t, []TreeChange{
+		{Status: ChangeMode, Path: "bin/tool"},
+		{Status: ChangeModified, Path: "changed", OldChecksum: "2", Checksum: "4"},
+		{Status: ChangeModified, Path: "link"},
+		{Status: ChangeRenamed, Path: "new", OldPath: "old", OldChecksum: "3", Checksum: "3"},
+	}, changes)
+
+	_, err = DiffManifests(&Manifest{Algorithms: []Algorithm{Md5Hash}}, newManifest)
+	assert.Equal(t, ErrNoCommonAlgorithm, err)
+}
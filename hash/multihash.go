@@ -0,0 +1,149 @@
+package hash
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrInvalidMultihash = errors.New("hashutils: invalid multihash")
+
+var ErrUnsupportedMultibase = errors.New("hashutils: unsupported multibase prefix")
+
+// multihashCodes maps the algorithms to their multicodec codes. FNV and
+// the Castagnoli CRC32 used by this package are not registered in the
+// multicodec table, so they use codes from the private use range
+// (0x300000 - 0x3fffff) and only round-trip through this package.
+var multihashCodes = map[Algorithm]uint64{
+	Md5Hash:    0xd5,
+	Sha1Hash:   0x11,
+	Sha224Hash: 0x1013,
+	Sha256Hash: 0x12,
+	Sha384Hash: 0x20,
+	Sha512Hash: 0x13,
+	Fnv32Hash:  0x300001,
+	Fnv32aHash: 0x300002,
+	Fnv64Hash:  0x300003,
+	Fnv64aHash: 0x300004,
+	Crc32Hash:  0x300005,
+	// xxh-64 is registered as a draft in the multicodec table.
+	XxHash64Hash: 0xb3e2,
+	// blake2b-512.
+	Blake2bHash: 0xb240,
+}
+
+// multibasePrefixes maps the encodings to their multibase prefixes.
+var multibasePrefixes = map[Encoding]byte{
+	Hex:          'f',
+	HexUpper:     'F',
+	Base32:       'C',
+	Base58:       'z',
+	Base64:       'M',
+	Base64Raw:    'm',
+	Base64URL:    'U',
+	Base64RawURL: 'u',
+	Decimal:      '9',
+}
+
+// Multihash wraps the checksum computed with the algorithm into the
+// self-describing multihash format: the unsigned varint code of the
+// algorithm, the unsigned varint length of the checksum and the
+// checksum itself.
+func Multihash(algorithm Algorithm, hash []byte) ([]byte, error) {
+	code, ok := multihashCodes[algorithm]
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	buf := make([]byte, 2*binary.MaxVarintLen64+len(hash))
+	n := binary.PutUvarint(buf, code)
+	n += binary.PutUvarint(buf[n:], uint64(len(hash)))
+	n += copy(buf[n:], hash)
+	return buf[:n], nil
+}
+
+// ParseMultihash returns the algorithm and the checksum wrapped in the
+// multihash. Truncated checksums, shorter than the full checksum size
+// of the algorithm, are allowed by the multihash specification.
+func ParseMultihash(multihash []byte) (Algorithm, []byte, error) {
+	code, n := binary.Uvarint(multihash)
+	if n <= 0 {
+		return "", nil, ErrInvalidMultihash
+	}
+	length, m := binary.Uvarint(multihash[n:])
+	if m <= 0 || uint64(len(multihash)-n-m) != length {
+		return "", nil, ErrInvalidMultihash
+	}
+	for algorithm, c := range multihashCodes {
+		if c != code {
+			continue
+		}
+		if size, _ := Size(algorithm); length > uint64(size) {
+			return "", nil, ErrInvalidMultihash
+		}
+		return algorithm, multihash[n+m:], nil
+	}
+	return "", nil, ErrUnsupportedAlgorithm
+}
+
+// MultibaseEncode encodes the data using the encoding and prefixes the
+// result with the multibase code of the encoding.
+func MultibaseEncode(encoding Encoding, data []byte) (string, error) {
+	prefix, ok := multibasePrefixes[encoding]
+	if !ok {
+		return "", ErrUnsupportedMultibase
+	}
+	encoded, err := Encode(encoding, data)
+	if err != nil {
+		return "", err
+	}
+	return string(prefix) + encoded, nil
+}
+
+// MultibaseDecode decodes multibase text. Besides the prefixes of the
+// encodings of this package it understands the unpadded base32 variants
+// ('b' and 'B') and the padded lower case one ('c') used by IPFS.
+func MultibaseDecode(text string) ([]byte, error) {
+	if len(text) == 0 {
+		return nil, ErrUnsupportedMultibase
+	}
+	prefix, body := text[0], text[1:]
+	switch prefix {
+	case 'b', 'B':
+		return Decode(Base32, upperBase32(body, true))
+	case 'c':
+		return Decode(Base32, upperBase32(body, false))
+	}
+	for encoding, p := range multibasePrefixes {
+		if p == prefix {
+			return Decode(encoding, body)
+		}
+	}
+	return nil, ErrUnsupportedMultibase
+}
+
+// DecodeMultihash decodes multibase text holding a multihash, as
+// produced by a hash builder configured with Multihash, and returns the
+// algorithm and the checksum.
+func DecodeMultihash(text string) (Algorithm, []byte, error) {
+	multihash, err := MultibaseDecode(text)
+	if err != nil {
+		return "", nil, err
+	}
+	return ParseMultihash(multihash)
+}
+
+// upperBase32 turns base32 text into the upper case, padded form
+// understood by the RFC 4648 decoder.
+func upperBase32(text string, pad bool) string {
+	upper := []byte(text)
+	for i, c := range upper {
+		if 'a' <= c && c <= 'z' {
+			upper[i] = c - 'a' + 'A'
+		}
+	}
+	if pad {
+		for len(upper)%8 != 0 {
+			upper = append(upper, '=')
+		}
+	}
+	return string(upper)
+}
@@ -0,0 +1,69 @@
+package hash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultihash(t *testing.T) {
+	sha256, err := Sha256("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Sha256Hash)
+
+	multihash, err := Multihash(Sha256Hash, sha256)
+	require.NoError(t, err, "Error creating multihash")
+	assert.Equal(t, append([]byte{0x12, 0x20}, sha256...), multihash)
+
+	algorithm, digest, err := ParseMultihash(multihash)
+	require.NoError(t, err, "Error parsing multihash")
+	assert.Equal(t, Algorithm(Sha256Hash), algorithm)
+	assert.Equal(t, sha256, digest)
+
+	for algorithm := range multihashCodes {
+		maker := New().Algorithm(algorithm).Encoding(Base58).Multihash(true).Build()
+		text, err := maker.HashText("foo")
+		require.NoError(t, err, "Error hashing text to using %s", algorithm)
+		decoded, digest, err := DecodeMultihash(text)
+		require.NoError(t, err, "Error decoding multihash of %s", algorithm)
+		assert.Equal(t, algorithm, decoded)
+		expected, err := New().Algorithm(algorithm).Encoding(Hex).Build().HashText("foo")
+		require.NoError(t, err, "Error hashing text to using %s", algorithm)
+		assert.Equal(t, expected, mustEncode(t, Hex, digest))
+	}
+
+	_, _, err = ParseMultihash([]byte{0x12, 0x21, 0x00})
+	assert.Equal(t, ErrInvalidMultihash, err)
+
+	_, _, err = ParseMultihash([]byte{0x14, 0x01, 0x00})
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
+
+func TestMultibase(t *testing.T) {
+	maker := New().Algorithm(Sha256Hash).Encoding(Hex).Multihash(true).Build()
+	text, err := maker.HashText("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Sha256Hash)
+	assert.Equal(t, "f12202c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", text)
+
+	maker = New().Algorithm(Sha256Hash).Encoding(Base64).Multihash(true).Build()
+	text, err = maker.HashText("foo")
+	require.NoError(t, err, "Error hashing text to using %s", Sha256Hash)
+	assert.Equal(t, "MEiAsJrRraP/Gj/mbRTwdMEE0E0ItcGSDv6D5il6IYmbnrg==", text)
+
+	algorithm, digest, err := DecodeMultihash("bciqcyjvunnup7rup7gnukpa5gbatie2cfvygja57ud4yuxuimjtoplq")
+	require.NoError(t, err, "Error decoding multihash")
+	assert.Equal(t, Algorithm(Sha256Hash), algorithm)
+	assert.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", mustEncode(t, Hex, digest))
+
+	_, err = MultibaseEncode(Z85, digest)
+	assert.Equal(t, ErrUnsupportedMultibase, err)
+
+	_, err = MultibaseDecode("#foo")
+	assert.Equal(t, ErrUnsupportedMultibase, err)
+}
+
+func mustEncode(t *testing.T, encoding Encoding, hash []byte) string {
+	encoded, err := Encode(encoding, hash)
+	require.NoError(t, err, "Error encoding checksum using %s", encoding)
+	return encoded
+}
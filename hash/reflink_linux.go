@@ -0,0 +1,21 @@
+package hash
+
+import (
+	"os"
+	"syscall"
+)
+
+// ioctlFICLONE is the FICLONE request of linux/fs.h.
+const ioctlFICLONE = 0x40049409
+
+// reflink makes the content of dst share the extents of src.
+func reflink(dst *os.File, src *os.File) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dst.Fd(), ioctlFICLONE, src.Fd())
+	switch errno {
+	case 0:
+		return nil
+	case syscall.EOPNOTSUPP, syscall.ENOTTY, syscall.EXDEV, syscall.EINVAL:
+		return ErrReflinkUnsupported
+	}
+	return errno
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package hash
+
+import "os"
+
+func reflink(dst *os.File, src *os.File) error {
+	return ErrReflinkUnsupported
+}
@@ -0,0 +1,89 @@
+package hash
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+)
+
+// sriPriorities ranks the algorithms allowed in Subresource Integrity
+// metadata from the weakest to the strongest.
+var sriPriorities = map[Algorithm]int{
+	Sha256Hash: 1,
+	Sha384Hash: 2,
+	Sha512Hash: 3,
+}
+
+// SRI returns the Subresource Integrity metadata, such as "sha384-...",
+// of the content read from the reader. Only SHA-256, SHA-384 and SHA-512
+// are allowed by the specification.
+func SRI(algorithm Algorithm, reader io.Reader) (string, error) {
+	if _, ok := sriPriorities[algorithm]; !ok {
+		return "", ErrUnsupportedAlgorithm
+	}
+	hash, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	sum, err := hashReader(hash, reader)
+	if err != nil {
+		return "", err
+	}
+	return string(algorithm) + "-" + base64.StdEncoding.EncodeToString(sum), nil
+}
+
+// SRIFile returns the Subresource Integrity metadata of a file.
+func SRIFile(algorithm Algorithm, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return SRI(algorithm, file)
+}
+
+// VerifySRI reports whether the content read from the reader matches
+// the integrity attribute, which holds whitespace separated metadata.
+// As the specification requires, metadata with unknown algorithms is
+// ignored, only the metadata of the strongest algorithm is checked and
+// the content matches if any of those checksums matches. An attribute
+// without any usable metadata matches every content.
+func VerifySRI(integrity string, reader io.Reader) (bool, error) {
+	strongest := Algorithm("")
+	var expected []string
+	for _, token := range strings.Fields(integrity) {
+		if i := strings.IndexByte(token, '?'); i >= 0 {
+			token = token[:i]
+		}
+		dash := strings.IndexByte(token, '-')
+		if dash < 0 {
+			continue
+		}
+		algorithm := Algorithm(token[:dash])
+		priority, ok := sriPriorities[algorithm]
+		if !ok {
+			continue
+		}
+		switch {
+		case priority > sriPriorities[strongest]:
+			strongest = algorithm
+			expected = []string{token}
+		case algorithm == strongest:
+			expected = append(expected, token)
+		}
+	}
+	if len(expected) == 0 {
+		return true, nil
+	}
+	actual, err := SRI(strongest, reader)
+	if err != nil {
+		return false, err
+	}
+	for _, metadata := range expected {
+		if metadata == actual {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,60 @@
+package hash
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const script = "alert('Hello, world.');"
+
+func TestSRI(t *testing.T) {
+	integrity, err := SRI(Sha384Hash, strings.NewReader(script))
+	require.NoError(t, err, "Error computing integrity using %s", Sha384Hash)
+	assert.Equal(t, "sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO", integrity)
+
+	integrity, err = SRI(Sha256Hash, strings.NewReader("foo"))
+	require.NoError(t, err, "Error computing integrity using %s", Sha256Hash)
+	assert.Equal(t, "sha256-LCa0a2j/xo/5m0U8HTBBNBNCLXBkg7+g+YpeiGJm564=", integrity)
+
+	_, err = SRI(Sha1Hash, strings.NewReader("foo"))
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
+
+func TestSRIFile(t *testing.T) {
+	foo, err := ioutil.TempFile("", "foo.*.js")
+	require.NoError(t, err, "Error creating temporary file")
+	_, err = foo.WriteString("foo")
+	require.NoError(t, err, "Error writing to temporary file")
+	defer os.Remove(foo.Name())
+
+	integrity, err := SRIFile(Sha512Hash, foo.Name())
+	require.NoError(t, err, "Error computing integrity using %s", Sha512Hash)
+	assert.Equal(t, "sha512-9/u6bgY2+JDlb7vzKD5STG+jIErimDgtYkdB0NxmODJuKCxBvl5CVNiCB3LFUYosWowMf37aGVlKfrU5RT4e1w==", integrity)
+}
+
+func TestVerifySRI(t *testing.T) {
+	tests := map[string]bool{
+		"sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO":                           true,
+		"sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO?ct=application/javascript": true,
+		"sha384-dOTZf16X8p34q2/kYyEFm0jh89uTjikhnzjeLeF0FHsEaYKb1A1cv+Lyv4Hk8vHd":                           false,
+		// The strongest algorithm wins, so the broken SHA-256 metadata is ignored.
+		"sha256-broken sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO": true,
+		// The matching SHA-256 metadata doesn't help if the SHA-384 one fails.
+		"sha256-LCa0a2j/xo/5m0U8HTBBNBNCLXBkg7+g+YpeiGJm564= sha384-broken": false,
+		// Any of the metadata using the strongest algorithm may match.
+		"sha384-broken sha384-H8BRh8j48O9oYatfu5AZzq6A9RINhZO5H16dQZngK7T62em8MUt1FLm52t+eX6xO": true,
+		// Without usable metadata every content matches.
+		"md5-broken": true,
+		"":           true,
+	}
+	for integrity, expected := range tests {
+		valid, err := VerifySRI(integrity, strings.NewReader(script))
+		require.NoError(t, err, "Error verifying integrity %s", integrity)
+		assert.Equal(t, expected, valid, "Unexpected verification of %s", integrity)
+	}
+}
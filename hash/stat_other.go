@@ -0,0 +1,18 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package hash
+
+import "os"
+
+// fileID returns the device and inode numbers of the file, which are
+// not available on this platform.
+func fileID(info os.FileInfo) (device uint64, inode uint64, ok bool) {
+	return 0, 0, false
+}
+
+// fileOwner returns the user and group owning the file, which are not
+// available on this platform.
+func fileOwner(info os.FileInfo) (uid int, gid int, ok bool) {
+	return 0, 0, false
+}
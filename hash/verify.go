@@ -0,0 +1,84 @@
+package hash
+
+import (
+	"encoding/hex"
+	"os"
+)
+
+// CheckStatus is the outcome of verifying an entry of a checksum file.
+type CheckStatus string
+
+const (
+	CheckOK         CheckStatus = "OK"
+	CheckFailed                 = "FAILED"
+	CheckMissing                = "MISSING"
+	CheckUnreadable             = "UNREADABLE"
+)
+
+// CheckResult is the result of verifying an entry of a checksum file.
+type CheckResult struct {
+	Entry  ChecksumEntry
+	Status CheckStatus
+	// Err is the error opening or reading the file of a missing or
+	// unreadable entry.
+	Err error
+}
+
+// VerifyChecksums re-hashes the files listed by the entries and compares
+// the result with the recorded checksums. Each file is hashed with the
+// algorithm of its entry, unless algorithm is not empty, in which case
+// that one is used for every entry. The files of GNU lines whose length
+// is shared by several algorithms, like the 128 digits of sha512sum and
+// b2sum, are hashed with each of them, and the entry of the result
+// holds the algorithm matching the checksum, if any.
+// ErrUnsupportedAlgorithm is returned, before any file is hashed, if an
+// entry can't be verified.
+func VerifyChecksums(algorithm Algorithm, entries ...ChecksumEntry) ([]CheckResult, error) {
+	for _, entry := range entries {
+		algorithms := entryAlgorithms(algorithm, entry)
+		if len(algorithms) == 0 {
+			return nil, ErrUnsupportedAlgorithm
+		}
+		for _, algorithm := range algorithms {
+			if _, err := newHash(algorithm); err != nil {
+				return nil, err
+			}
+		}
+	}
+	results := make([]CheckResult, 0, len(entries))
+	for _, entry := range entries {
+		result := CheckResult{Entry: entry, Status: CheckFailed}
+		sums, err := hashChecksumFile(entry.Path, entryAlgorithms(algorithm, entry))
+		switch {
+		case os.IsNotExist(err):
+			result.Status, result.Err = CheckMissing, err
+		case err != nil:
+			result.Status, result.Err = CheckUnreadable, err
+		}
+		for algorithm, sum := range sums {
+			if hex.EncodeToString(sum) == entry.Checksum {
+				result.Entry.Algorithm, result.Status = algorithm, CheckOK
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func entryAlgorithms(algorithm Algorithm, entry ChecksumEntry) []Algorithm {
+	if algorithm != "" {
+		return []Algorithm{algorithm}
+	}
+	return CandidateAlgorithms(entry)
+}
+
+// hashChecksumFile hashes the file with each of the algorithms.
+func hashChecksumFile(path string, algorithms []Algorithm) (map[Algorithm][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	_, sums, err := hashReaderMulti(file, algorithms)
+	return sums, err
+}
@@ -0,0 +1,67 @@
+package hash
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyChecksums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "check")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	foo := filepath.Join(dir, "foo")
+	bar := filepath.Join(dir, "bar")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+	require.NoError(t, ioutil.WriteFile(bar, []byte("bar"), 0644))
+
+	entries := []ChecksumEntry{
+		{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: foo},
+		{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: bar},
+		{Algorithm: Md5Hash, Checksum: "37b51d194a7513e45b56f6524f2d51f2", Path: bar},
+		{Algorithm: Sha256Hash, Checksum: sha256Foo, Path: filepath.Join(dir, "baz")},
+	}
+	results, err := VerifyChecksums("", entries...)
+	require.NoError(t, err, "Error verifying checksums")
+	require.Len(t, results, 4)
+	assert.Equal(t, CheckStatus(CheckOK), results[0].Status)
+	assert.Equal(t, CheckStatus(CheckFailed), results[1].Status)
+	assert.Equal(t, CheckStatus(CheckOK), results[2].Status)
+	assert.Equal(t, CheckStatus(CheckMissing), results[3].Status)
+	assert.True(t, os.IsNotExist(results[3].Err))
+
+	results, err = VerifyChecksums(Sha256Hash, ChecksumEntry{Checksum: sha256Bar, Path: bar})
+	require.NoError(t, err, "Error verifying checksums")
+	assert.Equal(t, CheckStatus(CheckOK), results[0].Status)
+
+	// The 128 digits of sha512sum and b2sum lines are verified with both
+	// algorithms, and resolved to the matching one.
+	sha512Bar := "d82c4eb5261cb9c8aa9855edd67d1bd10482f41529858d925094d173fa662aa91ff39bc5b188615273484021dfb16fd8284cf684ccf0fc795be3aa2fc1e6c181"
+	b2sumBar := "76aafe37ce69887569c3c1a51f14b639191fb2180cb0c87b566529496636712868556a9adf069d59769bf7e2393d215f195d8e7694f26fc7e20d92195973add8"
+	lines := sha512Bar + "  " + bar + "\n" + b2sumBar + "  " + bar + "\n" + b2sumBar + "  " + foo + "\n"
+	checksums, err := ParseChecksums(strings.NewReader(lines), false)
+	require.NoError(t, err, "Error parsing checksum file")
+	require.Len(t, checksums.Entries, 3)
+	results, err = VerifyChecksums("", checksums.Entries...)
+	require.NoError(t, err, "Error verifying checksums")
+	assert.Equal(t, CheckStatus(CheckOK), results[0].Status)
+	assert.Equal(t, Algorithm(Sha512Hash), results[0].Entry.Algorithm)
+	assert.Equal(t, CheckStatus(CheckOK), results[1].Status)
+	assert.Equal(t, Algorithm(Blake2bHash), results[1].Entry.Algorithm)
+	assert.Equal(t, CheckStatus(CheckFailed), results[2].Status)
+	results, err = VerifyChecksums(Blake2bHash, checksums.Entries...)
+	require.NoError(t, err, "Error verifying checksums")
+	assert.Equal(t, CheckStatus(CheckFailed), results[0].Status)
+	assert.Equal(t, CheckStatus(CheckOK), results[1].Status)
+
+	_, err = VerifyChecksums("", ChecksumEntry{Checksum: "0123456789abcdef", Path: bar, Format: GNUFormat})
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+	_, err = VerifyChecksums("", ChecksumEntry{Algorithm: "sha3-256", Checksum: sha256Bar, Path: bar})
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
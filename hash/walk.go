@@ -0,0 +1,386 @@
+package hash
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrUnsupportedLinkPolicy = errors.New("hashutils: unsupported symbolic link policy")
+
+// LinkPolicy is the way symbolic links found in a tree are hashed.
+// Symbolic links given as the path to hash are always followed.
+type LinkPolicy string
+
+const (
+	// LinkSkip leaves symbolic links out of the tree.
+	LinkSkip LinkPolicy = "skip"
+	// LinkTarget hashes the path a symbolic link points to, as returned
+	// by os.Readlink, as if it were the content of a file.
+	LinkTarget = "target"
+	// LinkFollow replaces symbolic links with what they point to and
+	// enters the directories they point to. A link pointing to one of
+	// the directories it is found in, which would make the walk loop
+	// forever, is not followed, nor is a dangling link.
+	LinkFollow = "follow"
+)
+
+// WalkOptions selects the files and directories visited when hashing a
+// tree. Patterns follow the .gitignore syntax: they are matched against
+// the slash separated path relative to the root, "*", "?" and character
+// classes match within a path element, "**" matches any number of
+// elements, a pattern without a slash matches at any depth, a leading
+// slash anchors the pattern to the root and a trailing slash makes it
+// only match directories.
+type WalkOptions struct {
+	// Include holds patterns of the files to visit. When empty, every
+	// file is visited. A file is also included if one of its parent
+	// directories matches. Directories are entered unless excluded.
+	Include []string `json:"include,omitempty"`
+	// Exclude holds patterns of the files and directories to skip, along
+	// with everything under them.
+	Exclude []string `json:"exclude,omitempty"`
+	// IgnoreFiles holds names of ignore files, like .gitignore or
+	// .dockerignore, read in every directory. Their patterns apply to
+	// the directory holding them, later and deeper patterns override
+	// earlier ones and "!" re-includes what a previous pattern ignored,
+	// like git does.
+	IgnoreFiles []string `json:"ignoreFiles,omitempty"`
+	// MaxDepth, when positive, is the number of levels below the root
+	// that are visited.
+	MaxDepth int `json:"maxDepth,omitempty"`
+	// Links is the policy for symbolic links. When empty, links are
+	// listed by directory hashes and manifests like any other entry, but
+	// neither followed nor hashed.
+	Links LinkPolicy `json:"links,omitempty"`
+	// Streams makes named pipes and character devices hashed by reading
+	// them until the end of file, instead of being left out.
+	Streams bool `json:"streams,omitempty"`
+	// OneFileSystem keeps the walk from entering directories on another
+	// file system than the root, on platforms reporting devices.
+	OneFileSystem bool `json:"oneFileSystem,omitempty"`
+}
+
+func (o WalkOptions) validate() error {
+	switch o.Links {
+	case "", LinkSkip, LinkTarget, LinkFollow:
+		return nil
+	}
+	return ErrUnsupportedLinkPolicy
+}
+
+// hasContent reports whether the file has a content hashed under the
+// options: regular files, symbolic links with the LinkTarget policy,
+// and named pipes and character devices with Streams.
+func hasContent(info os.FileInfo, options WalkOptions) bool {
+	mode := info.Mode()
+	switch {
+	case mode.IsRegular():
+		return true
+	case mode&os.ModeSymlink != 0:
+		return options.Links == LinkTarget
+	case mode&os.ModeNamedPipe != 0, mode&os.ModeCharDevice != 0:
+		return options.Streams
+	}
+	return false
+}
+
+// openContent opens the content of a file for which hasContent holds.
+func openContent(path string, info os.FileInfo) (io.ReadCloser, error) {
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(strings.NewReader(target)), nil
+	}
+	return os.Open(path)
+}
+
+// pattern is a compiled .gitignore style pattern.
+type pattern struct {
+	elements []string
+	negate   bool
+	dirOnly  bool
+}
+
+func compilePattern(text string) pattern {
+	var p pattern
+	if strings.HasPrefix(text, "!") {
+		p.negate = true
+		text = text[1:]
+	} else if strings.HasPrefix(text, `\!`) || strings.HasPrefix(text, `\#`) {
+		text = text[1:]
+	}
+	if strings.HasSuffix(text, "/") {
+		p.dirOnly = true
+		text = strings.TrimRight(text, "/")
+	}
+	if !strings.Contains(text, "/") {
+		text = "**/" + text
+	}
+	p.elements = strings.Split(strings.TrimPrefix(text, "/"), "/")
+	return p
+}
+
+func compilePatterns(texts []string) []pattern {
+	patterns := make([]pattern, 0, len(texts))
+	for _, text := range texts {
+		patterns = append(patterns, compilePattern(text))
+	}
+	return patterns
+}
+
+func (p pattern) match(relative string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return matchElements(p.elements, strings.Split(relative, "/"))
+}
+
+func matchElements(pattern []string, elements []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				// A trailing "**" matches what is inside, but not the
+				// directory itself.
+				return len(elements) > 0
+			}
+			for i := 0; i <= len(elements); i++ {
+				if matchElements(pattern[1:], elements[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elements) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elements[0]); !ok {
+			return false
+		}
+		pattern, elements = pattern[1:], elements[1:]
+	}
+	return len(elements) == 0
+}
+
+// ignoreRules are the patterns of the ignore files of a directory.
+type ignoreRules struct {
+	// dir is the slash separated path of the directory relative to the
+	// root, empty for the root itself.
+	dir      string
+	patterns []pattern
+}
+
+// readIgnoreFile reads the patterns of an ignore file, skipping blank
+// lines and comments. A missing file has no patterns.
+func readIgnoreFile(path string) ([]pattern, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var patterns []pattern
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, compilePattern(line))
+	}
+	return patterns, scanner.Err()
+}
+
+type walker struct {
+	options WalkOptions
+	include []pattern
+	exclude []pattern
+	fn      filepath.WalkFunc
+	// device is the device of the root, if known.
+	device    uint64
+	hasDevice bool
+}
+
+// WalkTree walks the tree like filepath.Walk, in lexical order, but
+// only visits the files and directories selected by the options, and
+// applies their symbolic link and file system policies. A followed link
+// is visited with the information of what it points to. The root is
+// always visited, and followed if it is a symbolic link. Every walk of this module goes through it, so that
+// the options select the same files for all of them.
+func WalkTree(root string, options WalkOptions, fn filepath.WalkFunc) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+	// The root is followed whatever the link policy, like the paths
+	// given to hash.
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		w := &walker{
+			options: options,
+			include: compilePatterns(options.Include),
+			exclude: compilePatterns(options.Exclude),
+			fn:      fn,
+		}
+		w.device, _, w.hasDevice = fileID(info)
+		err = w.walk(root, "", info, 0, nil, nil)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// walk visits the file or directory; ancestors are the directories
+// holding it, used to detect symbolic link loops.
+func (w *walker) walk(dir string, relative string, info os.FileInfo, depth int, rules []ignoreRules, ancestors []os.FileInfo) error {
+	if !info.IsDir() {
+		return w.fn(dir, info, nil)
+	}
+	if err := w.fn(dir, info, nil); err != nil {
+		return err
+	}
+	if w.options.MaxDepth > 0 && depth >= w.options.MaxDepth {
+		return nil
+	}
+	if device, _, ok := fileID(info); w.options.OneFileSystem && ok && w.hasDevice && device != w.device {
+		return nil
+	}
+	ancestors = append(ancestors[:len(ancestors):len(ancestors)], info)
+
+	names, err := readDirNames(dir)
+	if err == nil {
+		rules, err = w.readIgnoreFiles(dir, relative, rules)
+	}
+	if err != nil {
+		return w.fn(dir, info, err)
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		childRelative := name
+		if relative != "" {
+			childRelative = relative + "/" + name
+		}
+		childInfo, err := os.Lstat(path)
+		if err != nil {
+			if err := w.fn(path, childInfo, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if childInfo.Mode()&os.ModeSymlink != 0 {
+			if w.options.Links == LinkSkip {
+				continue
+			}
+			if w.options.Links == LinkFollow {
+				childInfo = w.follow(path, childInfo, ancestors)
+			}
+		}
+		if w.skip(childRelative, childInfo.IsDir(), rules) {
+			continue
+		}
+		if err := w.walk(path, childRelative, childInfo, depth+1, rules, ancestors); err != nil {
+			if !childInfo.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// follow returns the information of what the symbolic link points to,
+// or of the link itself if it is dangling or points to one of the
+// ancestors.
+func (w *walker) follow(path string, link os.FileInfo, ancestors []os.FileInfo) os.FileInfo {
+	target, err := os.Stat(path)
+	if err != nil {
+		return link
+	}
+	for _, ancestor := range ancestors {
+		if os.SameFile(target, ancestor) {
+			return link
+		}
+	}
+	return target
+}
+
+func (w *walker) readIgnoreFiles(dir string, relative string, rules []ignoreRules) ([]ignoreRules, error) {
+	for _, name := range w.options.IgnoreFiles {
+		patterns, err := readIgnoreFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if len(patterns) > 0 {
+			// Copy so that sibling directories don't share their rules.
+			rules = append(rules[:len(rules):len(rules)], ignoreRules{dir: relative, patterns: patterns})
+		}
+	}
+	return rules, nil
+}
+
+// skip reports whether the file or directory at the relative path is
+// left out of the walk.
+func (w *walker) skip(relative string, isDir bool, rules []ignoreRules) bool {
+	for _, p := range w.exclude {
+		if p.match(relative, isDir) {
+			return true
+		}
+	}
+	ignored := false
+	for _, r := range rules {
+		rulesRelative := relative
+		if r.dir != "" {
+			rulesRelative = strings.TrimPrefix(relative, r.dir+"/")
+		}
+		for _, p := range r.patterns {
+			if p.match(rulesRelative, isDir) {
+				ignored = !p.negate
+			}
+		}
+	}
+	if ignored {
+		return true
+	}
+	return !isDir && len(w.include) > 0 && !w.included(relative)
+}
+
+// included reports whether the file, or one of its parent directories,
+// matches an include pattern.
+func (w *walker) included(relative string) bool {
+	for _, p := range w.include {
+		if p.match(relative, false) {
+			return true
+		}
+		for dir := path.Dir(relative); dir != "."; dir = path.Dir(dir) {
+			if p.match(dir, true) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func readDirNames(dir string) ([]string, error) {
+	file, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	names, err := file.Readdirnames(-1)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,202 @@
+package hash
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		relative string
+		isDir    bool
+		match    bool
+	}{
+		{"*.log", "debug.log", false, true},
+		{"*.log", "logs/debug.log", false, true},
+		{"*.log", "debug.log.txt", false, false},
+		{"/debug.log", "debug.log", false, true},
+		{"/debug.log", "logs/debug.log", false, false},
+		{"build/", "build", true, true},
+		{"build/", "build", false, false},
+		{"build/", "src/build", true, true},
+		{"logs/*.log", "logs/debug.log", false, true},
+		{"logs/*.log", "logs/old/debug.log", false, false},
+		{"logs/**/*.log", "logs/debug.log", false, true},
+		{"logs/**/*.log", "logs/old/debug.log", false, true},
+		{"**/logs", "a/b/logs", true, true},
+		{"logs/**", "logs/a/b", false, true},
+		{"logs/**", "logs", true, false},
+		{"debug?.log", "debug1.log", false, true},
+		{"debug[0-9].log", "debuga.log", false, false},
+		{`\#notes`, "#notes", false, true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.match, compilePattern(test.pattern).match(test.relative, test.isDir), "%s %s", test.pattern, test.relative)
+	}
+	assert.True(t, compilePattern("!keep.log").negate)
+}
+
+// writeTree creates a temporary directory holding the files, keyed by
+// their slash-separated path, along with their parent directories.
+func writeTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "tree")
+	require.NoError(t, err, "Error creating temporary directory")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func walkedPaths(t *testing.T, root string, options WalkOptions) []string {
+	var paths []string
+	err := WalkTree(root, options, func(path string, info os.FileInfo, err error) error {
+		require.NoError(t, err)
+		relative, err := filepath.Rel(root, path)
+		require.NoError(t, err)
+		paths = append(paths, filepath.ToSlash(relative))
+		return nil
+	})
+	require.NoError(t, err, "Error walking tree")
+	return paths
+}
+
+func TestWalkTree(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".gitignore":          "# Logs\n*.log\n!keep.log\nbuild/\n",
+		"main.go":             "",
+		"debug.log":           "",
+		"keep.log":            "",
+		"build/out":           "",
+		"node_modules/x/a.go": "",
+		"src/.gitignore":      "secret\n",
+		"src/secret":          "",
+		"src/lib.go":          "",
+		"src/lib_test.go":     "",
+		"secret":              "",
+	})
+	defer os.RemoveAll(dir)
+
+	assert.Equal(t, []string{
+		".", ".gitignore", "build", "build/out", "debug.log", "keep.log", "main.go",
+		"node_modules", "node_modules/x", "node_modules/x/a.go",
+		"secret", "src", "src/.gitignore", "src/lib.go", "src/lib_test.go", "src/secret",
+	}, walkedPaths(t, dir, WalkOptions{}))
+
+	options := WalkOptions{Exclude: []string{"node_modules"}, IgnoreFiles: []string{".gitignore"}}
+	assert.Equal(t, []string{
+		".", ".gitignore", "keep.log", "main.go", "secret", "src", "src/.gitignore", "src/lib.go", "src/lib_test.go",
+	}, walkedPaths(t, dir, options))
+
+	options.Include = []string{"*.go"}
+	options.Exclude = []string{"node_modules", "*_test.go"}
+	assert.Equal(t, []string{".", "main.go", "src", "src/lib.go"}, walkedPaths(t, dir, options))
+
+	assert.Equal(t, []string{".", "build", "build/out", "node_modules", "node_modules/x", "node_modules/x/a.go", "src"},
+		walkedPaths(t, dir, WalkOptions{Include: []string{"build", "node_modules/**/*.go"}}))
+
+	assert.Equal(t, []string{".", "build", "node_modules", "src"},
+		walkedPaths(t, dir, WalkOptions{Include: []string{"none"}, MaxDepth: 1}))
+}
+
+func TestHashDirWalk(t *testing.T) {
+	dir := writeTree(t, map[string]string{"main.go": "package main"})
+	defer os.RemoveAll(dir)
+
+	maker := New().Algorithm(Sha256Hash).Encoding(Hex).Walk(WalkOptions{Exclude: []string{"node_modules"}}).Build()
+	before, err := maker.HashDir(dir)
+	require.NoError(t, err, "Error hashing dir")
+	unfiltered, err := Sha256DirHex(dir)
+	require.NoError(t, err, "Error hashing dir")
+	assert.Equal(t, unfiltered, before)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules", "x"), 0755))
+	after, err := maker.HashPath(dir)
+	require.NoError(t, err, "Error hashing dir")
+	assert.Equal(t, before, after)
+	unfiltered, err = Sha256DirHex(dir)
+	require.NoError(t, err, "Error hashing dir")
+	assert.NotEqual(t, before, unfiltered)
+}
+
+func TestWalkTreeLinks(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a/foo": "foo", "b/bar": "bar"})
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Symlink("../a", filepath.Join(dir, "b", "a")))
+	require.NoError(t, os.Symlink("..", filepath.Join(dir, "b", "loop")))
+	require.NoError(t, os.Symlink("missing", filepath.Join(dir, "b", "dangling")))
+
+	assert.Equal(t, []string{".", "a", "a/foo", "b", "b/a", "b/bar", "b/dangling", "b/loop"},
+		walkedPaths(t, dir, WalkOptions{}))
+	assert.Equal(t, []string{".", "a", "a/foo", "b", "b/bar"},
+		walkedPaths(t, dir, WalkOptions{Links: LinkSkip}))
+	assert.Equal(t, []string{".", "a", "a/foo", "b", "b/a", "b/a/foo", "b/bar", "b/dangling", "b/loop"},
+		walkedPaths(t, dir, WalkOptions{Links: LinkFollow}))
+
+	err := WalkTree(dir, WalkOptions{Links: LinkPolicy("hard")}, nil)
+	assert.Equal(t, ErrUnsupportedLinkPolicy, err)
+}
+
+func TestWalkTreeLinkedRoot(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a/foo": "foo"})
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "root")
+	require.NoError(t, os.Symlink("a", root))
+
+	// The root is followed whatever the link policy.
+	for _, links := range []LinkPolicy{"", LinkSkip, LinkTarget, LinkFollow} {
+		assert.Equal(t, []string{".", "foo"}, walkedPaths(t, root, WalkOptions{Links: links}), links)
+	}
+	before, err := Sha256DirHex(root)
+	require.NoError(t, err, "Error hashing dir")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "bar"), []byte("bar"), 0644))
+	after, err := Sha256DirHex(root)
+	require.NoError(t, err, "Error hashing dir")
+	assert.NotEqual(t, before, after, "The linked directory is hashed")
+}
+
+func TestCompareTreesLinkPolicies(t *testing.T) {
+	oldDir := writeTree(t, map[string]string{"foo": "foo", "bar": "bar"})
+	defer os.RemoveAll(oldDir)
+	newDir := writeTree(t, map[string]string{"foo": "foo", "bar": "bar"})
+	defer os.RemoveAll(newDir)
+	require.NoError(t, os.Symlink("foo", filepath.Join(oldDir, "link")))
+	require.NoError(t, os.Symlink("bar", filepath.Join(newDir, "link")))
+
+	changes, err := CompareTrees(Sha256Hash, WalkOptions{}, oldDir, newDir)
+	require.NoError(t, err, "Error comparing trees")
+	assert.Empty(t, changes)
+
+	changes, err = CompareTrees(Sha256Hash, WalkOptions{Links: LinkTarget}, oldDir, newDir)
+	require.NoError(t, err, "Error comparing trees")
+	assert.Equal(t, []TreeChange{{
+		Status:      ChangeModified,
+		Path:        "link",
+		OldChecksum: mustEncode(t, Hex, mustSha256(t, "foo")),
+		Checksum:    mustEncode(t, Hex, mustSha256(t, "bar")),
+	}}, changes)
+
+	changes, err = CompareTrees(Sha256Hash, WalkOptions{Links: LinkFollow}, oldDir, newDir)
+	require.NoError(t, err, "Error comparing trees")
+	assert.Equal(t, []TreeChange{{Status: ChangeModified, Path: "link", OldChecksum: sha256Foo, Checksum: sha256Bar}}, changes)
+}
+
+func TestHashPathStreams(t *testing.T) {
+	info, err := os.Stat(os.DevNull)
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		t.Skip("No null character device on this platform")
+	}
+	_, err = hashPathWalk(sha256.New(), os.DevNull, WalkOptions{})
+	assert.Equal(t, ErrNeitherFileNorDir, err)
+	sum, err := hashPathWalk(sha256.New(), os.DevNull, WalkOptions{Streams: true})
+	require.NoError(t, err, "Error hashing character device")
+	assert.Equal(t, mustSha256(t, ""), sum)
+}
@@ -0,0 +1,236 @@
+package hash
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrXattrUnsupported = errors.New("hashutils: extended attributes are not supported")
+
+var ErrNoXattrTag = errors.New("hashutils: file has no checksum attributes")
+
+var ErrFileChanged = errors.New("hashutils: file changed while it was hashed")
+
+// Extended attribute names of the shatag/cshatag convention: the
+// checksum is stored in user.shatag.<algorithm>, e.g. user.shatag.sha256,
+// and the modification time of the file when it was hashed in
+// user.shatag.ts, as seconds and nanoseconds since the epoch.
+const (
+	xattrPrefix = "user.shatag."
+	xattrTime   = xattrPrefix + "ts"
+)
+
+// XattrStatus is the outcome of verifying the checksum attributes of a
+// file.
+type XattrStatus string
+
+const (
+	// XattrOK is a file whose stored checksum is still valid.
+	XattrOK XattrStatus = "ok"
+	// XattrNew is a file without stored checksum.
+	XattrNew = "new"
+	// XattrOutdated is a file modified since its checksum was stored.
+	XattrOutdated = "outdated"
+	// XattrCorrupt is a file whose content changed although its
+	// modification time didn't, which hints at bit rot.
+	XattrCorrupt = "corrupt"
+)
+
+// XattrTag is the checksum of a file stored in its extended attributes.
+type XattrTag struct {
+	Algorithm Algorithm
+	// Checksum in lower case hexadecimal encoding.
+	Checksum string
+	// ModTime is the modification time of the file when it was hashed.
+	ModTime time.Time
+}
+
+// ReadXattrTag reads the checksum of the algorithm stored in the
+// extended attributes of the file.
+func ReadXattrTag(path string, algorithm Algorithm) (XattrTag, error) {
+	checksum, err := getxattr(path, xattrPrefix+string(algorithm))
+	if err != nil {
+		return XattrTag{}, err
+	}
+	ts, err := getxattr(path, xattrTime)
+	if err != nil {
+		return XattrTag{}, err
+	}
+	modTime, err := parseXattrTime(strings.TrimRight(string(ts), "\x00"))
+	if err != nil {
+		return XattrTag{}, err
+	}
+	return XattrTag{
+		Algorithm: algorithm,
+		Checksum:  strings.TrimRight(string(checksum), "\x00"),
+		ModTime:   modTime,
+	}, nil
+}
+
+// WriteXattrTag hashes the file with the algorithm and stores the
+// checksum and the modification time of the file in its extended
+// attributes, removing the checksums of other algorithms stored for
+// another modification time. ErrFileChanged is returned if the file
+// changed while it was hashed.
+func WriteXattrTag(path string, algorithm Algorithm) (XattrTag, error) {
+	tag, err := hashXattrTag(path, algorithm)
+	if err != nil {
+		return tag, err
+	}
+	return tag, storeXattrTag(path, tag)
+}
+
+// RemoveXattrTag removes the checksum of the algorithm from the
+// extended attributes of the file, along with the stored modification
+// time once no checksum of another algorithm remains.
+func RemoveXattrTag(path string, algorithm Algorithm) error {
+	if err := removexattr(path, xattrPrefix+string(algorithm)); err != nil && err != ErrNoXattrTag {
+		return err
+	}
+	names, err := listxattr(path)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, xattrPrefix) && name != xattrTime {
+			return nil
+		}
+	}
+	if err := removexattr(path, xattrTime); err != nil && err != ErrNoXattrTag {
+		return err
+	}
+	return nil
+}
+
+// VerifyXattrTag hashes the file and compares the result with the
+// checksum stored in its extended attributes, without modifying them.
+func VerifyXattrTag(path string, algorithm Algorithm) (XattrStatus, error) {
+	stored, err := ReadXattrTag(path, algorithm)
+	if err == ErrNoXattrTag {
+		return XattrNew, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	actual, err := hashXattrTag(path, algorithm)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case !actual.ModTime.Equal(stored.ModTime):
+		return XattrOutdated, nil
+	case actual.Checksum != stored.Checksum:
+		return XattrCorrupt, nil
+	}
+	return XattrOK, nil
+}
+
+// XattrHashFile returns the checksum of the file stored in its extended
+// attributes if the file wasn't modified since, and otherwise hashes
+// the file and stores the new checksum. Like FileCache, it doesn't trust
+// nor store checksums of files modified within the RacyWindow.
+func XattrHashFile(path string, algorithm Algorithm) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	racy := !info.ModTime().Before(time.Now().Add(-RacyWindow))
+	stored, err := ReadXattrTag(path, algorithm)
+	if err == nil && !racy && stored.ModTime.Equal(info.ModTime()) {
+		return hex.DecodeString(stored.Checksum)
+	}
+	if err != nil && err != ErrNoXattrTag {
+		return nil, err
+	}
+	tag, err := hashXattrTag(path, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if !racy {
+		if err := storeXattrTag(path, tag); err != nil {
+			return nil, err
+		}
+	}
+	return hex.DecodeString(tag.Checksum)
+}
+
+// hashXattrTag hashes the file, making sure it didn't change meanwhile.
+func hashXattrTag(path string, algorithm Algorithm) (XattrTag, error) {
+	hash, err := newHash(algorithm)
+	if err != nil {
+		return XattrTag{}, err
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		return XattrTag{}, err
+	}
+	sum, err := hashFile(hash, path)
+	if err != nil {
+		return XattrTag{}, err
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		return XattrTag{}, err
+	}
+	if cacheKey(algorithm, path, after) != cacheKey(algorithm, path, before) {
+		return XattrTag{}, ErrFileChanged
+	}
+	return XattrTag{Algorithm: algorithm, Checksum: hex.EncodeToString(sum), ModTime: before.ModTime()}, nil
+}
+
+// storeXattrTag stores the checksum and the modification time of the
+// tag. As the modification time is shared by the checksums of every
+// algorithm, the checksums of the other algorithms are removed when it
+// changes, since they were computed from an older content.
+func storeXattrTag(path string, tag XattrTag) error {
+	ts := formatXattrTime(tag.ModTime)
+	stored, err := getxattr(path, xattrTime)
+	if err != nil && err != ErrNoXattrTag {
+		return err
+	}
+	if err == ErrNoXattrTag || strings.TrimRight(string(stored), "\x00") != ts {
+		names, err := listxattr(path)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if !strings.HasPrefix(name, xattrPrefix) || name == xattrTime || name == xattrPrefix+string(tag.Algorithm) {
+				continue
+			}
+			if err := removexattr(path, name); err != nil && err != ErrNoXattrTag {
+				return err
+			}
+		}
+	}
+	if err := setxattr(path, xattrPrefix+string(tag.Algorithm), []byte(tag.Checksum)); err != nil {
+		return err
+	}
+	return setxattr(path, xattrTime, []byte(ts))
+}
+
+func formatXattrTime(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// parseXattrTime parses the stored modification time. Older shatag
+// versions store fewer than 9 fractional digits.
+func parseXattrTime(ts string) (time.Time, error) {
+	parts := strings.SplitN(ts, ".", 2)
+	seconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var nanoseconds int64
+	if len(parts) == 2 {
+		fraction := (parts[1] + "000000000")[:9]
+		if nanoseconds, err = strconv.ParseInt(fraction, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(seconds, nanoseconds), nil
+}
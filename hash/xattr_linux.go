@@ -0,0 +1,57 @@
+package hash
+
+import (
+	"strings"
+	"syscall"
+)
+
+func getxattr(path string, name string) ([]byte, error) {
+	buf := make([]byte, 256)
+	for {
+		n, err := syscall.Getxattr(path, name, buf)
+		switch err {
+		case nil:
+			return buf[:n], nil
+		case syscall.ERANGE:
+			buf = make([]byte, 2*len(buf))
+			continue
+		}
+		return nil, xattrError(err)
+	}
+}
+
+// listxattr returns the names of the extended attributes of the file.
+func listxattr(path string) ([]string, error) {
+	buf := make([]byte, 256)
+	for {
+		n, err := syscall.Listxattr(path, buf)
+		switch err {
+		case nil:
+			return strings.FieldsFunc(string(buf[:n]), func(r rune) bool { return r == 0 }), nil
+		case syscall.ERANGE:
+			buf = make([]byte, 2*len(buf))
+			continue
+		}
+		return nil, xattrError(err)
+	}
+}
+
+func setxattr(path string, name string, value []byte) error {
+	return xattrError(syscall.Setxattr(path, name, value, 0))
+}
+
+func removexattr(path string, name string) error {
+	return xattrError(syscall.Removexattr(path, name))
+}
+
+// xattrError maps the errors of missing attributes and of file systems
+// without extended attributes to the errors of this package.
+func xattrError(err error) error {
+	switch err {
+	case syscall.ENODATA:
+		return ErrNoXattrTag
+	case syscall.ENOTSUP:
+		return ErrXattrUnsupported
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+// +build !linux
+
+package hash
+
+func getxattr(path string, name string) ([]byte, error) {
+	return nil, ErrXattrUnsupported
+}
+
+func listxattr(path string) ([]string, error) {
+	return nil, ErrXattrUnsupported
+}
+
+func setxattr(path string, name string, value []byte) error {
+	return ErrXattrUnsupported
+}
+
+func removexattr(path string, name string) error {
+	return ErrXattrUnsupported
+}
@@ -0,0 +1,135 @@
+package hash
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXattrTag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xattr")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	foo := filepath.Join(dir, "foo")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+	hour := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(foo, hour, hour))
+
+	status, err := VerifyXattrTag(foo, Sha256Hash)
+	if err == ErrXattrUnsupported {
+		t.Skip("Extended attributes are not supported by the temporary directory")
+	}
+	require.NoError(t, err, "Error verifying checksum attributes")
+	assert.Equal(t, XattrStatus(XattrNew), status)
+
+	tag, err := WriteXattrTag(foo, Sha256Hash)
+	if err == ErrXattrUnsupported {
+		t.Skip("Extended attributes are not supported by the temporary directory")
+	}
+	require.NoError(t, err, "Error writing checksum attributes")
+	assert.Equal(t, XattrTag{Algorithm: Sha256Hash, Checksum: sha256Foo, ModTime: hour}, tag)
+
+	value, err := getxattr(foo, "user.shatag.ts")
+	require.NoError(t, err, "Error reading attribute")
+	assert.Equal(t, formatXattrTime(hour), string(value))
+
+	stored, err := ReadXattrTag(foo, Sha256Hash)
+	require.NoError(t, err, "Error reading checksum attributes")
+	assert.Equal(t, sha256Foo, stored.Checksum)
+	assert.True(t, hour.Equal(stored.ModTime))
+
+	status, err = VerifyXattrTag(foo, Sha256Hash)
+	require.NoError(t, err, "Error verifying checksum attributes")
+	assert.Equal(t, XattrStatus(XattrOK), status)
+
+	// The stored checksum is trusted while the modification time matches.
+	require.NoError(t, setxattr(foo, "user.shatag.sha256", []byte(sha256Bar)))
+	sum, err := XattrHashFile(foo, Sha256Hash)
+	require.NoError(t, err, "Error hashing file")
+	assert.Equal(t, sha256Bar, mustEncode(t, Hex, sum))
+
+	// Content changes that keep the modification time hint at bit rot.
+	status, err = VerifyXattrTag(foo, Sha256Hash)
+	require.NoError(t, err, "Error verifying checksum attributes")
+	assert.Equal(t, XattrStatus(XattrCorrupt), status)
+
+	require.NoError(t, os.Chtimes(foo, hour, hour.Add(time.Second)))
+	status, err = VerifyXattrTag(foo, Sha256Hash)
+	require.NoError(t, err, "Error verifying checksum attributes")
+	assert.Equal(t, XattrStatus(XattrOutdated), status)
+
+	sum, err = XattrHashFile(foo, Sha256Hash)
+	require.NoError(t, err, "Error hashing file")
+	assert.Equal(t, sha256Foo, mustEncode(t, Hex, sum))
+	status, err = VerifyXattrTag(foo, Sha256Hash)
+	require.NoError(t, err, "Error verifying checksum attributes")
+	assert.Equal(t, XattrStatus(XattrOK), status)
+
+	// The modification time is kept for the checksums of other
+	// algorithms.
+	_, err = WriteXattrTag(foo, Md5Hash)
+	require.NoError(t, err, "Error writing checksum attributes")
+	require.NoError(t, RemoveXattrTag(foo, Sha256Hash))
+	_, err = ReadXattrTag(foo, Sha256Hash)
+	assert.Equal(t, ErrNoXattrTag, err)
+	_, err = ReadXattrTag(foo, Md5Hash)
+	require.NoError(t, err, "Error reading checksum attributes")
+
+	require.NoError(t, RemoveXattrTag(foo, Md5Hash))
+	_, err = getxattr(foo, "user.shatag.ts")
+	assert.Equal(t, ErrNoXattrTag, err)
+}
+
+func TestXattrTagAlgorithms(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xattr")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	foo := filepath.Join(dir, "foo")
+	require.NoError(t, ioutil.WriteFile(foo, []byte("foo"), 0644))
+	hour := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(foo, hour, hour))
+	_, err = WriteXattrTag(foo, Sha256Hash)
+	if err == ErrXattrUnsupported {
+		t.Skip("Extended attributes are not supported by the temporary directory")
+	}
+	require.NoError(t, err, "Error writing checksum attributes")
+	_, err = WriteXattrTag(foo, Md5Hash)
+	require.NoError(t, err, "Error writing checksum attributes")
+	_, err = ReadXattrTag(foo, Sha256Hash)
+	require.NoError(t, err, "Checksums of the same content are kept")
+
+	// Tagging the modified file with md5 drops the outdated sha256.
+	require.NoError(t, ioutil.WriteFile(foo, []byte("bar"), 0644))
+	require.NoError(t, os.Chtimes(foo, hour, hour.Add(time.Second)))
+	_, err = WriteXattrTag(foo, Md5Hash)
+	require.NoError(t, err, "Error writing checksum attributes")
+	status, err := VerifyXattrTag(foo, Sha256Hash)
+	require.NoError(t, err, "Error verifying checksum attributes")
+	assert.Equal(t, XattrStatus(XattrNew), status)
+	sum, err := XattrHashFile(foo, Sha256Hash)
+	require.NoError(t, err, "Error hashing file")
+	assert.Equal(t, sha256Bar, mustEncode(t, Hex, sum))
+	status, err = VerifyXattrTag(foo, Md5Hash)
+	require.NoError(t, err, "Error verifying checksum attributes")
+	assert.Equal(t, XattrStatus(XattrOK), status)
+}
+
+func TestParseXattrTime(t *testing.T) {
+	ts, err := parseXattrTime("1234567890.5")
+	require.NoError(t, err, "Error parsing time")
+	assert.Equal(t, time.Unix(1234567890, 500000000), ts)
+
+	ts, err = parseXattrTime("1234567890")
+	require.NoError(t, err, "Error parsing time")
+	assert.Equal(t, time.Unix(1234567890, 0), ts)
+
+	_, err = parseXattrTime("foo")
+	assert.Error(t, err)
+}
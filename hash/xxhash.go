@@ -0,0 +1,139 @@
+package hash
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// xxHash64 is the streaming state of the 64-bit xxHash, which consumes
+// the input in stripes of 32 bytes.
+type xxHash64 struct {
+	seed   uint64
+	v      [4]uint64
+	total  uint64
+	buffer [32]byte
+	n      int
+}
+
+// NewXxHash64 returns a new hash.Hash64 computing the 64-bit xxHash of
+// the data with the seed. Its Sum method appends the checksum in big
+// endian order, like the FNV hashes of the standard library.
+func NewXxHash64(seed uint64) hash.Hash64 {
+	h := &xxHash64{seed: seed}
+	h.Reset()
+	return h
+}
+
+// XxHash64 returns the 64-bit xxHash of the given text.
+func XxHash64(text string) (uint64, error) {
+	return XxHash64Seed([]byte(text), 0), nil
+}
+
+// XxHash64Seed returns the 64-bit xxHash of the data with the seed.
+func XxHash64Seed(data []byte, seed uint64) uint64 {
+	h := xxHash64{seed: seed}
+	h.Reset()
+	h.Write(data)
+	return h.Sum64()
+}
+
+func xxRound(acc uint64, input uint64) uint64 {
+	acc += input * xxPrime2
+	return bits.RotateLeft64(acc, 31) * xxPrime1
+}
+
+func xxMergeRound(acc uint64, v uint64) uint64 {
+	acc ^= xxRound(0, v)
+	return acc*xxPrime1 + xxPrime4
+}
+
+func (h *xxHash64) Reset() {
+	h.v = [4]uint64{h.seed + xxPrime1 + xxPrime2, h.seed + xxPrime2, h.seed, h.seed - xxPrime1}
+	h.total = 0
+	h.n = 0
+}
+
+func (h *xxHash64) Size() int {
+	return 8
+}
+
+func (h *xxHash64) BlockSize() int {
+	return len(h.buffer)
+}
+
+func (h *xxHash64) Write(p []byte) (int, error) {
+	written := len(p)
+	h.total += uint64(written)
+	if h.n > 0 {
+		copied := copy(h.buffer[h.n:], p)
+		h.n += copied
+		p = p[copied:]
+		if h.n < len(h.buffer) {
+			return written, nil
+		}
+		h.stripe(h.buffer[:])
+		h.n = 0
+	}
+	for ; len(p) >= len(h.buffer); p = p[len(h.buffer):] {
+		h.stripe(p)
+	}
+	h.n = copy(h.buffer[:], p)
+	return written, nil
+}
+
+func (h *xxHash64) stripe(p []byte) {
+	for i := range h.v {
+		h.v[i] = xxRound(h.v[i], binary.LittleEndian.Uint64(p[8*i:]))
+	}
+}
+
+func (h *xxHash64) Sum64() uint64 {
+	var sum uint64
+	if h.total >= uint64(len(h.buffer)) {
+		sum = bits.RotateLeft64(h.v[0], 1) + bits.RotateLeft64(h.v[1], 7) +
+			bits.RotateLeft64(h.v[2], 12) + bits.RotateLeft64(h.v[3], 18)
+		for _, v := range h.v {
+			sum = xxMergeRound(sum, v)
+		}
+	} else {
+		sum = h.seed + xxPrime5
+	}
+	sum += h.total
+
+	p := h.buffer[:h.n]
+	for ; len(p) >= 8; p = p[8:] {
+		sum ^= xxRound(0, binary.LittleEndian.Uint64(p))
+		sum = bits.RotateLeft64(sum, 27)*xxPrime1 + xxPrime4
+	}
+	if len(p) >= 4 {
+		sum ^= uint64(binary.LittleEndian.Uint32(p)) * xxPrime1
+		sum = bits.RotateLeft64(sum, 23)*xxPrime2 + xxPrime3
+		p = p[4:]
+	}
+	for _, b := range p {
+		sum ^= uint64(b) * xxPrime5
+		sum = bits.RotateLeft64(sum, 11) * xxPrime1
+	}
+
+	sum ^= sum >> 33
+	sum *= xxPrime2
+	sum ^= sum >> 29
+	sum *= xxPrime3
+	sum ^= sum >> 32
+	return sum
+}
+
+func (h *xxHash64) Sum(b []byte) []byte {
+	var sum [8]byte
+	binary.BigEndian.PutUint64(sum[:], h.Sum64())
+	return append(b, sum[:]...)
+}
@@ -0,0 +1,44 @@
+package hash
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXxHash64(t *testing.T) {
+	tests := []struct {
+		text string
+		sum  uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"abc", 0x44bc2cf5ad770999},
+		{"hello, world", 0xb33a384e6d1b1242},
+		{"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789$", 0x1032d841e824f998},
+	}
+	for _, test := range tests {
+		sum, err := XxHash64(test.text)
+		require.NoError(t, err, "Error hashing text to using %s", XxHash64Hash)
+		assert.Equal(t, test.sum, sum, test.text)
+	}
+	assert.NotEqual(t, XxHash64Seed([]byte("abc"), 0), XxHash64Seed([]byte("abc"), 1))
+}
+
+func TestXxHash64Streaming(t *testing.T) {
+	data := bytes.Repeat([]byte("abcdefghijklmnopqrstuvwxyz0123456789"), 10)
+	h := NewXxHash64(42)
+	for i := 0; i < len(data); i += 7 {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		h.Write(data[i:end])
+	}
+	assert.Equal(t, XxHash64Seed(data, 42), h.Sum64())
+	assert.Equal(t, []byte{0xef, 0x46, 0xdb, 0x37, 0x51, 0xd8, 0xe9, 0x99}, NewXxHash64(0).Sum(nil))
+
+	h.Reset()
+	assert.Equal(t, XxHash64Seed(nil, 42), h.Sum64())
+}
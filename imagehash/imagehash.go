@@ -0,0 +1,198 @@
+package imagehash
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	// The decoders register the formats supported by HashFile.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+)
+
+var ErrUnsupportedMethod = errors.New("hashutils: unsupported perceptual hashing method")
+
+var ErrEmptyImage = errors.New("hashutils: image has no pixels")
+
+// Method is a perceptual hashing algorithm. Every method returns a
+// 64-bit hash whose most significant bit comes from the top left of
+// the image.
+type Method string
+
+const (
+	// AverageHash sets the bits of the pixels of the image reduced to
+	// 8x8 grays that are brighter than their mean.
+	AverageHash Method = "ahash"
+	// DifferenceHash sets the bits of the pixels of the image reduced to
+	// 9x8 grays that are darker than their right neighbour.
+	DifferenceHash = "dhash"
+	// PerceptualHash sets the bits of the 8x8 lowest frequencies of the
+	// discrete cosine transform of the image reduced to 32x32 grays that
+	// are above their median.
+	PerceptualHash = "phash"
+)
+
+// Hash returns the perceptual hash of the image with the method.
+func Hash(img image.Image, method Method) (uint64, error) {
+	if img.Bounds().Empty() {
+		return 0, ErrEmptyImage
+	}
+	switch method {
+	case AverageHash:
+		return Average(img), nil
+	case DifferenceHash:
+		return Difference(img), nil
+	case PerceptualHash:
+		return Perceptual(img), nil
+	}
+	return 0, ErrUnsupportedMethod
+}
+
+// HashFile decodes the PNG, JPEG or GIF image of the file and returns
+// its perceptual hash with the method.
+func HashFile(path string, method Method) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return 0, err
+	}
+	return Hash(img, method)
+}
+
+// Average returns the average hash of the image.
+func Average(img image.Image) uint64 {
+	pixels := grays(img, 8, 8)
+	mean := 0.0
+	for _, p := range pixels {
+		mean += p
+	}
+	mean /= float64(len(pixels))
+	var h uint64
+	for _, p := range pixels {
+		h <<= 1
+		if p > mean {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// Difference returns the difference hash of the image.
+func Difference(img image.Image) uint64 {
+	pixels := grays(img, 9, 8)
+	var h uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			h <<= 1
+			if pixels[y*9+x] < pixels[y*9+x+1] {
+				h |= 1
+			}
+		}
+	}
+	return h
+}
+
+// Perceptual returns the DCT based perceptual hash of the image.
+func Perceptual(img image.Image) uint64 {
+	const size = 32
+	coefficients := dct2(grays(img, size, size), size)
+	low := make([]float64, 0, 64)
+	for y := 0; y < 8; y++ {
+		low = append(low, coefficients[y*size:y*size+8]...)
+	}
+	sorted := append([]float64(nil), low...)
+	sort.Float64s(sorted)
+	median := (sorted[31] + sorted[32]) / 2
+	var h uint64
+	for _, c := range low {
+		h <<= 1
+		if c > median {
+			h |= 1
+		}
+	}
+	return h
+}
+
+// Distance returns the number of bits that differ between the hashes;
+// re-encoded or resized copies of an image are usually within 10 bits.
+func Distance(hash1 uint64, hash2 uint64) int {
+	return bits.OnesCount64(hash1 ^ hash2)
+}
+
+// Bytes returns the hash in big endian order, to be encoded like the
+// checksums of the hash package.
+func Bytes(hash uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, hash)
+	return b
+}
+
+// grays reduces the image to width x height luma values, every value
+// being the mean of the pixels of its area of the image.
+func grays(img image.Image, width int, height int) []float64 {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	pixels := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, h)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, w)
+			sum := 0.0
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, _ := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+				}
+			}
+			pixels[y*width+x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+	return pixels
+}
+
+// span returns the source pixels [start, end) covered by the target
+// pixel i of n, for a source of size pixels, covering at least one.
+func span(i int, n int, size int) (int, int) {
+	start := i * size / n
+	end := (i + 1) * size / n
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// dct2 returns the two dimensional DCT-II of the size x size values.
+func dct2(values []float64, size int) []float64 {
+	cosines := make([]float64, size*size)
+	for k := 0; k < size; k++ {
+		for n := 0; n < size; n++ {
+			cosines[k*size+n] = math.Cos(math.Pi / float64(size) * (float64(n) + 0.5) * float64(k))
+		}
+	}
+	transform := func(in []float64, out []float64, stride int) {
+		for k := 0; k < size; k++ {
+			sum := 0.0
+			for n := 0; n < size; n++ {
+				sum += in[n*stride] * cosines[k*size+n]
+			}
+			out[k*stride] = sum
+		}
+	}
+	rows := make([]float64, size*size)
+	for y := 0; y < size; y++ {
+		transform(values[y*size:], rows[y*size:], 1)
+	}
+	result := make([]float64, size*size)
+	for x := 0; x < size; x++ {
+		transform(rows[x:], result[x:], size)
+	}
+	return result
+}
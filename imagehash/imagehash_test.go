@@ -0,0 +1,114 @@
+package imagehash
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// landscape draws a smooth scene of the size: a diagonal gradient with
+// a bright disc.
+func landscape(width int, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			fx, fy := float64(x)/float64(width), float64(y)/float64(height)
+			v := 40 + 120*fx*fy
+			if math.Hypot(fx-0.3, fy-0.6) < 0.2 {
+				v = 230
+			}
+			img.Set(x, y, color.RGBA{uint8(v), uint8(v * 0.8), uint8(255 - v/2), 255})
+		}
+	}
+	return img
+}
+
+// stripes draws vertical stripes, a scene unrelated to landscape.
+func stripes(width int, height int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetGray(x, y, color.Gray{uint8(255 * (x / 12 % 2))})
+		}
+	}
+	return img
+}
+
+// reencode returns the image after a round trip through low quality
+// JPEG.
+func reencode(t *testing.T, img image.Image) image.Image {
+	var buffer bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 40}))
+	decoded, err := jpeg.Decode(&buffer)
+	require.NoError(t, err, "Error decoding JPEG")
+	return decoded
+}
+
+func TestHash(t *testing.T) {
+	original := landscape(320, 240)
+	for _, method := range []Method{AverageHash, DifferenceHash, PerceptualHash} {
+		h, err := Hash(original, method)
+		require.NoError(t, err, "Error hashing image using %s", method)
+
+		resized, err := Hash(landscape(160, 120), method)
+		require.NoError(t, err, "Error hashing image using %s", method)
+		assert.True(t, Distance(h, resized) <= 6, "%s: resized distance %d", method, Distance(h, resized))
+
+		reencoded, err := Hash(reencode(t, original), method)
+		require.NoError(t, err, "Error hashing image using %s", method)
+		assert.True(t, Distance(h, reencoded) <= 6, "%s: re-encoded distance %d", method, Distance(h, reencoded))
+
+		other, err := Hash(stripes(320, 240), method)
+		require.NoError(t, err, "Error hashing image using %s", method)
+		assert.True(t, Distance(h, other) > 16, "%s: unrelated distance %d", method, Distance(h, other))
+	}
+
+	_, err := Hash(original, Method("md5"))
+	assert.Equal(t, ErrUnsupportedMethod, err)
+	_, err = Hash(image.NewGray(image.Rect(0, 0, 0, 0)), AverageHash)
+	assert.Equal(t, ErrEmptyImage, err)
+}
+
+func TestHashTinyImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.SetGray(1, 0, color.Gray{255})
+	h, err := Hash(img, AverageHash)
+	require.NoError(t, err, "Error hashing image")
+	assert.Equal(t, uint64(0x0f0f0f0f0f0f0f0f), h)
+}
+
+func TestHashFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "imagehash")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+	img := landscape(64, 64)
+	path := filepath.Join(dir, "landscape.png")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(file, img))
+	require.NoError(t, file.Close())
+
+	h, err := HashFile(path, PerceptualHash)
+	require.NoError(t, err, "Error hashing file")
+	assert.Equal(t, Perceptual(img), h)
+
+	text := filepath.Join(dir, "notes.txt")
+	require.NoError(t, ioutil.WriteFile(text, []byte("not an image"), 0644))
+	_, err = HashFile(text, PerceptualHash)
+	assert.Equal(t, image.ErrFormat, err)
+}
+
+func TestBytes(t *testing.T) {
+	assert.Equal(t, []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}, Bytes(0x0123456789abcdef))
+	assert.Equal(t, 64, Distance(0, math.MaxUint64))
+}
@@ -0,0 +1,92 @@
+package probabilistic
+
+import "encoding/binary"
+
+// AppendHeader appends the magic, the version and the parameters as
+// unsigned varints.
+func AppendHeader(data []byte, magic string, version byte, parameters ...uint64) []byte {
+	data = append(data, magic...)
+	data = append(data, version)
+	for _, parameter := range parameters {
+		data = AppendUvarint(data, parameter)
+	}
+	return data
+}
+
+// AppendUint64 appends the value as a little endian 64-bit integer.
+func AppendUint64(data []byte, value uint64) []byte {
+	var buffer [8]byte
+	binary.LittleEndian.PutUint64(buffer[:], value)
+	return append(data, buffer[:]...)
+}
+
+// AppendUvarint appends the value as an unsigned varint.
+func AppendUvarint(data []byte, value uint64) []byte {
+	var buffer [binary.MaxVarintLen64]byte
+	return append(data, buffer[:binary.PutUvarint(buffer[:], value)]...)
+}
+
+// Decoder reads the unsigned varints and the bytes following a header
+// written by AppendHeader. Once a read fails, the following ones return
+// zero values and Err returns the invalid error the decoder was created
+// with.
+type Decoder struct {
+	data    []byte
+	invalid error
+	err     error
+}
+
+// NewDecoder checks the magic and the version of the data; invalid is
+// the error of malformed data.
+func NewDecoder(data []byte, magic string, version byte, invalid error) *Decoder {
+	if len(data) < len(magic)+1 || string(data[:len(magic)]) != magic || data[len(magic)] != version {
+		return &Decoder{invalid: invalid, err: invalid}
+	}
+	return &Decoder{data: data[len(magic)+1:], invalid: invalid}
+}
+
+// Uvarint reads the next unsigned varint.
+func (d *Decoder) Uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = d.invalid
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
+
+// Bytes reads the next n bytes.
+func (d *Decoder) Bytes(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if uint64(len(d.data)) < n {
+		d.err = d.invalid
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+// Remaining returns the data not read yet.
+func (d *Decoder) Remaining() []byte {
+	return d.data
+}
+
+// Err returns the error of the decoding so far.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Finish returns the error of the decoding, trailing data included.
+func (d *Decoder) Finish() error {
+	if d.err == nil && len(d.data) != 0 {
+		return d.invalid
+	}
+	return d.err
+}
@@ -0,0 +1,36 @@
+package probabilistic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errInvalid = errors.New("invalid")
+
+func TestDecoder(t *testing.T) {
+	data := AppendHeader(nil, "TEST", 1, 300, 7)
+	data = AppendUint64(data, 0x0102030405060708)
+	assert.Equal(t, []byte("TEST\x01\xac\x02\x07\x08\x07\x06\x05\x04\x03\x02\x01"), data)
+
+	d := NewDecoder(data, "TEST", 1, errInvalid)
+	assert.Equal(t, uint64(300), d.Uvarint())
+	assert.Equal(t, uint64(7), d.Uvarint())
+	assert.Len(t, d.Remaining(), 8)
+	assert.Equal(t, errInvalid, d.Finish(), "Trailing data")
+	assert.Len(t, d.Bytes(8), 8)
+	assert.NoError(t, d.Finish())
+
+	assert.Nil(t, d.Bytes(1))
+	assert.Equal(t, uint64(0), d.Uvarint())
+	assert.Equal(t, errInvalid, d.Err())
+	assert.Equal(t, errInvalid, NewDecoder(data, "TEST", 2, errInvalid).Err(), "Other version")
+	assert.Equal(t, errInvalid, NewDecoder(data, "HUBF", 1, errInvalid).Err(), "Other magic")
+}
+
+func TestIndexHashes(t *testing.T) {
+	h1, h2 := IndexHashes([]byte("abc"))
+	assert.Equal(t, uint64(0x44bc2cf5ad770999), h1)
+	assert.Equal(t, uint64(1), h2&1, "The second hash is odd")
+}
@@ -0,0 +1,16 @@
+// Package probabilistic holds the helpers the filters and the sketches
+// share: the double hashing of their items and the encoding of their
+// serialized headers.
+package probabilistic
+
+import "github.com/sarathkumarsivan/hashutils/hash"
+
+// SecondSeed seeds the second hash of the double hashing.
+const SecondSeed = 0x9e3779b97f4a7c15
+
+// IndexHashes returns the two hashes the positions of the data are
+// derived from, the 64-bit xxHash of the data with seeds 0 and
+// SecondSeed. The second one is odd so that it never cancels out.
+func IndexHashes(data []byte) (uint64, uint64) {
+	return hash.XxHash64Seed(data, 0), hash.XxHash64Seed(data, SecondSeed) | 1
+}
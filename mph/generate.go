@@ -0,0 +1,124 @@
+package mph
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrInvalidIdentifier = errors.New("hashutils: package and function names must be Go identifiers")
+
+var ErrReservedIdentifier = errors.New("hashutils: function name collides with an identifier of the generated source")
+
+var ErrKeyMismatch = errors.New("hashutils: keys are not those the table was built from")
+
+// GoOptions configures the Go source written by WriteGo.
+type GoOptions struct {
+	// Package is the name of the package of the source.
+	Package string
+	// Name is the name of the generated function; its tables are named
+	// after it, starting with a lower case letter.
+	Name string
+}
+
+// WriteGo writes a Go source file, for go generate, declaring a function
+// returning the position of a key among the keys, which must be the ones
+// the table was built from, or -1 for any other key. The table and the
+// keys are embedded in the source, which imports the hash package of
+// this module.
+func (t *Table) WriteGo(w io.Writer, keys []string, options GoOptions) error {
+	if !token.IsIdentifier(options.Package) || !token.IsIdentifier(options.Name) {
+		return ErrInvalidIdentifier
+	}
+	if reservedIdentifier(options.Name, options.Package) {
+		return ErrReservedIdentifier
+	}
+	if uint64(len(keys)) != t.keys {
+		return ErrKeyMismatch
+	}
+	// The position of the keys among the keys, by index.
+	positions := make([]uint32, len(keys))
+	placed := make([]bool, len(keys))
+	for i, key := range keys {
+		index := t.LookupString(key)
+		if placed[index] {
+			return ErrKeyMismatch
+		}
+		positions[index], placed[index] = uint32(i), true
+	}
+
+	first, size := utf8.DecodeRuneInString(options.Name)
+	prefix := string(unicode.ToLower(first)) + options.Name[size:]
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "// Code generated by hashutils mph. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&source, "package %s\n\n", options.Package)
+	fmt.Fprintf(&source, "import \"github.com/sarathkumarsivan/hashutils/hash\"\n\n")
+	fmt.Fprintf(&source, "const (\n")
+	fmt.Fprintf(&source, "// %sSeed is the seed of the perfect hash function.\n", prefix)
+	fmt.Fprintf(&source, "%sSeed = %d\n", prefix, t.seed)
+	fmt.Fprintf(&source, "// %sSlots is the number of slots of the perfect hash function.\n", prefix)
+	fmt.Fprintf(&source, "%sSlots = %d\n", prefix, t.slots)
+	fmt.Fprintf(&source, ")\n\n")
+	fmt.Fprintf(&source, "// %sDisplacements are the displacements of the buckets.\n", prefix)
+	writeUint32s(&source, prefix+"Displacements", t.displacements)
+	fmt.Fprintf(&source, "// %sRemap holds the index of the keys of the slots past the keys.\n", prefix)
+	writeUint32s(&source, prefix+"Remap", t.remap)
+	fmt.Fprintf(&source, "// %sKeys are the keys by index.\n", prefix)
+	fmt.Fprintf(&source, "var %sKeys = [...]string{\n", prefix)
+	for _, i := range positions {
+		fmt.Fprintf(&source, "%s,\n", strconv.Quote(keys[i]))
+	}
+	fmt.Fprintf(&source, "}\n\n")
+	fmt.Fprintf(&source, "// %sPositions are the positions of the keys by index.\n", prefix)
+	writeUint32s(&source, prefix+"Positions", positions)
+	fmt.Fprintf(&source, "// %s returns the position of the key among the keys it was generated\n", options.Name)
+	fmt.Fprintf(&source, "// from, or -1 if it isn't one of them.\n")
+	fmt.Fprintf(&source, "func %s(key string) int {\n", options.Name)
+	fmt.Fprintf(&source, "\tdata := []byte(key)\n")
+	fmt.Fprintf(&source, "\td := uint64(%[1]sDisplacements[hash.XxHash64Seed(data, %[1]sSeed)%%uint64(len(%[1]sDisplacements))])\n", prefix)
+	fmt.Fprintf(&source, "\th := hash.XxHash64Seed(data, %sSeed+1)\n", prefix)
+	fmt.Fprintf(&source, "\tindex := (h&0xffffffff%%%[1]sSlots + d/%[1]sSlots*(h>>32%%%[1]sSlots) + d%%%[1]sSlots) %% %[1]sSlots\n", prefix)
+	fmt.Fprintf(&source, "\tif index >= uint64(len(%[1]sKeys)) {\n\t\tindex = uint64(%[1]sRemap[index-uint64(len(%[1]sKeys))])\n\t}\n", prefix)
+	fmt.Fprintf(&source, "\tif %sKeys[index] != key {\n\t\treturn -1\n\t}\n", prefix)
+	fmt.Fprintf(&source, "\treturn int(%sPositions[index])\n}\n", prefix)
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// reservedIdentifier returns whether the function name collides with the
+// hash import or the predeclared identifiers the generated source uses,
+// or can't name a function of the package. The tables can't collide
+// with the function, their names being the name followed by Seed, Slots,
+// Displacements, Remap, Keys or Positions.
+func reservedIdentifier(name string, pkg string) bool {
+	switch name {
+	case "hash", "len", "int", "string", "uint32", "uint64", "init", "_":
+		return true
+	case "main":
+		return pkg == "main"
+	}
+	return false
+}
+
+// writeUint32s writes the declaration of an array of uint32, 16 a line.
+func writeUint32s(w io.Writer, name string, values []uint32) {
+	fmt.Fprintf(w, "var %s = [...]uint32{", name)
+	for i, value := range values {
+		if i%16 == 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		fmt.Fprintf(w, "%d, ", value)
+	}
+	fmt.Fprintf(w, "\n}\n\n")
+}
@@ -0,0 +1,100 @@
+package mph
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGo(t *testing.T) {
+	keys := []string{"fr", "de", "us", "gb", "it", "es", "pt", "nl", "be", "ch", "at", "se"}
+	table, err := BuildStrings(keys, Options{})
+	require.NoError(t, err, "Error building table")
+
+	var source bytes.Buffer
+	require.NoError(t, table.WriteGo(&source, keys, GoOptions{Package: "countries", Name: "Country"}))
+	file, err := parser.ParseFile(token.NewFileSet(), "country.go", source.Bytes(), 0)
+	require.NoError(t, err, "Error parsing generated source")
+	assert.Equal(t, "countries", file.Name.Name)
+	assert.NotNil(t, file.Scope.Lookup("Country"))
+	assert.NotNil(t, file.Scope.Lookup("countryDisplacements"))
+	assert.Contains(t, source.String(), "// Code generated by hashutils mph. DO NOT EDIT.\n")
+	for _, key := range keys {
+		assert.Contains(t, source.String(), "\t\""+key+"\",\n")
+	}
+
+	assert.Equal(t, ErrInvalidIdentifier, table.WriteGo(&source, keys, GoOptions{Package: "countries", Name: "country-code"}))
+	assert.Equal(t, ErrInvalidIdentifier, table.WriteGo(&source, keys, GoOptions{Name: "Country"}))
+	for _, name := range []string{"hash", "len", "uint64", "init", "_"} {
+		assert.Equal(t, ErrReservedIdentifier, table.WriteGo(&source, keys, GoOptions{Package: "countries", Name: name}), name)
+	}
+	assert.Equal(t, ErrReservedIdentifier, table.WriteGo(&source, keys, GoOptions{Package: "main", Name: "main"}))
+	assert.Equal(t, ErrKeyMismatch, table.WriteGo(&source, keys[1:], GoOptions{Package: "countries", Name: "Country"}))
+	other := append([]string{"xx"}, keys[1:]...)
+	assert.Equal(t, ErrKeyMismatch, table.WriteGo(&source, other, GoOptions{Package: "countries", Name: "Country"}))
+}
+
+// TestWriteGoRun compiles the generated source in a module replacing
+// this one by its tree, and checks the positions it returns.
+func TestWriteGoRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping the build of the generated source in short mode")
+	}
+	goCommand, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("Skipping the build of the generated source without the go command")
+	}
+	root, err := filepath.Abs("..")
+	require.NoError(t, err, "Error locating the module")
+	dir, err := ioutil.TempDir("", "mph")
+	require.NoError(t, err, "Error creating temporary directory")
+	defer os.RemoveAll(dir)
+
+	keys := []string{"fr", "de", "us", "gb", "it", "es", "pt", "nl", "be", "ch", "at", "se"}
+	table, err := BuildStrings(keys, Options{})
+	require.NoError(t, err, "Error building table")
+	var source bytes.Buffer
+	require.NoError(t, table.WriteGo(&source, keys, GoOptions{Package: "main", Name: "Country"}))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "country.go"), source.Bytes(), 0644))
+	main := `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	for _, key := range os.Args[1:] {
+		fmt.Println(Country(key))
+	}
+}
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(main), 0644))
+	module := fmt.Sprintf("module countries\n\ngo 1.14\n\nrequire github.com/sarathkumarsivan/hashutils v0.0.0\n\n"+
+		"replace github.com/sarathkumarsivan/hashutils => %s\n", root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(module), 0644))
+
+	command := exec.Command(goCommand, append([]string{"run", "."}, append(keys, "xx", "")...)...)
+	command.Dir = dir
+	command.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	require.NoError(t, err, "Error running the generated source: %s", stderr.String())
+	var expected strings.Builder
+	for i := range keys {
+		fmt.Fprintf(&expected, "%d\n", i)
+	}
+	expected.WriteString("-1\n-1\n")
+	assert.Equal(t, expected.String(), string(output))
+}
@@ -0,0 +1,281 @@
+package mph
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/sarathkumarsivan/hashutils/internal/probabilistic"
+)
+
+var ErrNoKeys = errors.New("hashutils: a perfect hash function needs at least one key")
+
+var ErrDuplicateKey = errors.New("hashutils: duplicate key")
+
+var ErrInvalidBucketSize = errors.New("hashutils: bucket size must be between 1 and 6")
+
+var ErrTooManyKeys = errors.New("hashutils: a perfect hash function holds at most 2^31 keys")
+
+var ErrBuildFailed = errors.New("hashutils: no seed places the keys without collisions")
+
+var ErrInvalidTable = errors.New("hashutils: invalid serialized perfect hash table")
+
+const (
+	tableMagic   = "HUMP"
+	tableVersion = 1
+	// load is the fraction of the slots taken by the keys, whose slack
+	// keeps the search for the displacements of the last buckets short.
+	load = 0.99
+	// maxAttempts is the number of seeds tried before giving up.
+	maxAttempts = 64
+)
+
+// Options configures the build of a Table.
+type Options struct {
+	// BucketSize is the average number of keys per bucket, from 1 to 6,
+	// each bucket storing one displacement; 0 selects 4. Larger buckets
+	// make the table smaller and the build slower.
+	BucketSize int
+}
+
+// Table is a minimal perfect hash function built with the CHD algorithm
+// of Belazzougui, Botelho and Dietzfelbinger: it maps each of the n keys
+// it was built from to a distinct index of [0, n).
+//
+// The keys are split into buckets by their 64-bit xxHash with the seed
+// of the table, modulo the number of buckets. With h the 64-bit xxHash
+// of a key with the seed plus 1, f1 its low 32 bits and f2 its high 32
+// bits modulo the m = n/0.99 slots, and d the displacement of its bucket,
+// the slot of the key is (f1 + (d/m)*f2 + d%m) mod m. The displacements,
+// from 0, are searched bucket by bucket, the largest first, until the
+// keys of the bucket land on free slots; when some can't, the build
+// starts over with the next even seed. The index of a key is its slot
+// when below n; the keys in the slots past n are remapped to the free
+// slots below n.
+//
+// Keys outside the set get an arbitrary index, so callers keep the keys
+// to check them.
+type Table struct {
+	seed          uint64
+	keys          uint64
+	slots         uint64
+	displacements []uint32
+	// remap holds the index of the keys of the slots past n.
+	remap []uint32
+}
+
+// Build returns the minimal perfect hash function of the keys, which
+// must be distinct.
+func Build(keys [][]byte, options Options) (*Table, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	if uint64(len(keys)) > math.MaxInt32 {
+		return nil, ErrTooManyKeys
+	}
+	size := options.BucketSize
+	if size == 0 {
+		size = 4
+	}
+	if size < 0 || size > 6 {
+		return nil, ErrInvalidBucketSize
+	}
+	for seed := uint64(0); seed < 2*maxAttempts; seed += 2 {
+		t := &Table{
+			seed:          seed,
+			keys:          uint64(len(keys)),
+			slots:         uint64(math.Ceil(float64(len(keys)) / load)),
+			displacements: make([]uint32, (len(keys)+size-1)/size),
+		}
+		placed, err := t.place(keys)
+		if err != nil {
+			return nil, err
+		}
+		if placed {
+			return t, nil
+		}
+	}
+	return nil, ErrBuildFailed
+}
+
+// place searches the displacements of the buckets, and tells whether
+// all of them were found.
+func (t *Table) place(keys [][]byte) (bool, error) {
+	buckets := make([][]int, len(t.displacements))
+	for i, key := range keys {
+		b := t.bucket(key)
+		for _, j := range buckets[b] {
+			if bytes.Equal(keys[j], key) {
+				return false, ErrDuplicateKey
+			}
+		}
+		buckets[b] = append(buckets[b], i)
+	}
+	order := make([]int, len(buckets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return len(buckets[order[i]]) > len(buckets[order[j]]) })
+
+	// Every pair of d/m and d%m below m is tried, as far as a uint32 goes.
+	limit := uint64(math.MaxUint32)
+	if t.slots*t.slots-1 < limit {
+		limit = t.slots*t.slots - 1
+	}
+	taken := make([]bool, t.slots)
+	var f1, f2, slots []uint64
+	for _, b := range order {
+		if len(buckets[b]) == 0 {
+			break
+		}
+		f1, f2 = f1[:0], f2[:0]
+		for _, k := range buckets[b] {
+			h1, h2 := t.hashes(keys[k])
+			for i := range f1 {
+				// No displacement separates the keys.
+				if f1[i] == h1 && f2[i] == h2 {
+					return false, nil
+				}
+			}
+			f1, f2 = append(f1, h1), append(f2, h2)
+		}
+		placed := false
+		for d := uint64(0); d <= limit && !placed; d++ {
+			d0, d1 := d/t.slots, d%t.slots
+			slots = slots[:0]
+			placed = true
+			for i := range f1 {
+				slot := (f1[i] + d0*f2[i] + d1) % t.slots
+				if taken[slot] || containsSlot(slots, slot) {
+					placed = false
+					break
+				}
+				slots = append(slots, slot)
+			}
+			if placed {
+				t.displacements[b] = uint32(d)
+			}
+		}
+		if !placed {
+			return false, nil
+		}
+		for _, slot := range slots {
+			taken[slot] = true
+		}
+	}
+
+	// The keys past n move to the free slots below n, in order.
+	t.remap = make([]uint32, t.slots-t.keys)
+	free := uint64(0)
+	for slot := t.keys; slot < t.slots; slot++ {
+		if !taken[slot] {
+			continue
+		}
+		for taken[free] {
+			free++
+		}
+		t.remap[slot-t.keys] = uint32(free)
+		free++
+	}
+	return true, nil
+}
+
+// BuildStrings returns the minimal perfect hash function of the strings,
+// which must be distinct.
+func BuildStrings(keys []string, options Options) (*Table, error) {
+	data := make([][]byte, len(keys))
+	for i, key := range keys {
+		data[i] = []byte(key)
+	}
+	return Build(data, options)
+}
+
+func containsSlot(slots []uint64, slot uint64) bool {
+	for _, s := range slots {
+		if s == slot {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Table) bucket(key []byte) uint64 {
+	return hash.XxHash64Seed(key, t.seed) % uint64(len(t.displacements))
+}
+
+// hashes returns f1 and f2, the slot and the step of the key.
+func (t *Table) hashes(key []byte) (uint64, uint64) {
+	h := hash.XxHash64Seed(key, t.seed+1)
+	return h & math.MaxUint32 % t.slots, h >> 32 % t.slots
+}
+
+// Len returns the number of keys of the table.
+func (t *Table) Len() int {
+	return int(t.keys)
+}
+
+// Lookup returns the index of the key, distinct for every key the table
+// was built from.
+func (t *Table) Lookup(key []byte) uint64 {
+	d := uint64(t.displacements[t.bucket(key)])
+	f1, f2 := t.hashes(key)
+	slot := (f1 + d/t.slots*f2 + d%t.slots) % t.slots
+	if slot >= t.keys {
+		return uint64(t.remap[slot-t.keys])
+	}
+	return slot
+}
+
+// LookupString returns the index of the string.
+func (t *Table) LookupString(key string) uint64 {
+	return t.Lookup([]byte(key))
+}
+
+// MarshalBinary encodes the table as the "HUMP" magic, a version byte,
+// the unsigned varints of the seed, of the number of keys, of slots and
+// of buckets, then the displacements of the buckets and the indexes of
+// the slots past the number of keys, as unsigned varints.
+func (t *Table) MarshalBinary() ([]byte, error) {
+	data := probabilistic.AppendHeader(nil, tableMagic, tableVersion, t.seed, t.keys, t.slots, uint64(len(t.displacements)))
+	for _, d := range t.displacements {
+		data = probabilistic.AppendUvarint(data, uint64(d))
+	}
+	for _, index := range t.remap {
+		data = probabilistic.AppendUvarint(data, uint64(index))
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a table encoded by MarshalBinary.
+func (t *Table) UnmarshalBinary(data []byte) error {
+	d := probabilistic.NewDecoder(data, tableMagic, tableVersion, ErrInvalidTable)
+	seed, keys, slots, buckets := d.Uvarint(), d.Uvarint(), d.Uvarint(), d.Uvarint()
+	// Every displacement and index takes at least a byte.
+	remaining := uint64(len(d.Remaining()))
+	if d.Err() != nil || keys == 0 || slots < keys || slots > math.MaxUint32 || buckets == 0 ||
+		buckets > remaining || slots-keys > remaining-buckets {
+		return ErrInvalidTable
+	}
+	table := &Table{seed: seed, keys: keys, slots: slots, displacements: make([]uint32, buckets), remap: make([]uint32, slots-keys)}
+	for i := range table.displacements {
+		displacement := d.Uvarint()
+		if displacement > math.MaxUint32 {
+			return ErrInvalidTable
+		}
+		table.displacements[i] = uint32(displacement)
+	}
+	for i := range table.remap {
+		index := d.Uvarint()
+		if index >= keys {
+			return ErrInvalidTable
+		}
+		table.remap[i] = uint32(index)
+	}
+	if err := d.Finish(); err != nil {
+		return err
+	}
+	*t = *table
+	return nil
+}
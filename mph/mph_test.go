@@ -0,0 +1,88 @@
+package mph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func skus(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("SKU-%08d", i)
+	}
+	return keys
+}
+
+func TestBuild(t *testing.T) {
+	for _, n := range []int{1, 2, 10, 1000, 100000} {
+		for _, size := range []int{0, 1, 6} {
+			keys := skus(n)
+			table, err := BuildStrings(keys, Options{BucketSize: size})
+			require.NoError(t, err, "Error building %d keys", n)
+			assert.Equal(t, n, table.Len())
+			seen := make([]bool, n)
+			for _, key := range keys {
+				index := table.LookupString(key)
+				require.True(t, index < uint64(n), "Index out of range")
+				require.False(t, seen[index], "Collision of %s", key)
+				seen[index] = true
+			}
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	_, err := BuildStrings(nil, Options{})
+	assert.Equal(t, ErrNoKeys, err)
+	_, err = BuildStrings([]string{"fr", "de", "fr"}, Options{})
+	assert.Equal(t, ErrDuplicateKey, err)
+	_, err = BuildStrings([]string{"fr", "de"}, Options{BucketSize: 7})
+	assert.Equal(t, ErrInvalidBucketSize, err)
+}
+
+func TestTableMarshalBinary(t *testing.T) {
+	keys := skus(5000)
+	table, err := BuildStrings(keys, Options{})
+	require.NoError(t, err, "Error building table")
+	data, err := table.MarshalBinary()
+	require.NoError(t, err, "Error encoding table")
+	assert.Equal(t, "HUMP\x01\x00\x88\x27\xbb\x27\xe2\x09", string(data[:12]))
+
+	var decoded Table
+	require.NoError(t, decoded.UnmarshalBinary(data), "Error decoding table")
+	assert.Equal(t, table, &decoded)
+	for _, key := range keys {
+		assert.Equal(t, table.LookupString(key), decoded.LookupString(key))
+	}
+
+	assert.Equal(t, ErrInvalidTable, decoded.UnmarshalBinary(data[:len(data)-1]))
+	assert.Equal(t, ErrInvalidTable, decoded.UnmarshalBinary(append(data, 0)))
+	assert.Equal(t, ErrInvalidTable, decoded.UnmarshalBinary([]byte("HUMP\x01\x00\x00\x01\x01\x00")))
+	assert.Equal(t, ErrInvalidTable, decoded.UnmarshalBinary([]byte("HUMP\x01\x00\x02\x01\x01\x00")))
+	assert.Equal(t, ErrInvalidTable, decoded.UnmarshalBinary([]byte("HUMP\x01\x00\x01\x02\x01\x00\x01")))
+	assert.NoError(t, decoded.UnmarshalBinary([]byte("HUMP\x01\x00\x01\x02\x01\x00\x00")))
+	assert.Equal(t, ErrInvalidTable, decoded.UnmarshalBinary([]byte("HUBF\x01\x01\x01\x01")))
+}
+
+func BenchmarkBuild(b *testing.B) {
+	keys := skus(100000)
+	for i := 0; i < b.N; i++ {
+		if _, err := BuildStrings(keys, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	keys := skus(100000)
+	table, err := BuildStrings(keys, Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		table.LookupString(keys[i%len(keys)])
+	}
+}
@@ -0,0 +1,70 @@
+package sharding
+
+import (
+	"encoding/binary"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+// HashFunc maps a key to a 64-bit position.
+type HashFunc func(key string) uint64
+
+// NewHashFunc returns the HashFunc of the algorithm. The FNV hashes and
+// xxhash64 are used as is; the other algorithms, SHA-1 among them, are
+// reduced to the first 8 bytes of their checksum read in big endian
+// order.
+func NewHashFunc(algorithm hash.Algorithm) (HashFunc, error) {
+	switch algorithm {
+	case hash.XxHash64Hash:
+		return func(key string) uint64 {
+			return hash.XxHash64Seed([]byte(key), 0)
+		}, nil
+	case hash.Fnv32Hash:
+		return fnvFunc32(hash.Fnv32), nil
+	case hash.Fnv32aHash:
+		return fnvFunc32(hash.Fnv32a), nil
+	case hash.Fnv64Hash:
+		return fnvFunc64(hash.Fnv64), nil
+	case hash.Fnv64aHash:
+		return fnvFunc64(hash.Fnv64a), nil
+	}
+	maker := hash.New().Algorithm(algorithm).Encoding(hash.Hex).Build()
+	// Fail now rather than on the first key.
+	if _, err := maker.HashText(""); err != nil {
+		return nil, err
+	}
+	return func(key string) uint64 {
+		checksum, _ := maker.HashText(key)
+		sum, _ := hash.Decode(hash.Hex, checksum)
+		if len(sum) < 8 {
+			sum = append(make([]byte, 8-len(sum)), sum...)
+		}
+		return binary.BigEndian.Uint64(sum)
+	}, nil
+}
+
+func fnvFunc32(fn func(string) (uint32, error)) HashFunc {
+	return func(key string) uint64 {
+		sum, _ := fn(key)
+		return uint64(sum)
+	}
+}
+
+func fnvFunc64(fn func(string) (uint64, error)) HashFunc {
+	return func(key string) uint64 {
+		sum, _ := fn(key)
+		return sum
+	}
+}
+
+// mix64 is the finalizer of the 64-bit MurmurHash3, a bijection
+// spreading every input bit over the output. It evens out hashes whose
+// high bits change little between similar short keys, like FNV's.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,35 @@
+package sharding
+
+import (
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHashFunc(t *testing.T) {
+	fn, err := NewHashFunc(hash.Fnv32aHash)
+	require.NoError(t, err, "Error creating hash function using %s", hash.Fnv32aHash)
+	assert.Equal(t, uint64(0xa9f37ed7), fn("foo"))
+
+	fn, err = NewHashFunc(hash.Fnv64aHash)
+	require.NoError(t, err, "Error creating hash function using %s", hash.Fnv64aHash)
+	assert.Equal(t, uint64(0xdcb27518fed9d577), fn("foo"))
+
+	fn, err = NewHashFunc(hash.XxHash64Hash)
+	require.NoError(t, err, "Error creating hash function using %s", hash.XxHash64Hash)
+	assert.Equal(t, uint64(0x44bc2cf5ad770999), fn("abc"))
+
+	// The first 8 bytes of 0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33.
+	fn, err = NewHashFunc(hash.Sha1Hash)
+	require.NoError(t, err, "Error creating hash function using %s", hash.Sha1Hash)
+	assert.Equal(t, uint64(0x0beec7b5ea3f0fdb), fn("foo"))
+
+	fn, err = NewHashFunc(hash.Crc32Hash)
+	require.NoError(t, err, "Error creating hash function using %s", hash.Crc32Hash)
+	assert.True(t, fn("foo") < 1<<32)
+
+	_, err = NewHashFunc("md4")
+	assert.Equal(t, hash.ErrUnsupportedAlgorithm, err)
+}
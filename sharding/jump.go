@@ -0,0 +1,38 @@
+package sharding
+
+import (
+	"errors"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+var ErrInvalidBuckets = errors.New("hashutils: number of buckets must be positive")
+
+// Jump returns the bucket in [0, buckets) of the key with the jump
+// consistent hash of Lamping and Veach. Growing from n to n+1 buckets
+// only moves 1/(n+1) of the keys, all of them to the new bucket, and
+// needs no memory at all, but buckets can only be added or removed at
+// the end of the range.
+func Jump(key uint64, buckets int) (int, error) {
+	if buckets <= 0 {
+		return 0, ErrInvalidBuckets
+	}
+	b, j := int64(-1), int64(0)
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b), nil
+}
+
+// JumpString returns the bucket of the key with Jump, the key being
+// hashed with the 64-bit FNV-1a hash passed through the MurmurHash3
+// finalizer.
+func JumpString(key string, buckets int) (int, error) {
+	sum, err := hash.Fnv64a(key)
+	if err != nil {
+		return 0, err
+	}
+	return Jump(mix64(sum), buckets)
+}
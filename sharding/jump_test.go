@@ -0,0 +1,63 @@
+package sharding
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJump(t *testing.T) {
+	tests := []struct {
+		key     uint64
+		buckets int
+		bucket  int
+	}{
+		{1, 1, 0},
+		{42, 57, 43},
+		{0xdead10cc, 1, 0},
+		{0xdead10cc, 666, 361},
+		{256, 1024, 520},
+	}
+	for _, test := range tests {
+		bucket, err := Jump(test.key, test.buckets)
+		require.NoError(t, err, "Error hashing %d", test.key)
+		assert.Equal(t, test.bucket, bucket, "key %d, %d buckets", test.key, test.buckets)
+	}
+	_, err := Jump(1, 0)
+	assert.Equal(t, ErrInvalidBuckets, err)
+}
+
+func TestJumpString(t *testing.T) {
+	counts := make([]int, 10)
+	moved := 0
+	for i := 0; i < testKeys; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		bucket, err := JumpString(key, 10)
+		require.NoError(t, err, "Error hashing %s", key)
+		counts[bucket]++
+		grown, err := JumpString(key, 11)
+		require.NoError(t, err, "Error hashing %s", key)
+		if grown != bucket {
+			assert.Equal(t, 10, grown, "Keys only move to the new bucket")
+			moved++
+		}
+	}
+	for bucket, count := range counts {
+		assert.InDelta(t, testKeys/10, count, testKeys/10*0.05, "bucket %d has %d keys", bucket, count)
+	}
+	assert.InDelta(t, testKeys/11, moved, testKeys/11*0.05, "%d keys moved", moved)
+}
+
+func BenchmarkJump(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Jump(uint64(i), 1000)
+	}
+}
+
+func BenchmarkJumpString(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		JumpString("user:42", 1000)
+	}
+}
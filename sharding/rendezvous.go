@@ -0,0 +1,135 @@
+package sharding
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+// rendezvousNode is a node of a Rendezvous with the hash of its name.
+type rendezvousNode struct {
+	name   string
+	weight float64
+	hash   uint64
+}
+
+// Rendezvous is highest random weight hashing: every node draws a
+// score for the key from the hashes of both, and the key belongs to the
+// node with the highest score. Removing a node only moves its own keys,
+// and adding one only takes keys from the others, without virtual
+// nodes, at the cost of scoring every node on lookups. A Rendezvous is
+// safe for concurrent use.
+type Rendezvous struct {
+	hash  HashFunc
+	mutex sync.RWMutex
+	nodes []rendezvousNode
+}
+
+// NewRendezvous returns a Rendezvous without nodes hashing the keys and
+// the nodes with the hash function, the 64-bit FNV-1a hash if nil.
+func NewRendezvous(fn HashFunc) *Rendezvous {
+	if fn == nil {
+		fn, _ = NewHashFunc(hash.Fnv64aHash)
+	}
+	return &Rendezvous{hash: fn}
+}
+
+// Add adds the node, or changes its weight if it is already there. The
+// share of the keys of a node is its weight over the sum of the weights.
+func (r *Rendezvous) Add(node string, weight float64) error {
+	if weight <= 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+		return ErrInvalidWeight
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.nodes {
+		if r.nodes[i].name == node {
+			r.nodes[i].weight = weight
+			return nil
+		}
+	}
+	r.nodes = append(r.nodes, rendezvousNode{name: node, weight: weight, hash: r.hash(node)})
+	sort.Slice(r.nodes, func(i, j int) bool { return r.nodes[i].name < r.nodes[j].name })
+	return nil
+}
+
+// Remove removes the node.
+func (r *Rendezvous) Remove(node string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.nodes {
+		if r.nodes[i].name == node {
+			r.nodes = append(r.nodes[:i], r.nodes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrUnknownNode
+}
+
+// Nodes returns the nodes, sorted.
+func (r *Rendezvous) Nodes() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	nodes := make([]string, len(r.nodes))
+	for i, node := range r.nodes {
+		nodes[i] = node.name
+	}
+	return nodes
+}
+
+// score returns the score of the node for the key hash, using the
+// logarithmic method of weighted rendezvous hashing: the hashes are
+// mixed into a uniform u in (0, 1) and the score is -weight/ln(u).
+func score(node rendezvousNode, keyHash uint64) float64 {
+	u := (float64(mix64(node.hash^keyHash)>>11) + 0.5) / (1 << 53)
+	return -node.weight / math.Log(u)
+}
+
+// Get returns the node the key belongs to.
+func (r *Rendezvous) Get(key string) (string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if len(r.nodes) == 0 {
+		return "", ErrNoNodes
+	}
+	keyHash := mix64(r.hash(key))
+	best, bestScore := "", math.Inf(-1)
+	for _, node := range r.nodes {
+		if s := score(node, keyHash); s > bestScore {
+			best, bestScore = node.name, s
+		}
+	}
+	return best, nil
+}
+
+// GetN returns up to n nodes for the key, to hold its replicas, from
+// the highest score down. Fewer nodes are returned when there are fewer
+// than n. ErrInvalidReplicas is returned if n is negative.
+func (r *Rendezvous) GetN(key string, n int) ([]string, error) {
+	if n < 0 {
+		return nil, ErrInvalidReplicas
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if len(r.nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	keyHash := mix64(r.hash(key))
+	scores := make([]float64, len(r.nodes))
+	order := make([]int, len(r.nodes))
+	for i, node := range r.nodes {
+		scores[i] = score(node, keyHash)
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+	if n > len(order) {
+		n = len(order)
+	}
+	nodes := make([]string, n)
+	for i := range nodes {
+		nodes[i] = r.nodes[order[i]].name
+	}
+	return nodes, nil
+}
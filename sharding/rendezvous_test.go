@@ -0,0 +1,112 @@
+package sharding
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rendezvousOwners(t *testing.T, r *Rendezvous) []string {
+	nodes := make([]string, testKeys)
+	for i := range nodes {
+		node, err := r.Get(fmt.Sprintf("key-%d", i))
+		require.NoError(t, err, "Error getting node")
+		nodes[i] = node
+	}
+	return nodes
+}
+
+func TestRendezvous(t *testing.T) {
+	r := NewRendezvous(nil)
+	_, err := r.Get("key")
+	assert.Equal(t, ErrNoNodes, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, r.Add(fmt.Sprintf("node-%d", i), 1))
+	}
+	before := rendezvousOwners(t, r)
+	counts := make(map[string]int)
+	for _, node := range before {
+		counts[node]++
+	}
+	for node, count := range counts {
+		assert.InDelta(t, testKeys/10, count, testKeys/10*0.05, "%s has %d keys", node, count)
+	}
+
+	require.NoError(t, r.Add("node-10", 1))
+	after := rendezvousOwners(t, r)
+	moved := 0
+	for i := range before {
+		if before[i] != after[i] {
+			assert.Equal(t, "node-10", after[i], "Keys only move to the new node")
+			moved++
+		}
+	}
+	assert.InDelta(t, testKeys/11, moved, testKeys/11*0.05, "%d keys moved", moved)
+
+	require.NoError(t, r.Remove("node-10"))
+	assert.Equal(t, before, rendezvousOwners(t, r))
+	assert.Equal(t, ErrUnknownNode, r.Remove("node-10"))
+}
+
+func TestRendezvousWeights(t *testing.T) {
+	r := NewRendezvous(nil)
+	require.NoError(t, r.Add("small", 1))
+	require.NoError(t, r.Add("large", 3))
+	counts := make(map[string]int)
+	for _, node := range rendezvousOwners(t, r) {
+		counts[node]++
+	}
+	assert.InDelta(t, testKeys*3/4, counts["large"], testKeys*0.02, "large has %d keys", counts["large"])
+	assert.Equal(t, ErrInvalidWeight, r.Add("none", 0))
+}
+
+func TestRendezvousGetN(t *testing.T) {
+	r := NewRendezvous(nil)
+	for _, node := range []string{"c", "a", "b"} {
+		require.NoError(t, r.Add(node, 1))
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, r.Nodes())
+	_, err := r.GetN("key", -1)
+	assert.Equal(t, ErrInvalidReplicas, err)
+	nodes, err := r.GetN("key", 0)
+	require.NoError(t, err, "Error getting nodes")
+	assert.Empty(t, nodes)
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		primary, err := r.Get(key)
+		require.NoError(t, err, "Error getting node")
+		nodes, err := r.GetN(key, 5)
+		require.NoError(t, err, "Error getting nodes")
+		require.Len(t, nodes, 3)
+		assert.Equal(t, primary, nodes[0])
+		assert.ElementsMatch(t, []string{"a", "b", "c"}, nodes)
+	}
+}
+
+func benchmarkNodes(b *testing.B, add func(node string) error) {
+	for i := 0; i < 100; i++ {
+		if err := add(fmt.Sprintf("node-%d", i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+}
+
+func BenchmarkRendezvousGet(b *testing.B) {
+	r := NewRendezvous(nil)
+	benchmarkNodes(b, func(node string) error { return r.Add(node, 1) })
+	for i := 0; i < b.N; i++ {
+		r.Get("user:42")
+	}
+}
+
+func BenchmarkRingGet(b *testing.B) {
+	r := NewRing(RingOptions{})
+	benchmarkNodes(b, func(node string) error { return r.Add(node, 1) })
+	for i := 0; i < b.N; i++ {
+		r.Get("user:42")
+	}
+}
@@ -0,0 +1,198 @@
+package sharding
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+)
+
+var ErrNoNodes = errors.New("hashutils: no nodes to map the keys to")
+
+var ErrInvalidWeight = errors.New("hashutils: node weight must be positive")
+
+var ErrUnknownNode = errors.New("hashutils: unknown node")
+
+var ErrInvalidReplicas = errors.New("hashutils: number of replicas must not be negative")
+
+// RingOptions configures a Ring.
+type RingOptions struct {
+	// Hash places the virtual nodes and the keys on the ring; nil
+	// selects xxhash64.
+	Hash HashFunc
+	// VirtualNodes is the number of virtual nodes of a node of weight 1;
+	// 0 selects 160. More virtual nodes balance the keys better at the
+	// cost of memory.
+	VirtualNodes int
+}
+
+// point is a virtual node on the ring.
+type point struct {
+	position uint64
+	node     string
+}
+
+// ringState is an immutable snapshot of the ring, replaced as a whole
+// when the membership changes so that lookups never wait.
+type ringState struct {
+	weights map[string]int
+	points  []point
+}
+
+// Ring is a consistent hashing ring: a key belongs to the node of the
+// first virtual node following its position on the ring, so adding or
+// removing a node only moves the keys of its own virtual nodes. A Ring
+// is safe for concurrent use; lookups see the membership either before
+// or after a change, never a mix of both.
+type Ring struct {
+	hash         HashFunc
+	virtualNodes int
+	mutex        sync.Mutex
+	state        atomic.Value
+}
+
+// NewRing returns an empty ring.
+func NewRing(options RingOptions) *Ring {
+	r := &Ring{hash: options.Hash, virtualNodes: options.VirtualNodes}
+	if r.hash == nil {
+		r.hash, _ = NewHashFunc(hash.XxHash64Hash)
+	}
+	if r.virtualNodes <= 0 {
+		r.virtualNodes = 160
+	}
+	r.state.Store(&ringState{weights: map[string]int{}})
+	return r
+}
+
+func (r *Ring) load() *ringState {
+	return r.state.Load().(*ringState)
+}
+
+// Add adds the node to the ring, or changes its weight if it is already
+// there. A node of weight w gets w times as many virtual nodes, and
+// keys, as a node of weight 1.
+func (r *Ring) Add(node string, weight int) error {
+	if weight <= 0 {
+		return ErrInvalidWeight
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	weights := r.copyWeights()
+	weights[node] = weight
+	r.store(weights)
+	return nil
+}
+
+// Remove removes the node from the ring.
+func (r *Ring) Remove(node string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	weights := r.copyWeights()
+	if _, ok := weights[node]; !ok {
+		return ErrUnknownNode
+	}
+	delete(weights, node)
+	r.store(weights)
+	return nil
+}
+
+func (r *Ring) copyWeights() map[string]int {
+	current := r.load().weights
+	weights := make(map[string]int, len(current)+1)
+	for node, weight := range current {
+		weights[node] = weight
+	}
+	return weights
+}
+
+// position returns the position of the key on the ring, its hash passed
+// through mix64.
+func (r *Ring) position(key string) uint64 {
+	return mix64(r.hash(key))
+}
+
+// store replaces the state with the virtual nodes of the weights. The
+// virtual node i of a node is placed at the position of "node#i".
+func (r *Ring) store(weights map[string]int) {
+	var points []point
+	for node, weight := range weights {
+		for i := 0; i < weight*r.virtualNodes; i++ {
+			points = append(points, point{position: r.position(node + "#" + strconv.Itoa(i)), node: node})
+		}
+	}
+	// Virtual nodes colliding are ordered by node so that every ring
+	// with the same members agrees.
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].position != points[j].position {
+			return points[i].position < points[j].position
+		}
+		return points[i].node < points[j].node
+	})
+	r.state.Store(&ringState{weights: weights, points: points})
+}
+
+// Nodes returns the nodes of the ring, sorted.
+func (r *Ring) Nodes() []string {
+	weights := r.load().weights
+	nodes := make([]string, 0, len(weights))
+	for node := range weights {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Weight returns the weight of the node, 0 if it is not in the ring.
+func (r *Ring) Weight(node string) int {
+	return r.load().weights[node]
+}
+
+// search returns the index of the first virtual node at or after the
+// hash of the key, wrapping around the ring.
+func (s *ringState) search(position uint64) int {
+	i := sort.Search(len(s.points), func(i int) bool { return s.points[i].position >= position })
+	if i == len(s.points) {
+		return 0
+	}
+	return i
+}
+
+// Get returns the node the key belongs to.
+func (r *Ring) Get(key string) (string, error) {
+	s := r.load()
+	if len(s.points) == 0 {
+		return "", ErrNoNodes
+	}
+	return s.points[s.search(r.position(key))].node, nil
+}
+
+// GetN returns up to n distinct nodes for the key, to hold its
+// replicas: the node it belongs to followed by the next distinct nodes
+// clockwise on the ring. Fewer nodes are returned when the ring has
+// fewer than n. ErrInvalidReplicas is returned if n is negative.
+func (r *Ring) GetN(key string, n int) ([]string, error) {
+	if n < 0 {
+		return nil, ErrInvalidReplicas
+	}
+	s := r.load()
+	if len(s.points) == 0 {
+		return nil, ErrNoNodes
+	}
+	if n > len(s.weights) {
+		n = len(s.weights)
+	}
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	start := s.search(r.position(key))
+	for i := 0; len(nodes) < n && i < len(s.points); i++ {
+		node := s.points[(start+i)%len(s.points)].node
+		if !seen[node] {
+			seen[node] = true
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
@@ -0,0 +1,151 @@
+package sharding
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeys = 100000
+
+// owners returns the node of every test key.
+func owners(t *testing.T, r *Ring) []string {
+	nodes := make([]string, testKeys)
+	for i := range nodes {
+		node, err := r.Get(fmt.Sprintf("key-%d", i))
+		require.NoError(t, err, "Error getting node")
+		nodes[i] = node
+	}
+	return nodes
+}
+
+func newTestRing(t *testing.T, algorithm hash.Algorithm, nodes int) *Ring {
+	fn, err := NewHashFunc(algorithm)
+	require.NoError(t, err, "Error creating hash function using %s", algorithm)
+	r := NewRing(RingOptions{Hash: fn})
+	for i := 0; i < nodes; i++ {
+		require.NoError(t, r.Add(fmt.Sprintf("node-%d", i), 1))
+	}
+	return r
+}
+
+func TestRingDistribution(t *testing.T) {
+	for _, algorithm := range []hash.Algorithm{hash.XxHash64Hash, hash.Fnv64aHash, hash.Sha1Hash} {
+		r := newTestRing(t, algorithm, 10)
+		counts := make(map[string]int)
+		for _, node := range owners(t, r) {
+			counts[node]++
+		}
+		require.Len(t, counts, 10)
+		for node, count := range counts {
+			assert.InDelta(t, testKeys/10, count, testKeys/10*0.25, "%s: %s has %d keys", algorithm, node, count)
+		}
+	}
+}
+
+func TestRingWeights(t *testing.T) {
+	r := newTestRing(t, hash.XxHash64Hash, 4)
+	require.NoError(t, r.Add("node-0", 3))
+	assert.Equal(t, 3, r.Weight("node-0"))
+	counts := make(map[string]int)
+	for _, node := range owners(t, r) {
+		counts[node]++
+	}
+	// node-0 holds 3 of the 6 weight units.
+	assert.InDelta(t, testKeys/2, counts["node-0"], testKeys/2*0.15, "node-0 has %d keys", counts["node-0"])
+
+	assert.Equal(t, ErrInvalidWeight, r.Add("node-4", 0))
+}
+
+func TestRingMinimalMovement(t *testing.T) {
+	r := newTestRing(t, hash.XxHash64Hash, 10)
+	before := owners(t, r)
+
+	require.NoError(t, r.Add("node-10", 1))
+	after := owners(t, r)
+	moved := 0
+	for i := range before {
+		if before[i] != after[i] {
+			assert.Equal(t, "node-10", after[i], "Keys only move to the new node")
+			moved++
+		}
+	}
+	assert.InDelta(t, testKeys/11, moved, testKeys/11*0.3, "%d keys moved", moved)
+
+	require.NoError(t, r.Remove("node-3"))
+	removed := owners(t, r)
+	for i := range after {
+		if after[i] != "node-3" {
+			assert.Equal(t, after[i], removed[i], "Only the keys of the removed node move")
+		} else {
+			assert.NotEqual(t, "node-3", removed[i])
+		}
+	}
+	assert.Equal(t, ErrUnknownNode, r.Remove("node-3"))
+}
+
+func TestRingGetN(t *testing.T) {
+	r := NewRing(RingOptions{})
+	_, err := r.Get("key")
+	assert.Equal(t, ErrNoNodes, err)
+	_, err = r.GetN("key", 2)
+	assert.Equal(t, ErrNoNodes, err)
+
+	for _, node := range []string{"a", "b", "c"} {
+		require.NoError(t, r.Add(node, 1))
+	}
+	_, err = r.GetN("key", -1)
+	assert.Equal(t, ErrInvalidReplicas, err)
+	nodes, err := r.GetN("key", 0)
+	require.NoError(t, err, "Error getting nodes")
+	assert.Empty(t, nodes)
+	assert.Equal(t, []string{"a", "b", "c"}, r.Nodes())
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		primary, err := r.Get(key)
+		require.NoError(t, err, "Error getting node")
+		replicas, err := r.GetN(key, 2)
+		require.NoError(t, err, "Error getting nodes")
+		require.Len(t, replicas, 2)
+		assert.Equal(t, primary, replicas[0])
+		assert.NotEqual(t, replicas[0], replicas[1])
+
+		all, err := r.GetN(key, 5)
+		require.NoError(t, err, "Error getting nodes")
+		assert.ElementsMatch(t, []string{"a", "b", "c"}, all)
+	}
+}
+
+func TestRingConcurrentReads(t *testing.T) {
+	r := newTestRing(t, hash.XxHash64Hash, 3)
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				nodes, err := r.GetN(fmt.Sprintf("key-%d", j), 2)
+				if err != nil || len(nodes) != 2 {
+					t.Errorf("Unexpected lookup result %v, %v", nodes, err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 3; i < 20; i++ {
+		require.NoError(t, r.Add(fmt.Sprintf("node-%d", i), 1))
+		require.NoError(t, r.Remove(fmt.Sprintf("node-%d", i-3)))
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,263 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/sarathkumarsivan/hashutils/internal/probabilistic"
+)
+
+var ErrInvalidAccuracy = errors.New("hashutils: Count-Min epsilon and delta must be between 0 and 1")
+
+var ErrIncompatibleSketches = errors.New("hashutils: Count-Min sketches have different sizes or update rules")
+
+const countMinMagic = "HUCM"
+
+// CountMinOptions configures a CountMin sketch.
+type CountMinOptions struct {
+	// Conservative only increments the counters of an item that are
+	// below its new estimate, which lowers the overestimation but makes
+	// the sketch unable to count down.
+	Conservative bool
+	// HeavyHitters is the number of most frequent items tracked; 0
+	// disables the tracking.
+	HeavyHitters int
+}
+
+// HeavyHitter is a frequent item of a CountMin sketch.
+type HeavyHitter struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+}
+
+// CountMin is a Count-Min sketch estimating how many times items were
+// added, never under the actual count and, with a probability of
+// 1-delta, over it by at most epsilon times the total of the counts. The
+// column of an item in row i is h1 + i*h2 modulo the width, h1 and h2
+// being the 64-bit xxHash of the item with two seeds.
+type CountMin struct {
+	width        uint64
+	depth        uint64
+	conservative bool
+	total        uint64
+	counters     []uint64
+	capacity     int
+	heavy        map[string]uint64
+}
+
+// NewCountMin returns a sketch whose estimates exceed the counts by at
+// most epsilon times the total with a probability of 1-delta: it has
+// e/epsilon columns and ln(1/delta) rows.
+func NewCountMin(epsilon float64, delta float64, options CountMinOptions) (*CountMin, error) {
+	if epsilon <= 0 || epsilon >= 1 || delta <= 0 || delta >= 1 {
+		return nil, ErrInvalidAccuracy
+	}
+	width := uint64(math.Ceil(math.E / epsilon))
+	depth := uint64(math.Ceil(math.Log(1 / delta)))
+	return NewCountMinSize(width, depth, options), nil
+}
+
+// NewCountMinSize returns a sketch of width columns and depth rows.
+func NewCountMinSize(width uint64, depth uint64, options CountMinOptions) *CountMin {
+	if width == 0 {
+		width = 1
+	}
+	if depth == 0 {
+		depth = 1
+	}
+	c := &CountMin{
+		width:        width,
+		depth:        depth,
+		conservative: options.Conservative,
+		counters:     make([]uint64, width*depth),
+		capacity:     options.HeavyHitters,
+	}
+	if c.capacity > 0 {
+		c.heavy = make(map[string]uint64, c.capacity)
+	}
+	return c
+}
+
+// columns returns the counter of every row for the item.
+func (c *CountMin) columns(data []byte) []uint64 {
+	h1, h2 := probabilistic.IndexHashes(data)
+	positions := make([]uint64, c.depth)
+	for i := range positions {
+		positions[i] = uint64(i)*c.width + (h1+uint64(i)*h2)%c.width
+	}
+	return positions
+}
+
+func (c *CountMin) minimum(positions []uint64) uint64 {
+	estimate := uint64(math.MaxUint64)
+	for _, position := range positions {
+		if c.counters[position] < estimate {
+			estimate = c.counters[position]
+		}
+	}
+	return estimate
+}
+
+// Add adds count occurrences of the item.
+func (c *CountMin) Add(data []byte, count uint64) {
+	positions := c.columns(data)
+	c.total += count
+	if c.conservative {
+		target := c.minimum(positions) + count
+		for _, position := range positions {
+			if c.counters[position] < target {
+				c.counters[position] = target
+			}
+		}
+	} else {
+		for _, position := range positions {
+			c.counters[position] += count
+		}
+	}
+	if c.heavy != nil {
+		c.track(string(data), c.minimum(positions))
+	}
+}
+
+// AddString adds count occurrences of the string.
+func (c *CountMin) AddString(s string, count uint64) {
+	c.Add([]byte(s), count)
+}
+
+// track records the estimate of the item if it is among the most
+// frequent ones.
+func (c *CountMin) track(key string, estimate uint64) {
+	if _, ok := c.heavy[key]; ok || len(c.heavy) < c.capacity {
+		c.heavy[key] = estimate
+		return
+	}
+	smallest, smallestKey := uint64(math.MaxUint64), ""
+	for k, count := range c.heavy {
+		if count < smallest || count == smallest && k > smallestKey {
+			smallest, smallestKey = count, k
+		}
+	}
+	if estimate > smallest {
+		delete(c.heavy, smallestKey)
+		c.heavy[key] = estimate
+	}
+}
+
+// Estimate returns the estimated number of occurrences of the item.
+func (c *CountMin) Estimate(data []byte) uint64 {
+	return c.minimum(c.columns(data))
+}
+
+// EstimateString returns the estimated number of occurrences of the
+// string.
+func (c *CountMin) EstimateString(s string) uint64 {
+	return c.Estimate([]byte(s))
+}
+
+// Total returns the sum of the counts added to the sketch.
+func (c *CountMin) Total() uint64 {
+	return c.total
+}
+
+// HeavyHitters returns the tracked most frequent items with their
+// estimated counts, the most frequent first.
+func (c *CountMin) HeavyHitters() []HeavyHitter {
+	hitters := make([]HeavyHitter, 0, len(c.heavy))
+	for key := range c.heavy {
+		hitters = append(hitters, HeavyHitter{Key: key, Count: c.EstimateString(key)})
+	}
+	sort.Slice(hitters, func(i, j int) bool {
+		if hitters[i].Count != hitters[j].Count {
+			return hitters[i].Count > hitters[j].Count
+		}
+		return hitters[i].Key < hitters[j].Key
+	})
+	return hitters
+}
+
+// Merge adds the counts of the other sketch, of the same size and
+// update rule, to the sketch. The heavy hitters of both are estimated
+// again on the merged sketch.
+func (c *CountMin) Merge(other *CountMin) error {
+	if c.width != other.width || c.depth != other.depth || c.conservative != other.conservative {
+		return ErrIncompatibleSketches
+	}
+	for i := range c.counters {
+		c.counters[i] += other.counters[i]
+	}
+	c.total += other.total
+	if c.heavy == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(c.heavy)+len(other.heavy))
+	for key := range c.heavy {
+		keys = append(keys, key)
+	}
+	for key := range other.heavy {
+		keys = append(keys, key)
+	}
+	c.heavy = make(map[string]uint64, c.capacity)
+	for _, key := range keys {
+		c.track(key, c.EstimateString(key))
+	}
+	return nil
+}
+
+// MarshalBinary encodes the sketch as the "HUCM" magic, a version byte,
+// the unsigned varints of the width, the depth, the update rule, 1 for
+// conservative, the total, the heavy hitter capacity and the number of
+// tracked items, the counters row by row as little endian 64-bit
+// integers, and the tracked items as the unsigned varint of their
+// length followed by their bytes, sorted.
+func (c *CountMin) MarshalBinary() ([]byte, error) {
+	conservative := uint64(0)
+	if c.conservative {
+		conservative = 1
+	}
+	data := appendHeader(nil, countMinMagic, c.width, c.depth, conservative, c.total,
+		uint64(c.capacity), uint64(len(c.heavy)))
+	for _, counter := range c.counters {
+		data = probabilistic.AppendUint64(data, counter)
+	}
+	keys := make([]string, 0, len(c.heavy))
+	for key := range c.heavy {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		data = probabilistic.AppendUvarint(data, uint64(len(key)))
+		data = append(data, key...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a sketch encoded by MarshalBinary.
+func (c *CountMin) UnmarshalBinary(data []byte) error {
+	d := newDecoder(data, countMinMagic)
+	width, depth, conservative, total := d.Uvarint(), d.Uvarint(), d.Uvarint(), d.Uvarint()
+	capacity, tracked := d.Uvarint(), d.Uvarint()
+	if d.Err() != nil || width == 0 || depth == 0 || conservative > 1 || tracked > capacity ||
+		capacity > math.MaxInt32 || uint64(len(d.Remaining()))/8/depth < width {
+		return ErrInvalidSketch
+	}
+	sketch := NewCountMinSize(width, depth, CountMinOptions{Conservative: conservative == 1, HeavyHitters: int(capacity)})
+	sketch.total = total
+	counters := d.Bytes(width * depth * 8)
+	for i := range sketch.counters {
+		sketch.counters[i] = binary.LittleEndian.Uint64(counters[8*i:])
+	}
+	for i := uint64(0); i < tracked && d.Err() == nil; i++ {
+		key := d.Bytes(d.Uvarint())
+		sketch.heavy[string(key)] = 0
+	}
+	if err := d.Finish(); err != nil {
+		return err
+	}
+	for key := range sketch.heavy {
+		sketch.heavy[key] = sketch.EstimateString(key)
+	}
+	*c = *sketch
+	return nil
+}
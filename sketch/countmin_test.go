@@ -0,0 +1,78 @@
+package sketch
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addZipf adds item-i 1000/(i+1) times for the first n items.
+func addZipf(c *CountMin, n int) {
+	for i := 0; i < n; i++ {
+		c.AddString(fmt.Sprintf("item-%d", i), uint64(1000/(i+1)))
+	}
+}
+
+func TestCountMin(t *testing.T) {
+	for _, conservative := range []bool{false, true} {
+		c, err := NewCountMin(0.001, 0.01, CountMinOptions{Conservative: conservative})
+		require.NoError(t, err, "Error creating sketch")
+		addZipf(c, 5000)
+		bound := uint64(0.001 * float64(c.Total()))
+		for i := 0; i < 5000; i++ {
+			count := uint64(1000 / (i + 1))
+			estimate := c.EstimateString(fmt.Sprintf("item-%d", i))
+			assert.True(t, estimate >= count, "Never underestimates")
+			assert.True(t, estimate <= count+bound, "item-%d: %d for %d", i, estimate, count)
+		}
+		assert.True(t, c.EstimateString("missing") <= bound)
+	}
+
+	_, err := NewCountMin(0, 0.01, CountMinOptions{})
+	assert.Equal(t, ErrInvalidAccuracy, err)
+}
+
+func TestCountMinConservative(t *testing.T) {
+	plain := NewCountMinSize(64, 4, CountMinOptions{})
+	conservative := NewCountMinSize(64, 4, CountMinOptions{Conservative: true})
+	addZipf(plain, 500)
+	addZipf(conservative, 500)
+	plainError, conservativeError := uint64(0), uint64(0)
+	for i := 0; i < 500; i++ {
+		count := uint64(1000 / (i + 1))
+		plainError += plain.EstimateString(fmt.Sprintf("item-%d", i)) - count
+		conservativeError += conservative.EstimateString(fmt.Sprintf("item-%d", i)) - count
+	}
+	assert.True(t, conservativeError < plainError, "%d >= %d", conservativeError, plainError)
+}
+
+func TestCountMinHeavyHitters(t *testing.T) {
+	c := NewCountMinSize(2048, 5, CountMinOptions{HeavyHitters: 3})
+	addZipf(c, 1000)
+	assert.Equal(t, []HeavyHitter{{"item-0", 1000}, {"item-1", 500}, {"item-2", 333}}, c.HeavyHitters())
+
+	other := NewCountMinSize(2048, 5, CountMinOptions{HeavyHitters: 3})
+	other.AddString("burst", 800)
+	other.AddString("item-2", 500)
+	require.NoError(t, c.Merge(other), "Error merging sketches")
+	assert.Equal(t, []HeavyHitter{{"item-0", 1000}, {"item-2", 833}, {"burst", 800}}, c.HeavyHitters())
+	assert.Equal(t, ErrIncompatibleSketches, c.Merge(NewCountMinSize(2048, 4, CountMinOptions{})))
+}
+
+func TestCountMinMarshalBinary(t *testing.T) {
+	c := NewCountMinSize(100, 3, CountMinOptions{Conservative: true, HeavyHitters: 2})
+	addZipf(c, 50)
+	data, err := c.MarshalBinary()
+	require.NoError(t, err, "Error encoding sketch")
+	assert.Equal(t, "HUCM\x01\x64\x03\x01", string(data[:8]))
+
+	var decoded CountMin
+	require.NoError(t, decoded.UnmarshalBinary(data), "Error decoding sketch")
+	assert.Equal(t, c, &decoded)
+	assert.Equal(t, c.HeavyHitters(), decoded.HeavyHitters())
+
+	assert.Equal(t, ErrInvalidSketch, decoded.UnmarshalBinary(data[:len(data)-1]))
+	assert.Equal(t, ErrInvalidSketch, decoded.UnmarshalBinary([]byte("HUHL\x01")))
+}
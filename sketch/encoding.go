@@ -0,0 +1,22 @@
+package sketch
+
+import (
+	"errors"
+
+	"github.com/sarathkumarsivan/hashutils/internal/probabilistic"
+)
+
+var ErrInvalidSketch = errors.New("hashutils: invalid serialized sketch")
+
+const sketchVersion = 1
+
+// appendHeader appends the magic, the sketch version and the parameters
+// as unsigned varints.
+func appendHeader(data []byte, magic string, parameters ...uint64) []byte {
+	return probabilistic.AppendHeader(data, magic, sketchVersion, parameters...)
+}
+
+// newDecoder checks the magic and the version of the data.
+func newDecoder(data []byte, magic string) *probabilistic.Decoder {
+	return probabilistic.NewDecoder(data, magic, sketchVersion, ErrInvalidSketch)
+}
@@ -0,0 +1,246 @@
+package sketch
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/sarathkumarsivan/hashutils/hash"
+	"github.com/sarathkumarsivan/hashutils/internal/probabilistic"
+)
+
+var ErrInvalidPrecision = errors.New("hashutils: HyperLogLog precision must be between 4 and 18")
+
+var ErrPrecisionMismatch = errors.New("hashutils: HyperLogLog sketches have different precisions")
+
+const (
+	hyperLogLogMagic = "HUHL"
+	// sparsePrecision is the precision of the indexes of the sparse
+	// representation.
+	sparsePrecision = 25
+)
+
+// HyperLogLog estimates the number of distinct items added to it with
+// the HyperLogLog++ algorithm over the 64-bit xxHash: small sets are
+// kept in a sparse representation of precision 25, counted exactly up to
+// hash collisions, which turns into 2^precision registers as it grows.
+// The relative error of the dense estimate is about
+// 1.04/sqrt(2^precision). Instead of the empirical bias correction of
+// HyperLogLog++, estimates up to 2.5 times the number of registers are
+// replaced with linear counting while some registers are empty, as in
+// the original HyperLogLog.
+type HyperLogLog struct {
+	precision uint8
+	// sparse maps the indexes of precision 25 to their rank, until the
+	// sketch turns dense.
+	sparse    map[uint32]uint8
+	registers []uint8
+}
+
+// NewHyperLogLog returns an empty sketch of the precision, from 4 to 18.
+// The dense sketch takes 2^precision bytes; 14 gives an error of 0.8%
+// in 16 KiB.
+func NewHyperLogLog(precision uint8) (*HyperLogLog, error) {
+	if precision < 4 || precision > 18 {
+		return nil, ErrInvalidPrecision
+	}
+	return &HyperLogLog{precision: precision, sparse: make(map[uint32]uint8)}, nil
+}
+
+// Precision returns the precision of the sketch.
+func (h *HyperLogLog) Precision() uint8 {
+	return h.precision
+}
+
+// Sparse tells whether the sketch still uses the sparse representation.
+func (h *HyperLogLog) Sparse() bool {
+	return h.registers == nil
+}
+
+// rank returns the position of the first bit set in the bits of the
+// hash following the index, counted from 1, or the number of those bits
+// plus 1 if none is set.
+func rank(sum uint64, precision uint8) uint8 {
+	return uint8(bits.LeadingZeros64(sum<<precision|1<<(precision-1)) + 1)
+}
+
+// Add adds the item to the sketch.
+func (h *HyperLogLog) Add(data []byte) {
+	h.AddHash(hash.XxHash64Seed(data, 0))
+}
+
+// AddString adds the string to the sketch.
+func (h *HyperLogLog) AddString(s string) {
+	h.Add([]byte(s))
+}
+
+// AddHash adds an item by its 64-bit hash, which must be uniform over
+// all its bits; a hash with weak high bits, like FNV of short keys,
+// should be mixed first.
+func (h *HyperLogLog) AddHash(sum uint64) {
+	if h.registers != nil {
+		index := sum >> (64 - h.precision)
+		if r := rank(sum, h.precision); r > h.registers[index] {
+			h.registers[index] = r
+		}
+		return
+	}
+	index := uint32(sum >> (64 - sparsePrecision))
+	if r := rank(sum, sparsePrecision); r > h.sparse[index] {
+		h.sparse[index] = r
+	}
+	// A sparse entry costs more than 4 registers.
+	if len(h.sparse) > 1<<h.precision/4 {
+		h.toDense()
+	}
+}
+
+// toDense turns the sparse representation into registers.
+func (h *HyperLogLog) toDense() {
+	h.registers = make([]uint8, 1<<h.precision)
+	extra := sparsePrecision - h.precision
+	for sparseIndex, sparseRank := range h.sparse {
+		index := sparseIndex >> extra
+		// The bits of the sparse index beyond the precision come first in
+		// the rank of the dense register.
+		r := sparseRank + extra
+		if rest := sparseIndex << (32 - extra); rest != 0 {
+			r = uint8(bits.LeadingZeros32(rest)) + 1
+		}
+		if r > h.registers[index] {
+			h.registers[index] = r
+		}
+	}
+	h.sparse = nil
+}
+
+func linearCounting(m float64, empty float64) float64 {
+	return m * math.Log(m/empty)
+}
+
+// Estimate returns the estimated number of distinct items of the sketch.
+func (h *HyperLogLog) Estimate() uint64 {
+	if h.registers == nil {
+		m := float64(uint64(1) << sparsePrecision)
+		return uint64(math.Round(linearCounting(m, m-float64(len(h.sparse)))))
+	}
+	m := float64(len(h.registers))
+	sum, empty := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			empty++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	}
+	estimate := alpha * m * m / sum
+	if empty > 0 && estimate <= 2.5*m {
+		estimate = linearCounting(m, float64(empty))
+	}
+	return uint64(math.Round(estimate))
+}
+
+// Merge adds the items of the other sketch, of the same precision, to
+// the sketch, as if they had been added to it.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.precision != other.precision {
+		return ErrPrecisionMismatch
+	}
+	if h.registers == nil && other.registers == nil {
+		for index, r := range other.sparse {
+			if r > h.sparse[index] {
+				h.sparse[index] = r
+			}
+		}
+		if len(h.sparse) > 1<<h.precision/4 {
+			h.toDense()
+		}
+		return nil
+	}
+	if h.registers == nil {
+		h.toDense()
+	}
+	registers := other.registers
+	if registers == nil {
+		dense := &HyperLogLog{precision: other.precision, sparse: other.sparse}
+		dense.toDense()
+		registers = dense.registers
+	}
+	for i, r := range registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// MarshalBinary encodes the sketch as the "HUHL" magic, a version byte,
+// the unsigned varints of the precision and of the representation, 0
+// for sparse and 1 for dense, and then either the number of sparse
+// entries followed by the unsigned varint deltas of the entries sorted
+// as index<<6|rank, or the 2^precision registers as bytes.
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	if h.registers != nil {
+		data := appendHeader(nil, hyperLogLogMagic, uint64(h.precision), 1)
+		return append(data, h.registers...), nil
+	}
+	entries := make([]uint64, 0, len(h.sparse))
+	for index, r := range h.sparse {
+		entries = append(entries, uint64(index)<<6|uint64(r))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i] < entries[j] })
+	data := appendHeader(nil, hyperLogLogMagic, uint64(h.precision), 0, uint64(len(entries)))
+	previous := uint64(0)
+	for _, entry := range entries {
+		data = probabilistic.AppendUvarint(data, entry-previous)
+		previous = entry
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a sketch encoded by MarshalBinary.
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	d := newDecoder(data, hyperLogLogMagic)
+	precision, dense := d.Uvarint(), d.Uvarint()
+	if d.Err() != nil || precision < 4 || precision > 18 || dense > 1 {
+		return ErrInvalidSketch
+	}
+	sketch, _ := NewHyperLogLog(uint8(precision))
+	if dense == 1 {
+		sketch.sparse = nil
+		sketch.registers = append([]uint8(nil), d.Bytes(1<<precision)...)
+		for _, r := range sketch.registers {
+			if r > 64-sketch.precision+1 {
+				return ErrInvalidSketch
+			}
+		}
+	} else {
+		count := d.Uvarint()
+		if count > 1<<precision/4 {
+			return ErrInvalidSketch
+		}
+		entry := uint64(0)
+		for i := uint64(0); i < count && d.Err() == nil; i++ {
+			entry += d.Uvarint()
+			index, r := entry>>6, uint8(entry&0x3f)
+			if index >= 1<<sparsePrecision || r == 0 || r > 64-sparsePrecision+1 {
+				return ErrInvalidSketch
+			}
+			sketch.sparse[uint32(index)] = r
+		}
+	}
+	if err := d.Finish(); err != nil {
+		return err
+	}
+	*h = *sketch
+	return nil
+}
@@ -0,0 +1,103 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addItems(h *HyperLogLog, prefix string, n int) {
+	for i := 0; i < n; i++ {
+		h.AddString(fmt.Sprintf("%s-%d", prefix, i))
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{0, 1, 100, 1000, 10000, 100000, 1000000} {
+		h, err := NewHyperLogLog(14)
+		require.NoError(t, err, "Error creating sketch")
+		addItems(h, "event", n)
+		addItems(h, "event", n)
+		// Four standard errors of 1.04/sqrt(2^14).
+		assert.InDelta(t, n, h.Estimate(), math.Max(2, float64(n)*0.033), "%d items", n)
+		assert.Equal(t, n < 1<<14/4, h.Sparse(), "%d items", n)
+	}
+
+	_, err := NewHyperLogLog(3)
+	assert.Equal(t, ErrInvalidPrecision, err)
+	_, err = NewHyperLogLog(19)
+	assert.Equal(t, ErrInvalidPrecision, err)
+}
+
+func TestHyperLogLogSmallRange(t *testing.T) {
+	// Around 1 and 2 times the number of registers, where the raw
+	// estimate is biased upwards.
+	for _, precision := range []uint8{10, 14} {
+		m := 1 << precision
+		for _, n := range []int{m, 2 * m, 3 * m} {
+			h, err := NewHyperLogLog(precision)
+			require.NoError(t, err, "Error creating sketch")
+			addItems(h, "event", n)
+			// Four standard errors of 1.04/sqrt(2^precision).
+			tolerance := 4 * 1.04 / math.Sqrt(float64(m))
+			assert.InDelta(t, n, h.Estimate(), float64(n)*tolerance, "precision %d, %d items", precision, n)
+		}
+	}
+}
+
+func TestHyperLogLogSparseToDense(t *testing.T) {
+	sparse, err := NewHyperLogLog(10)
+	require.NoError(t, err, "Error creating sketch")
+	addItems(sparse, "event", 200)
+	require.True(t, sparse.Sparse())
+
+	// Registers built from the sparse representation are those that
+	// would have been updated directly.
+	dense := &HyperLogLog{precision: 10, registers: make([]uint8, 1<<10)}
+	addItems(dense, "event", 200)
+	sparse.toDense()
+	assert.Equal(t, dense.registers, sparse.registers)
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	for _, sizes := range [][2]int{{100, 200}, {100, 50000}, {50000, 100}, {50000, 80000}} {
+		a, err := NewHyperLogLog(12)
+		require.NoError(t, err, "Error creating sketch")
+		b, err := NewHyperLogLog(12)
+		require.NoError(t, err, "Error creating sketch")
+		addItems(a, "a", sizes[0])
+		addItems(b, "b", sizes[1])
+		addItems(b, "a", sizes[0]/2)
+		require.NoError(t, a.Merge(b), "Error merging sketches")
+		expected := float64(sizes[0] + sizes[1])
+		assert.InDelta(t, expected, a.Estimate(), expected*0.065, "sizes %v", sizes)
+	}
+
+	a, _ := NewHyperLogLog(12)
+	b, _ := NewHyperLogLog(13)
+	assert.Equal(t, ErrPrecisionMismatch, a.Merge(b))
+}
+
+func TestHyperLogLogMarshalBinary(t *testing.T) {
+	for _, n := range []int{0, 300, 10000} {
+		h, err := NewHyperLogLog(12)
+		require.NoError(t, err, "Error creating sketch")
+		addItems(h, "event", n)
+		data, err := h.MarshalBinary()
+		require.NoError(t, err, "Error encoding sketch")
+		assert.Equal(t, "HUHL\x01\x0c", string(data[:6]))
+
+		var decoded HyperLogLog
+		require.NoError(t, decoded.UnmarshalBinary(data), "Error decoding sketch")
+		assert.Equal(t, h, &decoded)
+		assert.Equal(t, h.Estimate(), decoded.Estimate())
+
+		assert.Equal(t, ErrInvalidSketch, decoded.UnmarshalBinary(data[:len(data)-1]))
+		assert.Equal(t, ErrInvalidSketch, decoded.UnmarshalBinary(append(data, 0)))
+	}
+	var decoded HyperLogLog
+	assert.Equal(t, ErrInvalidSketch, decoded.UnmarshalBinary([]byte("HUHL\x01\x03\x00\x00")))
+}